@@ -0,0 +1,269 @@
+// Package audit 追加写入的审计日志。每条记录包含上一条记录的 HMAC，
+// 修改、删除或插入任意一条记录都会使之后的校验失败；不知道密钥无法重新计算整条链
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+)
+
+// 审计事件
+const (
+	EventGeneration  = "generation"
+	EventAdmin       = "admin"
+	EventAdminDenied = "admin.denied"
+)
+
+// GenesisHash 第一条记录的 prev_hash
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Actor 操作者：调用方租户、凭据指纹和来源地址
+type Actor struct {
+	Tenant       string `json:"tenant,omitempty"`
+	APIKey       string `json:"api_key,omitempty"` // Authorization 凭据的指纹，不记录凭据本身
+	Admin        bool   `json:"admin,omitempty"`
+	IP           string `json:"ip,omitempty"`
+	ForwardedFor string `json:"forwarded_for,omitempty"`
+	UserAgent    string `json:"user_agent,omitempty"`
+}
+
+// Record 一条审计记录。Hash 为 HMAC-SHA256(audit.hmac_key, PrevHash || 不含 hash 字段的记录 JSON)
+type Record struct {
+	Seq       int64                  `json:"seq"`
+	Time      time.Time              `json:"time"`
+	Event     string                 `json:"event"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Actor     Actor                  `json:"actor"`
+	Action    string                 `json:"action,omitempty"` // 管理操作：方法和路径
+	Status    int                    `json:"status,omitempty"`
+	Provider  string                 `json:"provider,omitempty"`
+	Model     string                 `json:"model,omitempty"`
+	Prompt    string                 `json:"prompt,omitempty"`
+	Outcome   string                 `json:"outcome,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash,omitempty"`
+}
+
+// Logger 审计日志写入器，按大小轮转且不删除轮转后的文件，哈希链跨文件连续
+type Logger struct {
+	mu     sync.Mutex
+	path   string
+	key    []byte
+	file   *logging.RotatingFile
+	seq    int64
+	prev   string
+	redact bool
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+)
+
+// Open 打开审计日志，从最近一条记录恢复序号和哈希链
+func Open(cfg config.AuditConfig) (*Logger, error) {
+	path := cfg.GetFilePath()
+	if cfg.HMACKey == "" {
+		return nil, errors.New("open audit log: audit.hmac_key is not set")
+	}
+	l := &Logger{path: path, key: []byte(cfg.HMACKey), prev: GenesisHash, redact: cfg.RedactPrompts}
+
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		last, err := lastRecord(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.prev = last.Seq, last.Hash
+			break
+		}
+	}
+
+	f, err := logging.NewRotatingFile(path, cfg.MaxSizeMB, logging.KeepAllBackups, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Init 打开审计日志并设为 Log 使用的全局实例
+func Init(cfg config.AuditConfig) (*Logger, error) {
+	l, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defaultMu.Lock()
+	defaultLogger = l
+	defaultMu.Unlock()
+	return l, nil
+}
+
+// Default 返回全局审计日志，未开启时返回 nil
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// Log 写入全局审计日志，未开启时忽略；写入失败只记录错误日志，不影响请求
+func Log(r Record) {
+	l := Default()
+	if l == nil {
+		return
+	}
+	if err := l.Append(r); err != nil {
+		logging.Component("audit").Error("failed to write audit record", "event", r.Event, "request_id", r.RequestID, "error", err)
+	}
+}
+
+// Append 补全序号、时间和哈希后写入一条记录
+func (l *Logger) Append(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	if l.redact && r.Prompt != "" {
+		r.Prompt = HashPrompt(r.Prompt)
+	}
+	r.Seq = l.seq + 1
+	r.PrevHash = l.prev
+	r.Hash = ""
+
+	line, hash, err := encode(l.key, r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return err
+	}
+	l.seq, l.prev = r.Seq, hash
+	return nil
+}
+
+// Close 将缓冲写入磁盘并关闭文件
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.file.Sync(), l.file.Close())
+}
+
+// Path 返回当前审计日志文件路径
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Fingerprint 凭据指纹：SHA-256 的前 10 个十六进制字符，与密钥池 Key 指纹的算法相同
+func Fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:5])
+}
+
+// HashPrompt 返回提示词的摘要，用于 audit.redact_prompts
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// encode 序列化记录并在末尾追加 hash 字段，返回整行（含换行）和哈希
+func encode(key []byte, r Record) ([]byte, string, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := chainHash(key, r.PrevHash, body)
+	line := make([]byte, 0, len(body)+len(hash)+12)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+	return line, hash, nil
+}
+
+func chainHash(key []byte, prev string, body []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(prev))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Files 按时间顺序返回审计日志的全部文件：轮转后的备份在前，当前文件在最后
+func Files(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	backups, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(backups)
+	if _, err := os.Stat(path); err == nil {
+		backups = append(backups, path)
+	}
+	return backups, nil
+}
+
+// lastRecord 读取文件中最后一条可解析的记录，文件为空时返回 nil
+func lastRecord(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		if len(lines[i]) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(lines[i], &r); err != nil || r.Hash == "" {
+			// 崩溃时写了一半的行：从上一条记录继续，校验时会报告该行
+			continue
+		}
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// scan 逐行读取文件，回调返回 false 时停止
+func scan(path string, fn func(lineNo int, line []byte) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if !fn(lineNo, scanner.Bytes()) {
+			break
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"svg-generator/internal/config"
+)
+
+const testKey = "audit-test-hmac-key-0123456789abcdef"
+
+// writeLog 写入 n 条记录并返回日志路径，max_size_mb 为 1 时每条记录较大以触发轮转
+func writeLog(t *testing.T, n int, promptSize int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(config.AuditConfig{FilePath: path, MaxSizeMB: 1, HMACKey: testKey})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	prompt := strings.Repeat("x", promptSize)
+	for i := 0; i < n; i++ {
+		if err := l.Append(Record{Event: EventGeneration, Prompt: prompt}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return path
+}
+
+func TestOpenRequiresKey(t *testing.T) {
+	if _, err := Open(config.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.jsonl")}); err == nil {
+		t.Fatal("Open without hmac_key succeeded, want error")
+	}
+}
+
+func TestVerifyFiles(t *testing.T) {
+	path := writeLog(t, 5, 10)
+
+	result, err := VerifyFiles(path, VerifyOptions{Key: []byte(testKey)})
+	if err != nil {
+		t.Fatalf("VerifyFiles: %v", err)
+	}
+	if !result.Valid || !result.Anchored || result.Records != 5 || result.LastSeq != 5 {
+		t.Fatalf("VerifyFiles = %+v, want 5 valid anchored records", result)
+	}
+
+	result, err = VerifyFiles(path, VerifyOptions{Key: []byte("another-key-0123456789abcdef0123")})
+	if err != nil {
+		t.Fatalf("VerifyFiles: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("VerifyFiles with the wrong key succeeded")
+	}
+}
+
+func TestVerifyFilesDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(lines [][]byte) [][]byte
+	}{
+		{"modified record", func(lines [][]byte) [][]byte {
+			lines[2] = bytes.Replace(lines[2], []byte(`"event":"generation"`), []byte(`"event":"admin"`), 1)
+			return lines
+		}},
+		{"removed record", func(lines [][]byte) [][]byte {
+			return append(lines[:2], lines[3:]...)
+		}},
+		{"reordered records", func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}},
+		{"removed head", func(lines [][]byte) [][]byte {
+			return lines[1:]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, 5, 10)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.modify(bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")))
+			if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			result, err := VerifyFiles(path, VerifyOptions{Key: []byte(testKey)})
+			if err != nil {
+				t.Fatalf("VerifyFiles: %v", err)
+			}
+			if result.Valid {
+				t.Fatalf("VerifyFiles = valid, want failure")
+			}
+		})
+	}
+}
+
+func TestVerifyFilesAllowUnanchored(t *testing.T) {
+	path := writeLog(t, 5, 10)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if err := os.WriteFile(path, bytes.Join(lines[2:], nil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := VerifyFiles(path, VerifyOptions{Key: []byte(testKey), AllowUnanchored: true})
+	if err != nil {
+		t.Fatalf("VerifyFiles: %v", err)
+	}
+	if !result.Valid || result.Anchored || result.FirstSeq != 3 {
+		t.Fatalf("VerifyFiles = %+v, want valid unanchored log starting at seq 3", result)
+	}
+}
+
+func TestRotatedFilesAreKept(t *testing.T) {
+	// 每条记录约 300KB，max_size_mb 为 1 时 20 条记录轮转多次
+	path := writeLog(t, 20, 300<<10)
+	files, err := Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 5 {
+		t.Fatalf("got %d files, want every rotated file to be kept", len(files))
+	}
+	result, err := VerifyFiles(path, VerifyOptions{Key: []byte(testKey)})
+	if err != nil {
+		t.Fatalf("VerifyFiles: %v", err)
+	}
+	if !result.Valid || !result.Anchored || result.Records != 20 {
+		t.Fatalf("VerifyFiles = %+v, want 20 valid anchored records", result)
+	}
+}
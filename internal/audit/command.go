@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"svg-generator/internal/config"
+)
+
+// RunCommand 处理 `svg-generator audit verify` 子命令，离线校验审计日志（含轮转后的文件），返回进程退出码。
+// HMAC 密钥始终从配置读取（audit.hmac_key、audit.hmac_key_file 或 SVGGEN_AUDIT_HMAC_KEY）
+func RunCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(stderr, "usage: svg-generator audit verify [--file path] [--config path] [--allow-unanchored]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	defaultConfig := os.Getenv("CONFIG_PATH")
+	if defaultConfig == "" {
+		defaultConfig = "config.yaml"
+	}
+	file := fs.String("file", "", "audit log file (defaults to audit.file_path from the configuration)")
+	configPath := fs.String("config", defaultConfig, "configuration file providing audit.hmac_key and the audit log location")
+	allowUnanchored := fs.Bool("allow-unanchored", false, "accept a log whose first record is not the genesis record (earlier files were archived)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	if cfg.Audit.HMACKey == "" {
+		fmt.Fprintln(stderr, "audit.hmac_key is not set in the configuration")
+		return 1
+	}
+	path := *file
+	if path == "" {
+		path = cfg.Audit.GetFilePath()
+	}
+
+	result, err := VerifyFiles(path, VerifyOptions{Key: []byte(cfg.Audit.HMACKey), AllowUnanchored: *allowUnanchored})
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read audit log: %v\n", err)
+		return 1
+	}
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Fprintln(stdout, string(out))
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// Details 管理操作的审计详情，由处理器在处理过程中附加
+type Details struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+type detailsKey struct{}
+
+// WithDetails 返回可附加审计详情的上下文
+func WithDetails(ctx context.Context) (context.Context, *Details) {
+	d := &Details{}
+	return context.WithValue(ctx, detailsKey{}, d), d
+}
+
+// Annotate 为当前请求的审计记录附加详情，上下文中没有 Details 时忽略。不要附加凭据原文
+func Annotate(ctx context.Context, key string, value interface{}) {
+	d, _ := ctx.Value(detailsKey{}).(*Details)
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if d.values == nil {
+		d.values = make(map[string]interface{})
+	}
+	d.values[key] = value
+	d.mu.Unlock()
+}
+
+// Map 返回已附加的详情
+func (d *Details) Map() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.values
+}
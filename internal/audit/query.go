@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Filter 审计记录查询条件，零值字段不过滤
+type Filter struct {
+	From      time.Time
+	To        time.Time
+	Event     string
+	Tenant    string
+	RequestID string
+	Limit     int
+}
+
+func (f Filter) match(r Record) bool {
+	return (f.From.IsZero() || !r.Time.Before(f.From)) &&
+		(f.To.IsZero() || r.Time.Before(f.To)) &&
+		(f.Event == "" || r.Event == f.Event) &&
+		(f.Tenant == "" || r.Actor.Tenant == f.Tenant) &&
+		(f.RequestID == "" || r.RequestID == f.RequestID)
+}
+
+// Query 按条件查询记录，返回最新的 Limit 条（按时间倒序）以及是否被截断
+func (l *Logger) Query(f Filter) ([]Record, bool, error) {
+	files, err := Files(l.path)
+	if err != nil {
+		return nil, false, err
+	}
+	var out []Record
+	truncated := false
+	for _, file := range files {
+		err := scan(file, func(_ int, line []byte) bool {
+			var r Record
+			if json.Unmarshal(line, &r) != nil || !f.match(r) {
+				return true
+			}
+			out = append(out, r)
+			if f.Limit > 0 && len(out) > f.Limit {
+				out = out[1:]
+				truncated = true
+			}
+			return true
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, truncated, nil
+}
+
+// VerifyResult 哈希链校验结果
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Files    int    `json:"files"`
+	Records  int64  `json:"records"`
+	FirstSeq int64  `json:"first_seq,omitempty"`
+	LastSeq  int64  `json:"last_seq,omitempty"`
+	LastHash string `json:"last_hash,omitempty"`
+	// Anchored 第一条记录是否为创世记录。轮转后的文件不会被自动删除，
+	// 不是创世记录说明开头的文件被移走，只有校验归档后留下的文件时才允许
+	Anchored bool           `json:"anchored"`
+	Error    *VerifyFailure `json:"error,omitempty"`
+}
+
+// VerifyFailure 第一处校验失败的位置
+type VerifyFailure struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Seq    int64  `json:"seq,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Verify 按时间顺序校验全部文件：每条记录的哈希、与上一条记录的链接和序号连续性。
+// 只校验到开始校验时的最后一条记录，校验期间继续写入的记录不受影响
+func (l *Logger) Verify() (VerifyResult, error) {
+	l.mu.Lock()
+	until, expected := l.seq, l.prev
+	l.mu.Unlock()
+
+	result, err := VerifyFiles(l.path, VerifyOptions{Key: l.key, Until: until})
+	if err == nil && result.Valid && until > 0 && (result.LastSeq != until || result.LastHash != expected) {
+		// 末尾的记录被截断删除
+		result.Valid = false
+		result.Error = &VerifyFailure{File: filepath.Base(l.path), Seq: result.LastSeq + 1,
+			Reason: fmt.Sprintf("log ends at seq %d but %d records were written, trailing records were removed", result.LastSeq, until)}
+	}
+	return result, err
+}
+
+// VerifyOptions 离线校验的参数
+type VerifyOptions struct {
+	// Key 写入时使用的 audit.hmac_key
+	Key []byte
+	// Until 大于 0 时校验到该序号为止
+	Until int64
+	// AllowUnanchored 允许第一条记录不是创世记录，用于开头的文件已归档的情况
+	AllowUnanchored bool
+}
+
+// VerifyFiles 校验 path 对应的审计日志文件。不需要打开 Logger，可用于离线校验归档文件
+func VerifyFiles(path string, opts VerifyOptions) (VerifyResult, error) {
+	until := opts.Until
+	files, err := Files(path)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+
+	result := VerifyResult{Valid: true, Files: len(files)}
+	var prev string
+	var seq int64
+	for _, file := range files {
+		err := scan(file, func(lineNo int, line []byte) bool {
+			if until > 0 && result.Records > 0 && seq >= until {
+				return false
+			}
+			fail := func(reason string, s int64) bool {
+				result.Valid = false
+				result.Error = &VerifyFailure{File: filepath.Base(file), Line: lineNo, Seq: s, Reason: reason}
+				return false
+			}
+
+			var r Record
+			if err := json.Unmarshal(line, &r); err != nil {
+				return fail("record is not valid JSON", 0)
+			}
+			suffix := []byte(fmt.Sprintf(`,"hash":"%s"}`, r.Hash))
+			if r.Hash == "" || !bytes.HasSuffix(line, suffix) {
+				return fail("hash field missing or not last", r.Seq)
+			}
+			body := append(append([]byte{}, line[:len(line)-len(suffix)]...), '}')
+			if !hmac.Equal([]byte(chainHash(opts.Key, r.PrevHash, body)), []byte(r.Hash)) {
+				return fail("hash mismatch, record was modified or the HMAC key is wrong", r.Seq)
+			}
+
+			if result.Records == 0 {
+				result.FirstSeq = r.Seq
+				result.Anchored = r.PrevHash == GenesisHash && r.Seq == 1
+				if !result.Anchored && !opts.AllowUnanchored {
+					return fail(fmt.Sprintf("log starts at seq %d instead of the genesis record, earlier files were removed", r.Seq), r.Seq)
+				}
+			} else {
+				if r.PrevHash != prev {
+					return fail("prev_hash does not match previous record, records were removed or reordered", r.Seq)
+				}
+				if r.Seq != seq+1 {
+					return fail(fmt.Sprintf("sequence gap: expected %d, got %d", seq+1, r.Seq), r.Seq)
+				}
+			}
+			prev, seq = r.Hash, r.Seq
+			result.Records++
+			return true
+		})
+		if err != nil {
+			return result, err
+		}
+		if !result.Valid || (until > 0 && seq >= until) {
+			break
+		}
+	}
+	result.LastSeq, result.LastHash = seq, prev
+	return result, nil
+}
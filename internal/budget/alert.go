@@ -0,0 +1,97 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/pkg/utils"
+)
+
+// 告警级别
+const (
+	LevelSoft = "soft"
+	LevelHard = "hard"
+)
+
+// Alert 支出跨过上限时发送的告警，同时作为 webhook 的 JSON 请求体
+type Alert struct {
+	Event       string    `json:"event"` // budget.soft_limit_exceeded | budget.hard_limit_exceeded
+	Scope       string    `json:"scope"`
+	Tenant      string    `json:"tenant,omitempty"`
+	Spend       float64   `json:"spend"`
+	Limit       float64   `json:"limit"`
+	Currency    string    `json:"currency"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Time        time.Time `json:"time"`
+}
+
+// sending 正在推送的告警，退出时由 Drain 等待
+var sending sync.WaitGroup
+
+// Drain 等待正在推送的告警完成，ctx 结束时放弃等待
+func Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify 记录告警日志，配置了 webhook 时异步推送，推送失败只记录日志不重试
+func notify(cfg config.BudgetAlertConfig, alert Alert) {
+	logger := logging.Component("budget")
+	logger.Warn("budget limit exceeded",
+		"event", alert.Event, "scope", alert.Scope, "tenant", alert.Tenant,
+		"spend", alert.Spend, "limit", alert.Limit, "currency", alert.Currency)
+	if alert.Event == "budget."+LevelSoft+"_limit_exceeded" {
+		recordAction(alert.Scope, "soft_alert")
+	} else {
+		recordAction(alert.Scope, "hard_alert")
+	}
+
+	if cfg.WebhookURL == "" {
+		return
+	}
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.GetTimeout())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			logger.Error("failed to create budget alert request", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := utils.HTTPClient.Do(req)
+		if err != nil {
+			logger.Error("failed to send budget alert", "event", alert.Event, "error", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.Error("budget alert webhook returned error", "event", alert.Event, "status", resp.StatusCode)
+		}
+	}()
+}
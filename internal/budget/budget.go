@@ -0,0 +1,323 @@
+// Package budget 按租户和全局累计当期支出，执行软上限告警和硬上限拒绝、降级
+package budget
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+	_ "time/tzdata" // 容器镜像可能没有系统时区数据
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/lifecycle"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/usage"
+)
+
+// 预算检查结果
+const (
+	ActionAllow     = "allow"
+	ActionReject    = "reject"
+	ActionDowngrade = "downgrade"
+)
+
+// 上限作用范围
+const (
+	ScopeGlobal = "global"
+	ScopeTenant = "tenant"
+)
+
+// Decision 预算检查结果，Action 为 allow 时其余字段为空
+type Decision struct {
+	Action   string
+	Scope    string
+	Tenant   string
+	Provider string // 降级使用的 Provider
+	Spend    float64
+	Limit    float64
+	ResetAt  time.Time
+}
+
+// LimitStatus 单个范围的当期支出和上限
+type LimitStatus struct {
+	Tenant      string  `json:"tenant,omitempty"`
+	Spend       float64 `json:"spend"`
+	SoftLimit   float64 `json:"soft_limit,omitempty"`
+	HardLimit   float64 `json:"hard_limit,omitempty"`
+	OnExceed    string  `json:"on_exceed,omitempty"`
+	DowngradeTo string  `json:"downgrade_to,omitempty"`
+	State       string  `json:"state"` // ok | soft_exceeded | hard_exceeded
+}
+
+// Status 当期预算使用情况
+type Status struct {
+	Enabled     bool          `json:"enabled"`
+	Period      string        `json:"period"`
+	Timezone    string        `json:"timezone"`
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Currency    string        `json:"currency"`
+	Global      LimitStatus   `json:"global"`
+	Tenants     []LimitStatus `json:"tenants"`
+}
+
+// Tracker 当期支出累计。启动和进入新周期时从用量台账重新统计，之后随每条台账记录增量累加
+type Tracker struct {
+	mu      sync.Mutex
+	ledger  *usage.Ledger
+	period  string // 周期与时区，变化时重新统计
+	start   time.Time
+	end     time.Time
+	global  float64
+	tenants map[string]float64
+	alerted map[string]bool // 当期已发送的告警：scope/tenant/level
+}
+
+var defaultTracker *Tracker
+
+// Init 创建全局 Tracker 并订阅用量台账，退出时等待正在推送的告警
+func Init(ledger *usage.Ledger) *Tracker {
+	t := &Tracker{ledger: ledger}
+	defaultTracker = t
+	usage.OnRecord(t.add)
+	lifecycle.OnShutdown("budget-alerts", Drain)
+	return t
+}
+
+// Check 使用全局 Tracker 检查预算，未初始化或未开启预算时放行
+func Check(tenant, provider string) Decision {
+	if defaultTracker == nil {
+		return Decision{Action: ActionAllow}
+	}
+	return defaultTracker.Check(tenant, provider)
+}
+
+// Default 返回全局 Tracker，未初始化时返回 nil
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// Check 检查租户当期支出是否达到硬上限：任一范围要求拒绝时拒绝；
+// 要求降级且请求的 Provider 不是降级目标时返回降级，请求已使用降级目标时放行
+func (t *Tracker) Check(tenant, provider string) Decision {
+	cfg := config.Get().Budgets
+	if !cfg.Enabled {
+		return Decision{Action: ActionAllow}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.syncLocked(cfg, time.Now())
+
+	scopes := []struct {
+		scope string
+		limit config.BudgetLimit
+		spend float64
+	}{
+		{ScopeTenant, cfg.TenantLimit(tenant), t.tenants[tenant]},
+		{ScopeGlobal, cfg.Global, t.global},
+	}
+
+	decision := Decision{Action: ActionAllow}
+	for _, s := range scopes {
+		if s.limit.HardLimit <= 0 || s.spend < s.limit.HardLimit {
+			continue
+		}
+		d := Decision{Scope: s.scope, Spend: round(s.spend), Limit: s.limit.HardLimit, ResetAt: t.end}
+		if s.scope == ScopeTenant {
+			d.Tenant = tenant
+		}
+		if s.limit.OnExceed != ActionDowngrade {
+			d.Action = ActionReject
+			return d
+		}
+		if s.limit.DowngradeTo == provider {
+			continue
+		}
+		if decision.Action == ActionAllow {
+			d.Action = ActionDowngrade
+			d.Provider = s.limit.DowngradeTo
+			decision = d
+		}
+	}
+	return decision
+}
+
+// add 累加一条台账记录的成本，跨过上限时发送告警
+func (t *Tracker) add(e usage.Entry) {
+	cfg := config.Get().Budgets
+	if !cfg.Enabled || e.Cost <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.syncLocked(cfg, time.Now())
+	if e.Time.Before(t.start) {
+		t.mu.Unlock()
+		return
+	}
+	t.global += e.Cost
+	t.tenants[e.Tenant] += e.Cost
+
+	var alerts []Alert
+	alerts = t.crossedLocked(alerts, ScopeGlobal, "", cfg.Global, t.global, e.Currency)
+	alerts = t.crossedLocked(alerts, ScopeTenant, e.Tenant, cfg.TenantLimit(e.Tenant), t.tenants[e.Tenant], e.Currency)
+	t.mu.Unlock()
+
+	for _, alert := range alerts {
+		notify(cfg.Alerts, alert)
+	}
+}
+
+// crossedLocked 检查支出是否首次跨过软上限或硬上限，每个周期每个级别只告警一次
+func (t *Tracker) crossedLocked(alerts []Alert, scope, tenant string, limit config.BudgetLimit, spend float64, currency string) []Alert {
+	levels := []struct {
+		level string
+		value float64
+	}{
+		{LevelSoft, limit.SoftLimit},
+		{LevelHard, limit.HardLimit},
+	}
+	for _, l := range levels {
+		key := scope + "/" + tenant + "/" + l.level
+		if l.value <= 0 || spend < l.value || t.alerted[key] {
+			continue
+		}
+		t.alerted[key] = true
+		alerts = append(alerts, Alert{
+			Event:       "budget." + l.level + "_limit_exceeded",
+			Scope:       scope,
+			Tenant:      tenant,
+			Spend:       round(spend),
+			Limit:       l.value,
+			Currency:    currency,
+			Period:      t.period,
+			PeriodStart: t.start,
+			PeriodEnd:   t.end,
+			Time:        time.Now().UTC(),
+		})
+	}
+	return alerts
+}
+
+// syncLocked 进入新周期或周期配置变化时从台账重新统计当期支出，调用方需持有 t.mu
+func (t *Tracker) syncLocked(cfg config.BudgetsConfig, now time.Time) {
+	loc := cfg.GetLocation()
+	period := cfg.GetPeriod() + "@" + loc.String()
+	start, end := periodBounds(now, cfg.GetPeriod(), loc)
+	if period == t.period && start.Equal(t.start) {
+		return
+	}
+
+	t.period = period
+	t.start, t.end = start, end
+	t.global = 0
+	t.tenants = make(map[string]float64)
+	t.alerted = make(map[string]bool)
+	if t.ledger == nil {
+		return
+	}
+	entries, err := t.ledger.Query(start, end, nil)
+	if err != nil {
+		logging.Component("budget").Error("failed to load current period spend from usage ledger", "error", err)
+		return
+	}
+	for _, e := range entries {
+		t.global += e.Cost
+		t.tenants[e.Tenant] += e.Cost
+	}
+	// 台账中的支出已经跨过的上限在跨过时告警过，只标记不再发送，避免重启后重复告警
+	t.crossedLocked(nil, ScopeGlobal, "", cfg.Global, t.global, "")
+	for name, spend := range t.tenants {
+		t.crossedLocked(nil, ScopeTenant, name, cfg.TenantLimit(name), spend, "")
+	}
+	logging.Component("budget").Info("budget period started",
+		"period", period, "start", start, "end", end, "spend", round(t.global), "tenants", len(t.tenants))
+}
+
+// Status 返回当期支出：全局、单独配置了上限的租户和当期有支出的租户
+func (t *Tracker) Status() Status {
+	cfg := config.Get().Budgets
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.syncLocked(cfg, time.Now())
+
+	s := Status{
+		Enabled:     cfg.Enabled,
+		Period:      cfg.GetPeriod(),
+		Timezone:    cfg.GetLocation().String(),
+		PeriodStart: t.start,
+		PeriodEnd:   t.end,
+		Currency:    config.Get().Usage.GetCurrency(),
+		Global:      limitStatus("", cfg.Global, t.global),
+		Tenants:     []LimitStatus{},
+	}
+	names := make(map[string]bool, len(t.tenants)+len(cfg.Tenants))
+	for name := range t.tenants {
+		names[name] = true
+	}
+	for name := range cfg.Tenants {
+		names[name] = true
+	}
+	for name := range names {
+		s.Tenants = append(s.Tenants, limitStatus(name, cfg.TenantLimit(name), t.tenants[name]))
+	}
+	sort.Slice(s.Tenants, func(i, j int) bool {
+		if s.Tenants[i].Spend != s.Tenants[j].Spend {
+			return s.Tenants[i].Spend > s.Tenants[j].Spend
+		}
+		return s.Tenants[i].Tenant < s.Tenants[j].Tenant
+	})
+	return s
+}
+
+func limitStatus(tenant string, limit config.BudgetLimit, spend float64) LimitStatus {
+	s := LimitStatus{
+		Tenant:      tenant,
+		Spend:       round(spend),
+		SoftLimit:   limit.SoftLimit,
+		HardLimit:   limit.HardLimit,
+		OnExceed:    limit.OnExceed,
+		DowngradeTo: limit.DowngradeTo,
+		State:       "ok",
+	}
+	if s.OnExceed == "" && s.HardLimit > 0 {
+		s.OnExceed = ActionReject
+	}
+	switch {
+	case limit.HardLimit > 0 && spend >= limit.HardLimit:
+		s.State = "hard_exceeded"
+	case limit.SoftLimit > 0 && spend >= limit.SoftLimit:
+		s.State = "soft_exceeded"
+	}
+	return s
+}
+
+// periodBounds 返回 now 所在自然周期的起止时间（UTC），weekly 从周一开始
+func periodBounds(now time.Time, period string, loc *time.Location) (start, end time.Time) {
+	local := now.In(loc)
+	y, m, d := local.Date()
+	switch period {
+	case "daily":
+		start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 0, 1)
+	case "weekly":
+		offset := (int(local.Weekday()) + 6) % 7
+		start = time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 0, 7)
+	default:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	}
+	return start.UTC(), end.UTC()
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// recordAction 记录预算动作指标
+func recordAction(scope, action string) {
+	metrics.BudgetActions.Inc(scope, action)
+}
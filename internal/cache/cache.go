@@ -0,0 +1,61 @@
+// Package cache 登记进程内的缓存，供管理接口统一清空
+package cache
+
+import (
+	"sort"
+	"sync"
+)
+
+// Flusher 可清空的缓存，Flush 返回清除的条目数
+type Flusher interface {
+	Flush() int
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Flusher{}
+)
+
+// Register 以名称登记缓存，同名时替换
+func Register(name string, c Flusher) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = c
+}
+
+// Names 返回已登记的缓存名称
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Flush 清空指定名称的缓存，names 为空时清空全部；返回每个缓存清除的条目数，未登记的名称不出现在结果中
+func Flush(names ...string) map[string]int {
+	mu.Lock()
+	targets := make(map[string]Flusher, len(registry))
+	if len(names) == 0 {
+		for name, c := range registry {
+			targets[name] = c
+		}
+	} else {
+		for _, name := range names {
+			if c, ok := registry[name]; ok {
+				targets[name] = c
+			}
+		}
+	}
+	mu.Unlock()
+
+	flushed := make(map[string]int, len(targets))
+	for name, c := range targets {
+		flushed[name] = c.Flush()
+	}
+	return flushed
+}
@@ -0,0 +1,356 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+)
+
+// redisPoolSize 空闲连接池大小
+const redisPoolSize = 8
+
+// Redis 不可用时的重试间隔，连续失败时从最小值开始翻倍
+const (
+	redisMinBackoff = time.Second
+	redisMaxBackoff = 30 * time.Second
+)
+
+// errRedisNil Redis 返回的空值
+var errRedisNil = errors.New("redis: nil")
+
+// ErrRedisUnavailable Redis 连接失败后的重试间隔内直接返回的错误，不再尝试连接
+var ErrRedisUnavailable = errors.New("redis: unavailable, waiting before reconnecting")
+
+// Redis 使用 RESP 协议的最小 Redis 客户端，只实现缓存需要的 GET、SET、SCAN 和 DEL。
+// 键统一加上配置的前缀，Flush 只删除带前缀的键。连接失败后按退避间隔短路，
+// 期间的操作立即返回 ErrRedisUnavailable，不会让每个请求都等待连接超时
+type Redis struct {
+	cfg  config.RedisConfig
+	tls  *tls.Config
+	idle chan *redisConn
+
+	mu         sync.Mutex
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	backoff    time.Duration // 当前的重试间隔，为 0 表示连接正常
+	retryAt    time.Time
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRedis 创建 Redis 缓存并检查连通性；连接失败不阻止启动，后续操作按未命中处理。
+// 只在 TLS 配置无效时返回错误
+func NewRedis(cfg config.RedisConfig) (*Redis, error) {
+	c := &Redis{
+		cfg:        cfg,
+		idle:       make(chan *redisConn, redisPoolSize),
+		minBackoff: redisMinBackoff,
+		maxBackoff: redisMaxBackoff,
+	}
+	if cfg.TLS {
+		tlsCfg, err := redisTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.tls = tlsCfg
+	}
+	// 连接失败时由 failed 记录警告并进入退避
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetTimeout())
+	defer cancel()
+	c.do(ctx, "PING")
+	return c, nil
+}
+
+// redisTLSConfig 按配置创建 TLS 配置：校验服务端证书，tls_ca_file 为空时使用系统根证书
+func redisTLSConfig(cfg config.RedisConfig) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis addr: %w", err)
+	}
+	tlsCfg := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis tls_ca_file: no certificates found in %s", cfg.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+// Get 读取缓存
+func (c *Redis) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := c.do(ctx, "GET", c.cfg.GetKeyPrefix()+key)
+	if errors.Is(err, errRedisNil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	value, _ := reply.(string)
+	return value, true, nil
+}
+
+// Set 写入缓存，ttl 为 0 表示不过期
+func (c *Redis) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []string{"SET", c.cfg.GetKeyPrefix() + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Flush 删除带前缀的全部键
+func (c *Redis) Flush() int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deleted := 0
+	cursor := "0"
+	for {
+		reply, err := c.do(ctx, "SCAN", cursor, "MATCH", c.cfg.GetKeyPrefix()+"*", "COUNT", "500")
+		if err != nil {
+			logging.Component("cache").Warn("redis flush failed", "addr", c.cfg.Addr, "error", err)
+			return deleted
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return deleted
+		}
+		cursor, _ = parts[0].(string)
+		keys, _ := parts[1].([]interface{})
+		if len(keys) > 0 {
+			args := make([]string, 0, len(keys)+1)
+			args = append(args, "DEL")
+			for _, k := range keys {
+				if s, ok := k.(string); ok {
+					args = append(args, s)
+				}
+			}
+			if n, err := c.do(ctx, args...); err == nil {
+				count, _ := n.(int64)
+				deleted += int(count)
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return deleted
+		}
+	}
+}
+
+// Close 关闭空闲连接
+func (c *Redis) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do 执行一条命令；出错的连接直接丢弃，不放回连接池。
+// 退避期内直接返回 ErrRedisUnavailable，连接或网络错误时进入退避
+func (c *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	if !c.allow() {
+		return nil, ErrRedisUnavailable
+	}
+	conn, err := c.conn(ctx)
+	if err != nil {
+		c.failed(ctx, err)
+		return nil, err
+	}
+	deadline := time.Now().Add(c.cfg.GetTimeout())
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	reply, err := conn.command(args...)
+	var redisErr redisError
+	if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, &redisErr) {
+		conn.Close()
+		c.failed(ctx, err)
+		return nil, err
+	}
+	c.recovered()
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// allow 判断是否可以尝试连接：退避期内返回 false；退避期结束时放行一次探测，
+// 并把下一次重试推迟一个间隔，探测完成前其他操作仍然短路
+func (c *Redis) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backoff == 0 {
+		return true
+	}
+	now := time.Now()
+	if now.Before(c.retryAt) {
+		return false
+	}
+	c.retryAt = now.Add(c.backoff)
+	return true
+}
+
+// failed 记录一次连接失败并延长退避间隔；调用方的上下文已结束时不视为 Redis 故障
+func (c *Redis) failed(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backoff == 0 {
+		c.backoff = c.minBackoff
+		logging.Component("cache").Warn("redis is unavailable, cache lookups will miss until it recovers",
+			"addr", c.cfg.Addr, "retry_in", c.backoff.String(), "error", err)
+	} else if c.backoff *= 2; c.backoff > c.maxBackoff {
+		c.backoff = c.maxBackoff
+	}
+	c.retryAt = time.Now().Add(c.backoff)
+}
+
+// recovered 命令成功后结束退避
+func (c *Redis) recovered() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backoff != 0 {
+		logging.Component("cache").Info("redis recovered", "addr", c.cfg.Addr)
+		c.backoff = 0
+		c.retryAt = time.Time{}
+	}
+}
+
+// conn 从连接池获取连接，池为空时新建并完成认证和选库
+func (c *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: c.cfg.GetTimeout()}
+	var nc net.Conn
+	var err error
+	if c.tls != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.cfg.Addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis dial: %w", err)
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	conn.SetDeadline(time.Now().Add(c.cfg.GetTimeout()))
+	if c.cfg.Password != "" {
+		if _, err := conn.command("AUTH", c.cfg.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := conn.command("SELECT", strconv.Itoa(c.cfg.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError Redis 返回的错误回复，连接仍然可用
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// command 发送一条命令并读取回复
+func (conn *redisConn) command(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return conn.readReply()
+}
+
+// readReply 解析一条 RESP 回复：简单字符串和批量字符串返回 string，整数返回 int64，数组返回 []interface{}
+func (conn *redisConn) readReply() (interface{}, error) {
+	line, err := conn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(conn.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := conn.readReply()
+			if err != nil && !errors.Is(err, errRedisNil) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"svg-generator/internal/config"
+)
+
+// fakeRedis 实现缓存用到的 RESP 命令的测试服务器
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func startFakeRedis(t *testing.T, ln net.Listener, password string) *fakeRedis {
+	t.Helper()
+	s := &fakeRedis{ln: ln, password: password, data: map[string]string{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		s.mu.Lock()
+		switch cmd {
+		case "AUTH":
+			if args[1] == s.password {
+				authed = true
+				io.WriteString(conn, "+OK\r\n")
+			} else {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+			}
+		case "PING":
+			io.WriteString(conn, "+PONG\r\n")
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "SET":
+			s.data[args[1]] = args[2]
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			if v, ok := s.data[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		case "SCAN":
+			prefix := strings.TrimSuffix(args[3], "*")
+			var keys []string
+			for k := range s.data {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+			for _, k := range keys {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+			}
+		case "DEL":
+			n := 0
+			for _, k := range args[1:] {
+				if _, ok := s.data[k]; ok {
+					delete(s.data, k)
+					n++
+				}
+			}
+			fmt.Fprintf(conn, ":%d\r\n", n)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		s.mu.Unlock()
+	}
+}
+
+// readCommand 读取客户端发送的 RESP 数组命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func newTestRedis(t *testing.T, cfg config.RedisConfig) *Redis {
+	t.Helper()
+	c, err := NewRedis(cfg)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestRedisGetSetFlush(t *testing.T) {
+	ln := listen(t)
+	srv := startFakeRedis(t, ln, "s3cret")
+	c := newTestRedis(t, config.RedisConfig{Addr: ln.Addr().String(), Password: "s3cret", DB: 2, KeyPrefix: "t:"})
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v, want miss", ok, err)
+	}
+	if err := c.Set(ctx, "k", "a cat\r\nwith lines", time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, ok, err := c.Get(ctx, "k"); !ok || err != nil || v != "a cat\r\nwith lines" {
+		t.Fatalf("Get(k) = %q, %v, %v", v, ok, err)
+	}
+
+	srv.mu.Lock()
+	srv.data["other:k"] = "kept"
+	srv.mu.Unlock()
+	if n := c.Flush(); n != 1 {
+		t.Fatalf("Flush() = %d, want 1", n)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if _, ok := srv.data["other:k"]; !ok || len(srv.data) != 1 {
+		t.Fatalf("Flush removed keys outside the prefix: %v", srv.data)
+	}
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	ln := listen(t)
+	startFakeRedis(t, ln, "s3cret")
+	c := newTestRedis(t, config.RedisConfig{Addr: ln.Addr().String(), Password: "wrong"})
+	c.recovered() // NewRedis 的连通性检查已进入退避
+	if _, _, err := c.Get(context.Background(), "k"); err == nil || !strings.Contains(err.Error(), "auth") {
+		t.Fatalf("Get with wrong password = %v, want auth error", err)
+	}
+}
+
+func TestRedisShortCircuit(t *testing.T) {
+	ln := listen(t)
+	addr := ln.Addr().String()
+	ln.Close() // 先让 Redis 不可用
+
+	c := newTestRedis(t, config.RedisConfig{Addr: addr})
+	c.minBackoff, c.maxBackoff = 50*time.Millisecond, 200*time.Millisecond
+	c.recovered()
+	ctx := context.Background()
+
+	if _, _, err := c.Get(ctx, "k"); err == nil || errors.Is(err, ErrRedisUnavailable) {
+		t.Fatalf("first Get = %v, want dial error", err)
+	}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, _, err := c.Get(ctx, "k"); !errors.Is(err, ErrRedisUnavailable) {
+			t.Fatalf("Get during backoff = %v, want ErrRedisUnavailable", err)
+		}
+	}
+	if d := time.Since(start); d > 40*time.Millisecond {
+		t.Fatalf("short-circuited calls took %s", d)
+	}
+
+	// 重试失败后间隔翻倍
+	time.Sleep(60 * time.Millisecond)
+	if _, _, err := c.Get(ctx, "k"); err == nil || errors.Is(err, ErrRedisUnavailable) {
+		t.Fatalf("probe Get = %v, want dial error", err)
+	}
+	if c.backoff != 100*time.Millisecond {
+		t.Fatalf("backoff = %s, want 100ms", c.backoff)
+	}
+
+	// Redis 恢复后第一次探测成功即结束退避
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	startFakeRedis(t, ln, "")
+	time.Sleep(110 * time.Millisecond)
+	if _, ok, err := c.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("Get after recovery = %v, %v, want miss", ok, err)
+	}
+	if c.backoff != 0 {
+		t.Fatalf("backoff = %s after recovery, want 0", c.backoff)
+	}
+}
+
+func TestRedisTLS(t *testing.T) {
+	// 借用 httptest 的自签名证书（对 127.0.0.1 有效）
+	https := httptest.NewUnstartedServer(nil)
+	https.StartTLS()
+	defer https.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", https.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startFakeRedis(t, ln, "")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: https.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestRedis(t, config.RedisConfig{Addr: ln.Addr().String(), TLS: true, TLSCAFile: caFile})
+	ctx := context.Background()
+	if err := c.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("Set over TLS: %v", err)
+	}
+	if v, ok, err := c.Get(ctx, "k"); !ok || err != nil || v != "v" {
+		t.Fatalf("Get over TLS = %q, %v, %v", v, ok, err)
+	}
+
+	// 不信任该证书时握手失败
+	untrusted := newTestRedis(t, config.RedisConfig{Addr: ln.Addr().String(), TLS: true})
+	untrusted.recovered()
+	if _, _, err := untrusted.Get(ctx, "k"); err == nil {
+		t.Fatal("Get with an untrusted certificate succeeded")
+	}
+
+	if _, err := NewRedis(config.RedisConfig{Addr: ln.Addr().String(), TLS: true, TLSCAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatal("NewRedis with a missing tls_ca_file succeeded")
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    interface{}
+		wantErr error
+	}{
+		{"+OK\r\n", "OK", nil},
+		{":42\r\n", int64(42), nil},
+		{"$5\r\nhello\r\n", "hello", nil},
+		{"$0\r\n\r\n", "", nil},
+		{"$-1\r\n", nil, errRedisNil},
+		{"*-1\r\n", nil, errRedisNil},
+	}
+	for _, tt := range tests {
+		conn := &redisConn{r: bufio.NewReader(strings.NewReader(tt.raw))}
+		got, err := conn.readReply()
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("readReply(%q) error = %v, want %v", tt.raw, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("readReply(%q) = %#v, %v, want %#v", tt.raw, got, err, tt.want)
+		}
+	}
+
+	conn := &redisConn{r: bufio.NewReader(strings.NewReader("-ERR wrong type\r\n"))}
+	var redisErr redisError
+	if _, err := conn.readReply(); !errors.As(err, &redisErr) {
+		t.Errorf("readReply(error reply) = %v, want redisError", err)
+	}
+
+	conn = &redisConn{r: bufio.NewReader(strings.NewReader("*3\r\n$1\r\na\r\n$-1\r\n:7\r\n"))}
+	got, err := conn.readReply()
+	items, ok := got.([]interface{})
+	if err != nil || !ok || len(items) != 3 || items[0] != "a" || items[1] != nil || items[2] != int64(7) {
+		t.Errorf("readReply(array) = %#v, %v", got, err)
+	}
+}
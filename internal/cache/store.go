@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store 字符串键值缓存，LRU 和 Redis 两种后端共用。
+// Get 未命中时返回 ok=false；后端故障时返回 error，调用方应按未命中处理
+type Store interface {
+	Flusher
+	Get(ctx context.Context, key string) (value string, ok bool, err error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
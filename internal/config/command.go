@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunCommand 处理 `svg-generator config <validate|schema>` 子命令，返回进程退出码
+func RunCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: svg-generator config <validate|schema> [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return validateConfigCommand(args[1:], stdout, stderr)
+	case "schema":
+		out, err := JSONSchema()
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to generate schema: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(out))
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\nusage: svg-generator config <validate|schema> [flags]\n", args[0])
+		return 2
+	}
+}
+
+// validateConfigCommand 按启动时相同的分层规则加载并校验配置，列出全部问题
+func validateConfigCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	defaultPath := os.Getenv("CONFIG_PATH")
+	if defaultPath == "" {
+		defaultPath = "config.yaml"
+	}
+	configPath := fs.String("config", defaultPath, "base configuration file (APP_ENV profile and SVGGEN_* overrides are applied)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	files := ConfigFiles(*configPath)
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "configuration is invalid (%v):\n", files)
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			for _, problem := range validationErr.Problems {
+				fmt.Fprintf(stderr, "  - %s\n", problem)
+			}
+		} else {
+			fmt.Fprintf(stderr, "  - %v\n", err)
+		}
+		return 1
+	}
+
+	// 缺少 API Key 不影响配置合法性，但启动时会导致对应 Provider 不可用；
+	// api_key、key_pool.keys 和 key_pool.keys_file 中任意一处有 Key 即可
+	for _, p := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"svgio", cfg.Providers.SVGIO.Enabled},
+		{"recraft", cfg.Providers.Recraft.Enabled},
+		{"claude", cfg.Providers.Claude.Enabled},
+	} {
+		if keys, _ := cfg.GetProviderKeys(p.name); p.enabled && len(keys) == 0 {
+			fmt.Fprintf(stdout, "warning: providers.%s is enabled but has no api_key or key_pool keys\n", p.name)
+		}
+	}
+
+	fmt.Fprintf(stdout, "configuration is valid (%v)\n", files)
+	return 0
+}
@@ -1,9 +1,7 @@
 package config
 
 import (
-
 	"time"
-
 )
 
 // Config 应用程序配置结构
@@ -11,10 +9,20 @@ type Config struct {
 	Server      ServerConfig      `yaml:"server"`
 	Providers   ProvidersConfig   `yaml:"providers"`
 	Translation TranslationConfig `yaml:"translation"`
+	Enhancement EnhancementConfig `yaml:"enhancement"`
 	HTTPClient  HTTPClientConfig  `yaml:"http_client"`
 	Logging     LoggingConfig     `yaml:"logging"`
 	Features    FeaturesConfig    `yaml:"features"`
 	Security    SecurityConfig    `yaml:"security"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Health      HealthConfig      `yaml:"health"`
+	Reload      ReloadConfig      `yaml:"reload"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Usage       UsageConfig       `yaml:"usage"`
+	Budgets     BudgetsConfig     `yaml:"budgets"`
+	Audit       AuditConfig       `yaml:"audit"`
+	Moderation  ModerationConfig  `yaml:"moderation"`
+	Privacy     PrivacyConfig     `yaml:"privacy"`
 }
 
 // ServerConfig 服务器配置
@@ -24,6 +32,11 @@ type ServerConfig struct {
 	Timeout      time.Duration `yaml:"timeout"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout 收到退出信号后等待进行中请求完成的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay 置为未就绪后、停止监听前的等待时间，供负载均衡摘除实例
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
 }
 
 // ProvidersConfig 提供商配置
@@ -40,6 +53,15 @@ type SVGIOConfig struct {
 	Timeout    time.Duration  `yaml:"timeout"`
 	MaxRetries int            `yaml:"max_retries"`
 	Enabled    bool           `yaml:"enabled"`
+	APIKey     string         `yaml:"api_key"`
+	APIKeyFile string         `yaml:"api_key_file"`
+	KeyPool    KeyPoolConfig  `yaml:"key_pool"`
+	// AllowedDownloadHosts 允许下载生成结果的主机白名单
+	AllowedDownloadHosts []string `yaml:"allowed_download_hosts"`
+	// RequestIDHeader 转发请求ID使用的请求头，为空时不转发
+	RequestIDHeader string `yaml:"request_id_header"`
+	// SupportedLanguages 上游能直接理解的提示词语言（ISO 639-1），其他语言先翻译；默认只支持 en
+	SupportedLanguages []string `yaml:"supported_languages"`
 }
 
 // SVGIOEndpoints SVG.IO端点配置
@@ -57,12 +79,22 @@ type RecraftConfig struct {
 	Enabled         bool             `yaml:"enabled"`
 	DefaultModel    string           `yaml:"default_model"`
 	SupportedModels []string         `yaml:"supported_models"`
+	APIKey          string           `yaml:"api_key"`
+	APIKeyFile      string           `yaml:"api_key_file"`
+	KeyPool         KeyPoolConfig    `yaml:"key_pool"`
+	// AllowedDownloadHosts 允许下载生成结果的主机白名单
+	AllowedDownloadHosts []string `yaml:"allowed_download_hosts"`
+	// RequestIDHeader 转发请求ID使用的请求头，为空时不转发
+	RequestIDHeader string `yaml:"request_id_header"`
+	// SupportedLanguages 上游能直接理解的提示词语言，"*" 表示任意语言（默认）
+	SupportedLanguages []string `yaml:"supported_languages"`
 }
 
 // RecraftEndpoints Recraft端点配置
 type RecraftEndpoints struct {
 	Generate  string `yaml:"generate"`
 	Vectorize string `yaml:"vectorize"`
+	User      string `yaml:"user"` // 凭据检查使用的当前用户接口
 }
 
 // ClaudeConfig Claude提供商配置
@@ -74,12 +106,32 @@ type ClaudeConfig struct {
 	Enabled      bool            `yaml:"enabled"`
 	DefaultModel string          `yaml:"default_model"`
 	MaxTokens    int             `yaml:"max_tokens"`
-	Temperature  float64         `yaml:"temperature"`
+	Temperature  float64         `yaml:"temperature"` // 0-2，未配置时为 0.7，0 为确定性输出
+	APIKey       string          `yaml:"api_key"`
+	APIKeyFile   string          `yaml:"api_key_file"`
+	KeyPool      KeyPoolConfig   `yaml:"key_pool"`
+	// RequestIDHeader 转发请求ID使用的请求头，为空时不转发
+	RequestIDHeader string `yaml:"request_id_header"`
+	// SupportedLanguages 上游能直接理解的提示词语言，"*" 表示任意语言（默认）
+	SupportedLanguages []string `yaml:"supported_languages"`
 }
 
 // ClaudeEndpoints Claude端点配置
 type ClaudeEndpoints struct {
-	Chat string `yaml:"chat"`
+	Chat   string `yaml:"chat"`
+	Models string `yaml:"models"` // 凭据检查使用的模型列表接口
+}
+
+// KeyPoolConfig Provider 的 API Key 池，与 api_key 一起轮流使用以分摊单个账户的限流
+type KeyPoolConfig struct {
+	// Keys 除 api_key 之外的 API Key
+	Keys []string `yaml:"keys"`
+	// KeysFile 每行一个 API Key 的文件，# 开头的行为注释；每次重新加载配置时重新读取
+	KeysFile string `yaml:"keys_file"`
+	// Strategy Key 选择策略：round_robin（默认）或 least_used
+	Strategy string `yaml:"strategy"`
+	// Cooldown 返回 401/403/429 的 Key 被隔离的时长
+	Cooldown time.Duration `yaml:"cooldown"`
 }
 
 // TranslationConfig 翻译服务配置
@@ -91,6 +143,93 @@ type TranslationConfig struct {
 	MaxRetries      int           `yaml:"max_retries"`
 	FallbackEnabled bool          `yaml:"fallback_enabled"`
 	FallbackModels  []string      `yaml:"fallback_models"`
+	APIKey          string        `yaml:"api_key"`
+	APIKeyFile      string        `yaml:"api_key_file"`
+	// RequestIDHeader 转发请求ID使用的请求头，为空时不转发
+	RequestIDHeader string `yaml:"request_id_header"`
+	// MinConfidence 语言检测置信度低于该值时视为上游支持的语言，不翻译（默认 0.3）
+	MinConfidence float64 `yaml:"min_confidence"`
+	// MaxTokens 翻译请求的 max_tokens，推理模型的思考过程也计入（默认 1024）；
+	// 译文未通过校验（被截断、不是英文等）时同一翻译器最多重试 MaxRetries 次
+	MaxTokens int `yaml:"max_tokens"`
+	// Translators 按顺序尝试的翻译器链；为空时由 service_url、default_model 和 fallback_models 生成
+	Translators []TranslatorConfig `yaml:"translators"`
+	// Cache 翻译结果缓存
+	Cache TranslationCacheConfig `yaml:"cache"`
+	// Glossary 按租户的术语表
+	Glossary GlossaryConfig `yaml:"glossary"`
+}
+
+// TranslatorConfig 翻译器链中的一个翻译器
+type TranslatorConfig struct {
+	Name string `yaml:"name"`
+	// Type 翻译器类型：openai（OpenAI 兼容的 chat completions 接口）| dictionary（离线词典）
+	Type string `yaml:"type"`
+	// ServiceURL、APIKey 为空时使用 translation 级别的配置
+	ServiceURL string        `yaml:"service_url"`
+	APIKey     string        `yaml:"api_key"`
+	APIKeyFile string        `yaml:"api_key_file"`
+	Model      string        `yaml:"model"`
+	Timeout    time.Duration `yaml:"timeout"`
+	// MaxTokens 为 0 时使用 translation.max_tokens
+	MaxTokens int `yaml:"max_tokens"`
+	// DictionaryFile 离线词典的补充词条，每行 "中文<TAB>English"，优先于内置词条
+	DictionaryFile string `yaml:"dictionary_file"`
+}
+
+// TranslationCacheConfig 翻译结果缓存配置，键为规范化后的原文、模型和目标语言
+type TranslationCacheConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend 缓存后端：memory（进程内 LRU）| redis
+	Backend    string        `yaml:"backend"`
+	MaxEntries int           `yaml:"max_entries"`
+	TTL        time.Duration `yaml:"ttl"`
+	// WarmFile 启动时预热缓存的 JSONL 文件，每行 {"text", "translation", "model", "target"}
+	WarmFile string      `yaml:"warm_file"`
+	Redis    RedisConfig `yaml:"redis"`
+}
+
+// GlossaryConfig 术语表配置：按租户维护 原文术语 → 固定英文译法或不翻译，
+// 翻译前直接替换，并作为约束写入翻译提示词。术语表通过管理接口修改，每次修改生成新版本
+type GlossaryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Dir 术语表存储目录，每个租户一个 <tenant>.jsonl 文件，每行一个版本
+	Dir string `yaml:"dir"`
+	// MaxEntries 单个租户术语表的最大条目数
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// EnhancementConfig 提示词增强配置：翻译之后、调用上游之前，用大模型把简短的提示词扩写为
+// 针对目标 Provider 的画面描述。请求可以用 enhance 字段选择级别
+type EnhancementConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// DefaultLevel 请求未指定 enhance 时使用的级别：off（默认）| light | detailed
+	DefaultLevel string `yaml:"default_level"`
+	// ServiceURL、APIKey、Model 为空时使用 translation 的 service_url、api_key 和 default_model
+	ServiceURL string        `yaml:"service_url"`
+	APIKey     string        `yaml:"api_key"`
+	APIKeyFile string        `yaml:"api_key_file"`
+	Model      string        `yaml:"model"`
+	Timeout    time.Duration `yaml:"timeout"`
+	// MaxTokens 增强请求的 max_tokens，推理模型的思考过程也计入（默认 1024）
+	MaxTokens int `yaml:"max_tokens"`
+	// MaxInputLength 提示词超过该字符数时视为已足够详细，不再增强（默认 200）
+	MaxInputLength int `yaml:"max_input_length"`
+	// MaxOutputLength 增强结果的最大字符数，超过时使用原提示词（默认 1000）
+	MaxOutputLength int `yaml:"max_output_length"`
+}
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Addr         string        `yaml:"addr"`
+	Password     string        `yaml:"password"`
+	PasswordFile string        `yaml:"password_file"`
+	DB           int           `yaml:"db"`
+	KeyPrefix    string        `yaml:"key_prefix"`
+	Timeout      time.Duration `yaml:"timeout"`
+	// TLS 使用 TLS 连接并校验服务端证书；TLSCAFile 为签发服务端证书的 CA（PEM），为空时使用系统根证书
+	TLS       bool   `yaml:"tls"`
+	TLSCAFile string `yaml:"tls_ca_file"`
 }
 
 // HTTPClientConfig HTTP客户端配置
@@ -110,6 +249,13 @@ type LoggingConfig struct {
 	Output               string `yaml:"output"`
 	EnableRequestLogging bool   `yaml:"enable_request_logging"`
 	EnableErrorStack     bool   `yaml:"enable_error_stack"`
+	// 文件输出 (output 为 "file" 时使用 file_path)
+	FilePath   string `yaml:"file_path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	// RedactPrompts 日志中隐藏提示词内容，仅保留长度
+	RedactPrompts bool `yaml:"redact_prompts"`
 }
 
 // FeaturesConfig 功能特性配置
@@ -121,13 +267,204 @@ type FeaturesConfig struct {
 	EnableCaching      bool `yaml:"enable_caching"`
 }
 
+// TracingConfig 链路追踪配置，features.enable_tracing 为 true 时生效
+type TracingConfig struct {
+	ServiceName string            `yaml:"service_name"`
+	Exporter    string            `yaml:"exporter"` // otlp, stdout, file
+	Endpoint    string            `yaml:"endpoint"` // OTLP/HTTP 地址，如 http://localhost:4318/v1/traces
+	Headers     map[string]string `yaml:"headers"`
+	FilePath    string            `yaml:"file_path"`
+	SampleRatio float64           `yaml:"sample_ratio"`
+	Timeout     time.Duration     `yaml:"timeout"`
+}
+
+// HealthConfig Provider 健康检查与熔断配置
+type HealthConfig struct {
+	// CredentialCheckInterval 后台凭据检查间隔，0 表示不检查
+	CredentialCheckInterval time.Duration `yaml:"credential_check_interval"`
+	CredentialCheckTimeout  time.Duration `yaml:"credential_check_timeout"`
+	// BreakerFailureThreshold 连续失败多少次后熔断，0 表示不熔断
+	BreakerFailureThreshold int `yaml:"breaker_failure_threshold"`
+	// BreakerOpenDuration 熔断后多久放行一次试探请求
+	BreakerOpenDuration time.Duration `yaml:"breaker_open_duration"`
+	// LatencyWindow 计算 p95 延迟使用的最近调用次数
+	LatencyWindow int `yaml:"latency_window"`
+}
+
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	EnableAPIKeyValidation bool     `yaml:"enable_api_key_validation"`
-	AllowedOrigins         []string `yaml:"allowed_origins"`
-	MaxRequestSize         string   `yaml:"max_request_size"`
-	EnableRequestID        bool     `yaml:"enable_request_id"`
+	// EnableAPIKeyValidation /v1 接口要求携带 tenant_keys 中的 API Key，未携带时返回 401
+	EnableAPIKeyValidation bool           `yaml:"enable_api_key_validation"`
+	AllowedOrigins         []string       `yaml:"allowed_origins"`
+	MaxRequestSize         string         `yaml:"max_request_size"`
+	EnableRequestID        bool           `yaml:"enable_request_id"`
+	Download               DownloadConfig `yaml:"download"`
+	// TenantHeader 携带管理令牌的请求可以用该请求头指定代表的租户；其他请求的租户只由 API Key 决定
+	TenantHeader string `yaml:"tenant_header"`
+	// TenantKeys 调用方 API Key 与租户的对应关系，请求以 Authorization: Bearer <key> 或 X-API-Key 携带
+	TenantKeys []TenantKeyConfig `yaml:"tenant_keys"`
+}
+
+// TenantKeyConfig 一个租户的 API Key
+type TenantKeyConfig struct {
+	Tenant string   `yaml:"tenant"`
+	Keys   []string `yaml:"keys"`
+	// KeysFile 每行一个 Key 的文件，忽略空行和 # 开头的注释行，与 keys 合并
+	KeysFile string `yaml:"keys_file"`
+}
+
+// DownloadConfig 上游资源下载配置
+type DownloadConfig struct {
+	MaxSize              string `yaml:"max_size"`
+	MaxRedirects         int    `yaml:"max_redirects"`
+	AllowPrivateNetworks bool   `yaml:"allow_private_networks"`
+}
+
+// AdminConfig 运行时管理接口配置
+type AdminConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token 访问 /admin 接口的 Bearer 令牌
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// OverridesFile 持久化运行时修改的文件，为空时修改只保存在内存中，重启后失效
+	OverridesFile string `yaml:"overrides_file"`
+}
+
+// UsageConfig 用量台账与成本核算配置
+type UsageConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// LedgerPath 用量台账文件（JSON Lines，追加写入），为空时只保存在内存中
+	LedgerPath string `yaml:"ledger_path"`
+	// MaxMemoryEntries 未配置台账文件时内存中保留的最近记录数
+	MaxMemoryEntries int           `yaml:"max_memory_entries"`
+	Currency         string        `yaml:"currency"`
+	Pricing          PricingConfig `yaml:"pricing"`
+}
+
+// PricingConfig 各 Provider 的计费价格
+type PricingConfig struct {
+	SVGIO   PriceConfig `yaml:"svgio"`
+	Recraft PriceConfig `yaml:"recraft"`
+	Claude  PriceConfig `yaml:"claude"`
+}
+
+// PriceConfig 单个 Provider 的价格，按 token、生成图片数和向量化调用次数计费
+type PriceConfig struct {
+	InputPer1KTokens  float64 `yaml:"input_per_1k_tokens"`
+	OutputPer1KTokens float64 `yaml:"output_per_1k_tokens"`
+	PerImage          float64 `yaml:"per_image"`
+	PerVectorize      float64 `yaml:"per_vectorize"`
+}
+
+// BudgetsConfig 按租户和全局的支出上限，支出按 usage.pricing 计算的成本累计
+type BudgetsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Period 统计周期：daily | weekly（周一开始）| monthly，按 Timezone 的自然周期重置
+	Period   string `yaml:"period"`
+	Timezone string `yaml:"timezone"`
+	// Global 全部租户合计的上限
+	Global BudgetLimit `yaml:"global"`
+	// DefaultTenant 未在 Tenants 中单独配置的租户使用的上限
+	DefaultTenant BudgetLimit            `yaml:"default_tenant"`
+	Tenants       map[string]BudgetLimit `yaml:"tenants"`
+	Alerts        BudgetAlertConfig      `yaml:"alerts"`
+}
+
+// BudgetLimit 支出上限，0 表示不限制。超过软上限时告警，达到硬上限时按 OnExceed 拒绝或降级
+type BudgetLimit struct {
+	SoftLimit float64 `yaml:"soft_limit"`
+	HardLimit float64 `yaml:"hard_limit"`
+	// OnExceed 达到硬上限后的处理：reject（默认）| downgrade
+	OnExceed string `yaml:"on_exceed"`
+	// DowngradeTo 降级使用的 Provider，通常是单价更低的 Provider
+	DowngradeTo string `yaml:"downgrade_to"`
+}
+
+// BudgetAlertConfig 超过上限时的告警，始终写入 warning 日志，配置了 WebhookURL 时同时推送
+type BudgetAlertConfig struct {
+	WebhookURL string            `yaml:"webhook_url"`
+	Headers    map[string]string `yaml:"headers"`
+	Timeout    time.Duration     `yaml:"timeout"`
+}
+
+// AuditConfig 审计日志配置：记录生成请求和管理操作，记录之间以 HMAC 链相连，可以发现篡改和删除
+type AuditConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	FilePath string `yaml:"file_path"`
+	// 按大小轮转，轮转后的文件不会被删除，清理只能通过归档完成
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// HMACKey 计算记录哈希链的密钥，不知道密钥无法伪造一条能通过校验的链
+	HMACKey     string `yaml:"hmac_key"`
+	HMACKeyFile string `yaml:"hmac_key_file"`
+	// RedactPrompts 只记录提示词的 SHA-256 摘要而不记录原文
+	RedactPrompts bool `yaml:"redact_prompts"`
 }
 
-// 全局配置实例
-var AppConfig *Config
+// ModerationConfig 内容审核配置：生成前检查提示词，生成后检查上游返回的 NSFW 标记。
+// 审核级别由低到高为 off | relaxed | standard | strict，级别越高启用的检查越多
+type ModerationConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	DefaultLevel string `yaml:"default_level"`
+	// Tenants 按租户覆盖审核级别
+	Tenants map[string]string `yaml:"tenants"`
+	// Blocklist 不区分大小写的屏蔽词，relaxed 及以上级别生效
+	Blocklist  []string                   `yaml:"blocklist"`
+	Rules      []ModerationRule           `yaml:"rules"`
+	Classifier ModerationClassifierConfig `yaml:"classifier"`
+}
+
+// ModerationRule 正则审核规则
+type ModerationRule struct {
+	Pattern  string `yaml:"pattern"`
+	Category string `yaml:"category"`
+	// MinLevel 规则生效的最低审核级别，默认 standard
+	MinLevel string `yaml:"min_level"`
+}
+
+// ModerationClassifierConfig 可选的 LLM 分类器，使用 OpenAI 兼容的 chat completions 接口
+type ModerationClassifierConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	ServiceURL string        `yaml:"service_url"`
+	APIKey     string        `yaml:"api_key"`
+	APIKeyFile string        `yaml:"api_key_file"`
+	Model      string        `yaml:"model"`
+	Timeout    time.Duration `yaml:"timeout"`
+	// MinLevel 分类器生效的最低审核级别，默认 strict
+	MinLevel string `yaml:"min_level"`
+	// FailOpen 分类器不可用时放行（默认拒绝）
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// PrivacyConfig 个人信息脱敏配置：在翻译和调用上游之前检测提示词中的邮箱、电话、身份证号等，
+// 按 action 掩码或拒绝请求
+type PrivacyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Action 检测到个人信息时的处理方式：mask（替换为类型占位符）| reject（返回 422）
+	Action string `yaml:"action"`
+	// Detectors 启用的内置检测器，为空时启用全部
+	Detectors []string     `yaml:"detectors"`
+	Patterns  []PIIPattern `yaml:"patterns"`
+	// Tenants 按租户覆盖处理方式，并决定审计日志能否保存脱敏前的原文
+	Tenants map[string]PrivacyTenantConfig `yaml:"tenants"`
+}
+
+// PIIPattern 自定义检测规则，命中内容替换为 [NAME]
+type PIIPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// PrivacyTenantConfig 租户级脱敏策略
+type PrivacyTenantConfig struct {
+	Action string `yaml:"action"`
+	// StoreOriginal 允许审计日志记录脱敏前的提示词（默认只记录脱敏后的文本）
+	StoreOriginal bool `yaml:"store_original"`
+}
+
+// ReloadConfig 配置热更新
+type ReloadConfig struct {
+	// Watch 是否监听配置文件变化，关闭时仍可通过 SIGHUP 触发重新加载
+	Watch bool `yaml:"watch"`
+	// Interval 检查配置文件变化的间隔
+	Interval time.Duration `yaml:"interval"`
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"log/slog"
+	"regexp"
+	"sync"
+)
+
+// Derived 由配置派生、构建成本较高的数据（如预编译的正则），按配置快照缓存：
+// 重新加载配置后 Get 得到新的 *Config，第一次使用时重新构建
+type Derived[T any] struct {
+	build  func(cfg *Config) T
+	mu     sync.Mutex
+	source *Config
+	value  T
+}
+
+// NewDerived 创建按配置快照缓存的派生数据
+func NewDerived[T any](build func(cfg *Config) T) *Derived[T] {
+	return &Derived[T]{build: build}
+}
+
+// Get 返回 cfg 对应的派生数据，cfg 与上次不同时重新构建
+func (d *Derived[T]) Get(cfg *Config) T {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.source != cfg {
+		d.value = d.build(cfg)
+		d.source = cfg
+	}
+	return d.value
+}
+
+// CompileValidated 编译已通过配置校验的正则。校验已拒绝非法正则，
+// 失败只可能来自绕过校验的配置，记录错误并返回 nil，由调用方跳过该条
+func CompileValidated(field, pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		slog.Default().Error("skipping invalid pattern", "component", "config", "field", field, "error", err)
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 环境变量覆盖配置项的前缀，变量名由 YAML 路径转为大写并以下划线连接，
+// 例如 providers.claude.timeout 对应 SVGGEN_PROVIDERS_CLAUDE_TIMEOUT
+const EnvPrefix = "SVGGEN"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides 遍历配置结构，用同名环境变量覆盖对应字段
+func applyEnvOverrides(config *Config, lookup func(string) (string, bool)) error {
+	return walkEnv(reflect.ValueOf(config).Elem(), EnvPrefix, lookup)
+}
+
+func walkEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct {
+			if err := walkEnv(fv, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fv, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv 按字段类型解析环境变量值；切片以逗号分隔，映射以 key=value 逗号分隔
+func setFromEnv(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String || fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", fv.Type())
+		}
+		m := make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		fv.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// legacyEnv 旧版环境变量，优先级高于配置文件、低于 SVGGEN_* 变量
+var legacyEnv = []struct {
+	name string
+	dest func(*Config) *string
+}{
+	{"SVGIO_API_KEY", func(c *Config) *string { return &c.Providers.SVGIO.APIKey }},
+	{"RECRAFT_API_KEY", func(c *Config) *string { return &c.Providers.Recraft.APIKey }},
+	{"CLAUDE_API_KEY", func(c *Config) *string { return &c.Providers.Claude.APIKey }},
+	{"CLAUDE_BASE_URL", func(c *Config) *string { return &c.Providers.Claude.BaseURL }},
+	{"OPENAI_API_KEY", func(c *Config) *string { return &c.Translation.APIKey }},
+}
+
+// applyLegacyEnv 兼容 SVGIO_API_KEY 等旧版环境变量（及其 _FILE 形式）
+func applyLegacyEnv(config *Config, lookup func(string) (string, bool)) error {
+	for _, env := range legacyEnv {
+		dest := env.dest(config)
+		if v, ok := lookup(env.name); ok && v != "" {
+			// 旧版变量优先于 YAML 中的值，与重构前只读环境变量的行为一致
+			*dest = v
+			continue
+		}
+		if path, ok := lookup(env.name + "_FILE"); ok && path != "" {
+			secret, err := readSecretFile(path)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %w", env.name, err)
+			}
+			*dest = secret
+		}
+	}
+	return nil
+}
+
+// resolveSecretFiles 对未直接设置 api_key 的项读取 api_key_file 指向的密钥文件
+func resolveSecretFiles(config *Config) error {
+	secrets := []struct {
+		name string
+		key  *string
+		file string
+	}{
+		{"providers.svgio.api_key_file", &config.Providers.SVGIO.APIKey, config.Providers.SVGIO.APIKeyFile},
+		{"providers.recraft.api_key_file", &config.Providers.Recraft.APIKey, config.Providers.Recraft.APIKeyFile},
+		{"providers.claude.api_key_file", &config.Providers.Claude.APIKey, config.Providers.Claude.APIKeyFile},
+		{"translation.api_key_file", &config.Translation.APIKey, config.Translation.APIKeyFile},
+		{"enhancement.api_key_file", &config.Enhancement.APIKey, config.Enhancement.APIKeyFile},
+		{"admin.token_file", &config.Admin.Token, config.Admin.TokenFile},
+		{"translation.cache.redis.password_file", &config.Translation.Cache.Redis.Password, config.Translation.Cache.Redis.PasswordFile},
+		{"moderation.classifier.api_key_file", &config.Moderation.Classifier.APIKey, config.Moderation.Classifier.APIKeyFile},
+		{"audit.hmac_key_file", &config.Audit.HMACKey, config.Audit.HMACKeyFile},
+	}
+	for _, s := range secrets {
+		if *s.key != "" || s.file == "" {
+			continue
+		}
+		secret, err := readSecretFile(s.file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		*s.key = secret
+	}
+
+	pools := []struct {
+		name string
+		pool *KeyPoolConfig
+	}{
+		{"providers.svgio.key_pool.keys_file", &config.Providers.SVGIO.KeyPool},
+		{"providers.recraft.key_pool.keys_file", &config.Providers.Recraft.KeyPool},
+		{"providers.claude.key_pool.keys_file", &config.Providers.Claude.KeyPool},
+	}
+	for _, p := range pools {
+		if p.pool.KeysFile == "" {
+			continue
+		}
+		keys, err := readKeysFile(p.pool.KeysFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+		p.pool.Keys = append(append([]string(nil), p.pool.Keys...), keys...)
+	}
+
+	for i := range config.Security.TenantKeys {
+		tk := &config.Security.TenantKeys[i]
+		if tk.KeysFile == "" {
+			continue
+		}
+		keys, err := readKeysFile(tk.KeysFile)
+		if err != nil {
+			return fmt.Errorf("security.tenant_keys[%d].keys_file: %w", i, err)
+		}
+		tk.Keys = append(append([]string(nil), tk.Keys...), keys...)
+	}
+
+	for i := range config.Translation.Translators {
+		tr := &config.Translation.Translators[i]
+		if tr.APIKey != "" || tr.APIKeyFile == "" {
+			continue
+		}
+		secret, err := readSecretFile(tr.APIKeyFile)
+		if err != nil {
+			return fmt.Errorf("translation.translators[%d].api_key_file: %w", i, err)
+		}
+		tr.APIKey = secret
+	}
+	return nil
+}
+
+// readKeysFile 读取每行一个 API Key 的文件，忽略空行和 # 开头的注释行
+func readKeysFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keys = append(keys, line)
+		}
+	}
+	return keys, nil
+}
+
+// readSecretFile 读取密钥文件并去掉首尾空白（如 Docker/Kubernetes secret 末尾的换行）
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// redactedSecret 输出配置时替换密钥的占位符
+const redactedSecret = "[REDACTED]"
+
+// Redacted 返回隐藏了 API Key、管理令牌和追踪、预算告警请求头的配置副本，用于打印和诊断
+func (c *Config) Redacted() *Config {
+	out := *c
+	for _, key := range []*string{
+		&out.Providers.SVGIO.APIKey,
+		&out.Providers.Recraft.APIKey,
+		&out.Providers.Claude.APIKey,
+		&out.Translation.APIKey,
+		&out.Enhancement.APIKey,
+		&out.Admin.Token,
+		&out.Audit.HMACKey,
+		&out.Moderation.Classifier.APIKey,
+		&out.Translation.Cache.Redis.Password,
+	} {
+		if *key != "" {
+			*key = redactedSecret
+		}
+	}
+	if len(c.Translation.Translators) > 0 {
+		out.Translation.Translators = append([]TranslatorConfig(nil), c.Translation.Translators...)
+		for i := range out.Translation.Translators {
+			if out.Translation.Translators[i].APIKey != "" {
+				out.Translation.Translators[i].APIKey = redactedSecret
+			}
+		}
+	}
+	for _, pool := range []*KeyPoolConfig{&out.Providers.SVGIO.KeyPool, &out.Providers.Recraft.KeyPool, &out.Providers.Claude.KeyPool} {
+		if len(pool.Keys) > 0 {
+			redacted := make([]string, len(pool.Keys))
+			for i := range redacted {
+				redacted[i] = redactedSecret
+			}
+			pool.Keys = redacted
+		}
+	}
+	if len(c.Security.TenantKeys) > 0 {
+		out.Security.TenantKeys = append([]TenantKeyConfig(nil), c.Security.TenantKeys...)
+		for i := range out.Security.TenantKeys {
+			redacted := make([]string, len(out.Security.TenantKeys[i].Keys))
+			for j := range redacted {
+				redacted[j] = redactedSecret
+			}
+			out.Security.TenantKeys[i].Keys = redacted
+		}
+	}
+	if len(c.Tracing.Headers) > 0 {
+		out.Tracing.Headers = make(map[string]string, len(c.Tracing.Headers))
+		for k := range c.Tracing.Headers {
+			out.Tracing.Headers[k] = redactedSecret
+		}
+	}
+	if len(c.Budgets.Alerts.Headers) > 0 {
+		out.Budgets.Alerts.Headers = make(map[string]string, len(c.Budgets.Alerts.Headers))
+		for k := range c.Budgets.Alerts.Headers {
+			out.Budgets.Alerts.Headers[k] = redactedSecret
+		}
+	}
+	return &out
+}
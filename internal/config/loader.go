@@ -1,12 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
+
 // LoadConfig 从YAML文件加载配置
 func LoadConfig(configPath string) (*Config, error) {
 	// 如果没有指定配置文件路径，使用默认路径
@@ -24,16 +31,32 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 	}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// 依次叠加：基础配置文件 -> 环境配置文件 (APP_ENV) -> 旧版环境变量 -> SVGGEN_* 环境变量 -> 密钥文件。
+	// 0 是有意义取值的字段在解码前预置默认值，配置中显式设置的值（包括 0）会覆盖它
+	var config Config
+	config.Providers.Claude.Temperature = defaultClaudeTemperature
+	for _, path := range ConfigFiles(configPath) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		// 解码到同一结构体：后加载的文件只覆盖其中出现的字段，列表整体替换；
+		// 拒绝未知字段，避免拼写错误的配置项被静默忽略
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, describeYAMLError(path, err))
+		}
 	}
 
-	// 解析YAML
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := applyLegacyEnv(&config, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to apply environment: %w", err)
+	}
+	if err := applyEnvOverrides(&config, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+	}
+	if err := resolveSecretFiles(&config); err != nil {
+		return nil, fmt.Errorf("failed to read secret file: %w", err)
 	}
 
 	// 验证配置
@@ -44,6 +67,20 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// ConfigFiles 返回按叠加顺序排列的配置文件：基础文件，以及 APP_ENV 指定的环境文件。
+// 环境文件与基础文件同目录，命名为 <基础文件名>.<APP_ENV>.yaml，例如 config.dev.yaml
+func ConfigFiles(configPath string) []string {
+	files := []string{configPath}
+
+	env := strings.TrimSpace(os.Getenv("APP_ENV"))
+	if env == "" {
+		return files
+	}
+	ext := filepath.Ext(configPath)
+	profile := strings.TrimSuffix(configPath, ext) + "." + env + ext
+	return append(files, profile)
+}
+
 // InitConfig 初始化全局配置
 func InitConfig(configPath string) error {
 	config, err := LoadConfig(configPath)
@@ -51,40 +88,70 @@ func InitConfig(configPath string) error {
 		return err
 	}
 
-	AppConfig = config
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	effective, err := applyStoredOverrides(config, true)
+	if err != nil {
+		return err
+	}
+	loaded = config
+	current.Store(effective)
 	return nil
 }
 
-// validateConfig 验证配置有效性
-func validateConfig(config *Config) error {
-	// 验证服务器配置
-	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", config.Server.Port)
-	}
+// defaultMaxRequestSize 未配置 max_request_size 时使用的默认请求体上限
+const defaultMaxRequestSize int64 = 10 << 20
 
-	// 验证至少启用一个Provider
-	if !config.Providers.SVGIO.Enabled && !config.Providers.Recraft.Enabled && !config.Providers.Claude.Enabled {
-		return fmt.Errorf("at least one provider must be enabled")
+// ParseByteSize 解析形如 "10MB"、"512KB"、"1024" 的大小字符串（按1024进制）
+func ParseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	if value == "" {
+		return 0, fmt.Errorf("empty size")
 	}
 
-	// 验证Provider URL
-	providers := []struct {
-		name    string
-		enabled bool
-		baseURL string
+	units := []struct {
+		suffix     string
+		multiplier int64
 	}{
-		{"svgio", config.Providers.SVGIO.Enabled, config.Providers.SVGIO.BaseURL},
-		{"recraft", config.Providers.Recraft.Enabled, config.Providers.Recraft.BaseURL},
-		{"claude", config.Providers.Claude.Enabled, config.Providers.Claude.BaseURL},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
 	}
 
-	for _, p := range providers {
-		if p.enabled && p.baseURL == "" {
-			return fmt.Errorf("provider %s is enabled but base_url is empty", p.name)
+	multiplier := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			multiplier = u.multiplier
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			break
 		}
 	}
 
-	return nil
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("size must be positive: %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// GetMaxRequestSize 获取请求体大小上限（字节）
+func (c *Config) GetMaxRequestSize() int64 {
+	if c.Security.MaxRequestSize == "" {
+		return defaultMaxRequestSize
+	}
+	size, err := ParseByteSize(c.Security.MaxRequestSize)
+	if err != nil {
+		return defaultMaxRequestSize
+	}
+	return size
 }
 
 // GetServerAddr 获取服务器监听地址
@@ -92,6 +159,539 @@ func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
+// GetDownloadMaxSize 获取上游资源下载大小上限（字节），未配置时返回0使用下载器默认值
+func (c *Config) GetDownloadMaxSize() int64 {
+	if c.Security.Download.MaxSize == "" {
+		return 0
+	}
+	size, err := ParseByteSize(c.Security.Download.MaxSize)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// defaultShutdownTimeout 未配置 shutdown_timeout 时的排空时间
+const defaultShutdownTimeout = 30 * time.Second
+
+// GetShutdownTimeout 获取优雅退出的排空时间
+func (s ServerConfig) GetShutdownTimeout() time.Duration {
+	if s.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return s.ShutdownTimeout
+}
+
+// defaultRequestTimeout 未配置 server.timeout 时单个请求的处理时限
+const defaultRequestTimeout = 120 * time.Second
+
+// GetRequestTimeout 获取单个请求的处理时限
+func (s ServerConfig) GetRequestTimeout() time.Duration {
+	if s.Timeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return s.Timeout
+}
+
+// 健康检查默认值
+const (
+	defaultCredentialCheckTimeout = 10 * time.Second
+	defaultBreakerOpenDuration    = 30 * time.Second
+	defaultLatencyWindow          = 100
+)
+
+// GetCredentialCheckTimeout 获取单次凭据检查的超时时间
+func (h HealthConfig) GetCredentialCheckTimeout() time.Duration {
+	if h.CredentialCheckTimeout <= 0 {
+		return defaultCredentialCheckTimeout
+	}
+	return h.CredentialCheckTimeout
+}
+
+// GetBreakerOpenDuration 获取熔断持续时间
+func (h HealthConfig) GetBreakerOpenDuration() time.Duration {
+	if h.BreakerOpenDuration <= 0 {
+		return defaultBreakerOpenDuration
+	}
+	return h.BreakerOpenDuration
+}
+
+// GetLatencyWindow 获取延迟统计窗口大小
+func (h HealthConfig) GetLatencyWindow() int {
+	if h.LatencyWindow <= 0 {
+		return defaultLatencyWindow
+	}
+	return h.LatencyWindow
+}
+
+// defaultKeyCooldown 未配置 key_pool.cooldown 时 Key 的隔离时长
+const defaultKeyCooldown = time.Minute
+
+// GetCooldown 获取 Key 的隔离时长
+func (k KeyPoolConfig) GetCooldown() time.Duration {
+	if k.Cooldown <= 0 {
+		return defaultKeyCooldown
+	}
+	return k.Cooldown
+}
+
+// GetProviderKeys 返回指定 Provider 的全部 API Key（api_key 在前，去重并保持顺序）及密钥池配置
+func (c *Config) GetProviderKeys(provider string) ([]string, KeyPoolConfig) {
+	var apiKey string
+	var pool KeyPoolConfig
+	switch provider {
+	case "svgio":
+		apiKey, pool = c.Providers.SVGIO.APIKey, c.Providers.SVGIO.KeyPool
+	case "recraft":
+		apiKey, pool = c.Providers.Recraft.APIKey, c.Providers.Recraft.KeyPool
+	case "claude":
+		apiKey, pool = c.Providers.Claude.APIKey, c.Providers.Claude.KeyPool
+	default:
+		return nil, pool
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range append([]string{apiKey}, pool.Keys...) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, pool
+}
+
+// 用量核算默认值
+const (
+	defaultTenantHeader     = "X-Tenant-Id"
+	defaultMaxMemoryEntries = 100000
+	defaultCurrency         = "USD"
+)
+
+// GetTenantHeader 获取标识租户的请求头
+func (s SecurityConfig) GetTenantHeader() string {
+	if s.TenantHeader == "" {
+		return defaultTenantHeader
+	}
+	return s.TenantHeader
+}
+
+// GetMaxMemoryEntries 获取内存台账保留的记录数
+func (u UsageConfig) GetMaxMemoryEntries() int {
+	if u.MaxMemoryEntries <= 0 {
+		return defaultMaxMemoryEntries
+	}
+	return u.MaxMemoryEntries
+}
+
+// GetCurrency 获取计费币种
+func (u UsageConfig) GetCurrency() string {
+	if u.Currency == "" {
+		return defaultCurrency
+	}
+	return u.Currency
+}
+
+// GetPrice 获取指定 Provider 的价格
+func (p PricingConfig) GetPrice(provider string) PriceConfig {
+	switch provider {
+	case "svgio":
+		return p.SVGIO
+	case "recraft":
+		return p.Recraft
+	case "claude":
+		return p.Claude
+	default:
+		return PriceConfig{}
+	}
+}
+
+// 预算默认值
+const (
+	defaultBudgetPeriod       = "monthly"
+	defaultBudgetAlertTimeout = 5 * time.Second
+)
+
+// GetPeriod 获取预算统计周期
+func (b BudgetsConfig) GetPeriod() string {
+	if b.Period == "" {
+		return defaultBudgetPeriod
+	}
+	return b.Period
+}
+
+// GetLocation 获取预算周期使用的时区，未配置或无法加载时使用 UTC
+func (b BudgetsConfig) GetLocation() *time.Location {
+	if b.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// TenantLimit 获取租户的支出上限：优先使用 tenants 中的单独配置，否则使用 default_tenant
+func (b BudgetsConfig) TenantLimit(tenant string) BudgetLimit {
+	if limit, ok := b.Tenants[tenant]; ok {
+		return limit
+	}
+	return b.DefaultTenant
+}
+
+// GetTimeout 获取告警推送超时
+func (a BudgetAlertConfig) GetTimeout() time.Duration {
+	if a.Timeout <= 0 {
+		return defaultBudgetAlertTimeout
+	}
+	return a.Timeout
+}
+
+// defaultAuditFile 未配置 audit.file_path 时的审计日志文件
+const defaultAuditFile = "logs/audit.jsonl"
+
+// GetFilePath 获取审计日志文件路径
+func (a AuditConfig) GetFilePath() string {
+	if a.FilePath == "" {
+		return defaultAuditFile
+	}
+	return a.FilePath
+}
+
+// 审核默认值
+const (
+	defaultModerationLevel     = "standard"
+	defaultClassifierTimeout   = 10 * time.Second
+	defaultClassifierModel     = "gpt-4o-mini"
+	defaultClassifierMinLevel  = "strict"
+	defaultModerationRuleLevel = "standard"
+)
+
+// TenantLevel 获取租户的审核级别；未开启审核时为 off
+func (m ModerationConfig) TenantLevel(tenant string) string {
+	if !m.Enabled {
+		return "off"
+	}
+	if level, ok := m.Tenants[tenant]; ok && level != "" {
+		return strings.ToLower(level)
+	}
+	if m.DefaultLevel == "" {
+		return defaultModerationLevel
+	}
+	return strings.ToLower(m.DefaultLevel)
+}
+
+// GetMinLevel 获取规则生效的最低级别
+func (r ModerationRule) GetMinLevel() string {
+	if r.MinLevel == "" {
+		return defaultModerationRuleLevel
+	}
+	return strings.ToLower(r.MinLevel)
+}
+
+// GetTimeout 获取分类器请求超时
+func (c ModerationClassifierConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return defaultClassifierTimeout
+	}
+	return c.Timeout
+}
+
+// GetModel 获取分类器模型
+func (c ModerationClassifierConfig) GetModel() string {
+	if c.Model == "" {
+		return defaultClassifierModel
+	}
+	return c.Model
+}
+
+// GetMinLevel 获取分类器生效的最低级别
+func (c ModerationClassifierConfig) GetMinLevel() string {
+	if c.MinLevel == "" {
+		return defaultClassifierMinLevel
+	}
+	return strings.ToLower(c.MinLevel)
+}
+
+// 翻译器类型
+const (
+	TranslatorOpenAI     = "openai"
+	TranslatorDictionary = "dictionary"
+)
+
+// 提示词增强级别
+const (
+	EnhanceOff      = "off"
+	EnhanceLight    = "light"
+	EnhanceDetailed = "detailed"
+)
+
+// 提示词增强的默认值
+const (
+	defaultEnhancementTimeout         = 20 * time.Second
+	defaultEnhancementMaxTokens       = 1024
+	defaultEnhancementMaxInputLength  = 200
+	defaultEnhancementMaxOutputLength = 1000
+)
+
+// GetDefaultLevel 获取请求未指定时的增强级别
+func (e EnhancementConfig) GetDefaultLevel() string {
+	if e.DefaultLevel == "" {
+		return EnhanceOff
+	}
+	return strings.ToLower(e.DefaultLevel)
+}
+
+// GetMaxInputLength 获取触发增强的最大提示词长度
+func (e EnhancementConfig) GetMaxInputLength() int {
+	if e.MaxInputLength <= 0 {
+		return defaultEnhancementMaxInputLength
+	}
+	return e.MaxInputLength
+}
+
+// GetEnhancement 获取补全默认值后的提示词增强配置，service_url、api_key 和 model 未配置时继承 translation
+func (c *Config) GetEnhancement() EnhancementConfig {
+	e := c.Enhancement
+	if e.ServiceURL == "" {
+		e.ServiceURL = c.Translation.ServiceURL
+	}
+	if e.APIKey == "" {
+		e.APIKey = c.Translation.APIKey
+	}
+	if e.Model == "" {
+		e.Model = c.Translation.DefaultModel
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = defaultEnhancementTimeout
+	}
+	if e.MaxTokens <= 0 {
+		e.MaxTokens = defaultEnhancementMaxTokens
+	}
+	if e.MaxOutputLength <= 0 {
+		e.MaxOutputLength = defaultEnhancementMaxOutputLength
+	}
+	e.DefaultLevel = e.GetDefaultLevel()
+	e.MaxInputLength = e.GetMaxInputLength()
+	return e
+}
+
+// defaultTranslationTimeout 翻译器未配置超时时的默认值
+const defaultTranslationTimeout = 45 * time.Second
+
+// GetTimeout 获取翻译器的默认超时
+func (t TranslationConfig) GetTimeout() time.Duration {
+	if t.Timeout <= 0 {
+		return defaultTranslationTimeout
+	}
+	return t.Timeout
+}
+
+// defaultTranslationMaxTokens 翻译请求的默认 max_tokens，需容纳推理模型的思考过程
+const defaultTranslationMaxTokens = 1024
+
+// GetMaxTokens 获取翻译请求的默认 max_tokens
+func (t TranslationConfig) GetMaxTokens() int {
+	if t.MaxTokens <= 0 {
+		return defaultTranslationMaxTokens
+	}
+	return t.MaxTokens
+}
+
+// defaultClaudeTemperature 未配置 providers.claude.temperature 时的采样温度
+const defaultClaudeTemperature = 0.7
+
+// defaultMinConfidence 触发翻译的最低语言检测置信度
+const defaultMinConfidence = 0.3
+
+// GetMinConfidence 获取触发翻译的最低语言检测置信度
+func (t TranslationConfig) GetMinConfidence() float64 {
+	if t.MinConfidence <= 0 {
+		return defaultMinConfidence
+	}
+	return t.MinConfidence
+}
+
+// GetTranslators 获取补全默认值后的翻译器链。未配置 translators 时，由 default_model 生成；
+// fallback_enabled 时依次追加 fallback_models 和离线词典
+func (t TranslationConfig) GetTranslators() []TranslatorConfig {
+	translators := t.Translators
+	if len(translators) == 0 {
+		translators = []TranslatorConfig{{Name: "primary", Model: t.DefaultModel}}
+		if t.FallbackEnabled {
+			for _, model := range t.FallbackModels {
+				translators = append(translators, TranslatorConfig{Name: model, Model: model})
+			}
+			translators = append(translators, TranslatorConfig{Name: TranslatorDictionary, Type: TranslatorDictionary})
+		}
+	}
+
+	out := make([]TranslatorConfig, len(translators))
+	for i, tr := range translators {
+		if tr.Type == "" {
+			tr.Type = TranslatorOpenAI
+		}
+		tr.Type = strings.ToLower(tr.Type)
+		if tr.Type == TranslatorOpenAI {
+			if tr.ServiceURL == "" {
+				tr.ServiceURL = t.ServiceURL
+			}
+			if tr.APIKey == "" {
+				tr.APIKey = t.APIKey
+			}
+			if tr.Model == "" {
+				tr.Model = t.DefaultModel
+			}
+		}
+		if tr.Name == "" {
+			tr.Name = tr.Type
+			if tr.Model != "" {
+				tr.Name = tr.Model
+			}
+		}
+		if tr.Timeout <= 0 {
+			tr.Timeout = t.GetTimeout()
+		}
+		if tr.MaxTokens <= 0 {
+			tr.MaxTokens = t.GetMaxTokens()
+		}
+		out[i] = tr
+	}
+	return out
+}
+
+// 翻译缓存默认值
+const (
+	defaultTranslationCacheBackend = "memory"
+	defaultTranslationCacheEntries = 10000
+	defaultTranslationCacheTTL     = 7 * 24 * time.Hour
+	defaultRedisKeyPrefix          = "svggen:translate:"
+	defaultRedisTimeout            = 2 * time.Second
+	defaultGlossaryDir             = "data/glossaries"
+	defaultGlossaryEntries         = 500
+)
+
+// GetBackend 获取缓存后端
+func (c TranslationCacheConfig) GetBackend() string {
+	if c.Backend == "" {
+		return defaultTranslationCacheBackend
+	}
+	return strings.ToLower(c.Backend)
+}
+
+// GetMaxEntries 获取进程内缓存的最大条目数
+func (c TranslationCacheConfig) GetMaxEntries() int {
+	if c.MaxEntries <= 0 {
+		return defaultTranslationCacheEntries
+	}
+	return c.MaxEntries
+}
+
+// GetTTL 获取缓存有效期
+func (c TranslationCacheConfig) GetTTL() time.Duration {
+	if c.TTL <= 0 {
+		return defaultTranslationCacheTTL
+	}
+	return c.TTL
+}
+
+// GetDir 获取术语表存储目录
+func (g GlossaryConfig) GetDir() string {
+	if g.Dir == "" {
+		return defaultGlossaryDir
+	}
+	return g.Dir
+}
+
+// GetMaxEntries 获取单个租户术语表的最大条目数
+func (g GlossaryConfig) GetMaxEntries() int {
+	if g.MaxEntries <= 0 {
+		return defaultGlossaryEntries
+	}
+	return g.MaxEntries
+}
+
+// GetKeyPrefix 获取 Redis 键前缀
+func (r RedisConfig) GetKeyPrefix() string {
+	if r.KeyPrefix == "" {
+		return defaultRedisKeyPrefix
+	}
+	return r.KeyPrefix
+}
+
+// GetTimeout 获取 Redis 单次操作超时
+func (r RedisConfig) GetTimeout() time.Duration {
+	if r.Timeout <= 0 {
+		return defaultRedisTimeout
+	}
+	return r.Timeout
+}
+
+// 脱敏默认值
+const defaultPrivacyAction = "mask"
+
+// TenantPolicy 获取租户的处理方式和是否允许保存原文
+func (p PrivacyConfig) TenantPolicy(tenant string) (action string, storeOriginal bool) {
+	action = p.Action
+	t, ok := p.Tenants[tenant]
+	if ok && t.Action != "" {
+		action = t.Action
+	}
+	if action == "" {
+		action = defaultPrivacyAction
+	}
+	return strings.ToLower(action), ok && t.StoreOriginal
+}
+
+// GetProviderTimeout 获取指定 Provider 的上游调用超时，0 表示只受请求整体时限约束
+func (c *Config) GetProviderTimeout(provider string) time.Duration {
+	switch provider {
+	case "svgio":
+		return c.Providers.SVGIO.Timeout
+	case "recraft":
+		return c.Providers.Recraft.Timeout
+	case "claude":
+		return c.Providers.Claude.Timeout
+	default:
+		return 0
+	}
+}
+
+// defaultProviderLanguages 各 Provider 默认支持的提示词语言：SVG.IO 只理解英文，Recraft 和 Claude 支持任意语言
+var defaultProviderLanguages = map[string][]string{
+	"svgio":   {"en"},
+	"recraft": {"*"},
+	"claude":  {"*"},
+}
+
+// GetProviderLanguages 获取 Provider 支持的提示词语言
+func (c *Config) GetProviderLanguages(provider string) []string {
+	var languages []string
+	switch provider {
+	case "svgio":
+		languages = c.Providers.SVGIO.SupportedLanguages
+	case "recraft":
+		languages = c.Providers.Recraft.SupportedLanguages
+	case "claude":
+		languages = c.Providers.Claude.SupportedLanguages
+	}
+	if len(languages) == 0 {
+		return defaultProviderLanguages[provider]
+	}
+	return languages
+}
+
+// ProviderSupportsLanguage 判断 Provider 能否直接使用该语言的提示词
+func (c *Config) ProviderSupportsLanguage(provider, lang string) bool {
+	for _, l := range c.GetProviderLanguages(provider) {
+		if l == "*" || strings.EqualFold(l, lang) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsProviderEnabled 检查Provider是否启用
 func (c *Config) IsProviderEnabled(provider string) bool {
 	switch provider {
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overridablePaths 允许通过管理接口在运行时修改的配置项，均为无需重启即可生效的字段
+var overridablePaths = map[string]bool{
+	"providers.svgio.enabled":         true,
+	"providers.svgio.timeout":         true,
+	"providers.svgio.max_retries":     true,
+	"providers.recraft.enabled":       true,
+	"providers.recraft.timeout":       true,
+	"providers.recraft.max_retries":   true,
+	"providers.recraft.default_model": true,
+	"providers.claude.enabled":        true,
+	"providers.claude.timeout":        true,
+	"providers.claude.max_retries":    true,
+	"providers.claude.default_model":  true,
+	"providers.claude.max_tokens":     true,
+	"providers.claude.temperature":    true,
+	"translation.enabled":             true,
+	"translation.default_model":       true,
+	"translation.timeout":             true,
+	"features.enable_cors":            true,
+	"features.enable_rate_limiting":   true,
+	"features.enable_caching":         true,
+	"logging.level":                   true,
+	"logging.redact_prompts":          true,
+	"server.timeout":                  true,
+}
+
+var (
+	// loaded 最近一次从配置文件和环境变量加载的配置，不含运行时覆盖
+	loaded *Config
+	// overrides 运行时覆盖的配置项（YAML 路径 -> 值），叠加在 loaded 之上，重新加载配置文件后仍然保留
+	overrides = map[string]string{}
+)
+
+// OverridablePaths 返回允许在运行时修改的配置项路径
+func OverridablePaths() []string {
+	paths := make([]string, 0, len(overridablePaths))
+	for path := range overridablePaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Overrides 返回当前生效的运行时覆盖项副本
+func Overrides() map[string]string {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	out := make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		out[k] = v
+	}
+	return out
+}
+
+// SetOverrides 修改运行时覆盖项并立即应用，值为 nil 表示删除该覆盖项、恢复配置文件中的值。
+// 新配置校验失败时不做任何修改；配置了 admin.overrides_file 时写回该文件
+func SetOverrides(changes map[string]*string) (*Config, error) {
+	for path := range changes {
+		if !overridablePaths[path] {
+			return nil, fmt.Errorf("%s: cannot be changed at runtime", path)
+		}
+	}
+
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next := make(map[string]string, len(overrides)+len(changes))
+	for k, v := range overrides {
+		next[k] = v
+	}
+	for path, value := range changes {
+		if value == nil {
+			delete(next, path)
+		} else {
+			next[path] = *value
+		}
+	}
+	return swapOverrides(next)
+}
+
+// ResetOverrides 清除全部运行时覆盖项，恢复为配置文件中的值
+func ResetOverrides() (*Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return swapOverrides(map[string]string{})
+}
+
+// swapOverrides 在 loaded 上应用新的覆盖项并替换当前配置，调用方需持有 reloadMu
+func swapOverrides(next map[string]string) (*Config, error) {
+	if loaded == nil {
+		return nil, errors.New("configuration not initialized")
+	}
+	config, err := withOverrides(loaded, next)
+	if err != nil {
+		return nil, err
+	}
+	if path := loaded.Admin.OverridesFile; path != "" {
+		if err := saveOverrides(path, next); err != nil {
+			return nil, fmt.Errorf("failed to persist overrides: %w", err)
+		}
+	}
+
+	overrides = next
+	old := current.Swap(config)
+	for _, fn := range subscribers {
+		fn(old, config)
+	}
+	return config, nil
+}
+
+// withOverrides 返回叠加了覆盖项并重新校验的配置副本，不修改 base
+func withOverrides(base *Config, values map[string]string) (*Config, error) {
+	if len(values) == 0 {
+		return base, nil
+	}
+	config := *base
+	for path, raw := range values {
+		if err := setPath(&config, path, raw); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &config, nil
+}
+
+// setPath 按 YAML 路径定位字段并按字段类型解析赋值，解析规则与 SVGGEN_* 环境变量相同
+func setPath(config *Config, path, raw string) error {
+	v := reflect.ValueOf(config).Elem()
+	for _, segment := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return fmt.Errorf("%s: unknown setting", path)
+		}
+		found := false
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] == segment {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: unknown setting", path)
+		}
+	}
+	if v.Kind() == reflect.Struct && v.Type() != durationType {
+		return fmt.Errorf("%s: not a single setting", path)
+	}
+	if err := setFromEnv(v, raw); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// loadOverrides 读取持久化的覆盖项文件，文件不存在时返回空集合
+func loadOverrides(path string) (map[string]string, error) {
+	values := map[string]string{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for key := range values {
+		if !overridablePaths[key] {
+			return nil, fmt.Errorf("%s: %s cannot be changed at runtime", path, key)
+		}
+	}
+	return values, nil
+}
+
+// saveOverrides 原子写入覆盖项文件，没有覆盖项时删除文件
+func saveOverrides(path string, values map[string]string) error {
+	if len(values) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append([]byte("# 由管理接口写入的运行时覆盖项，启动时叠加在配置文件之上\n"), data...), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// applyStoredOverrides 加载配置后叠加内存中的覆盖项；首次加载时从 admin.overrides_file 读取。
+// 覆盖项与新配置冲突（校验失败）时丢弃覆盖项并记录警告，避免启动或重新加载被过期的覆盖项阻塞
+func applyStoredOverrides(config *Config, first bool) (*Config, error) {
+	if first && config.Admin.OverridesFile != "" {
+		values, err := loadOverrides(config.Admin.OverridesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load overrides: %w", err)
+		}
+		overrides = values
+	}
+
+	effective, err := withOverrides(config, overrides)
+	if err != nil {
+		slog.Default().With("component", "config").Warn("runtime overrides conflict with reloaded configuration, discarding them",
+			"overrides", overrides, "error", err)
+		overrides = map[string]string{}
+		return config, nil
+	}
+	return effective, nil
+}
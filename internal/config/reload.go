@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// current 当前生效的配置，重新加载时整体原子替换
+var current atomic.Pointer[Config]
+
+// Get 返回当前生效的配置快照，调用方不应修改返回值。
+// 同一请求内需要一致的配置时应只调用一次并复用结果
+func Get() *Config {
+	return current.Load()
+}
+
+// ReloadFunc 配置替换后的回调
+type ReloadFunc func(old, new *Config)
+
+var (
+	reloadMu    sync.Mutex
+	subscribers []ReloadFunc
+)
+
+// OnReload 注册配置替换回调，按注册顺序同步执行
+func OnReload(fn ReloadFunc) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// Reload 重新加载并校验配置文件，校验失败时保留当前配置并返回错误
+func Reload(configPath string) error {
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	// 运行时覆盖项在重新加载配置文件后继续生效
+	effective, err := applyStoredOverrides(config, false)
+	if err != nil {
+		return err
+	}
+	loaded = config
+	config = effective
+
+	old := current.Swap(config)
+	for _, fn := range subscribers {
+		fn(old, config)
+	}
+	return nil
+}
+
+// RestartRequired 返回新旧配置之间无法在运行时生效、需要重启才能应用的配置项
+func RestartRequired(old, new *Config) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	check("server.host", old.Server.Host != new.Server.Host)
+	check("server.port", old.Server.Port != new.Server.Port)
+	check("server.read_timeout", old.Server.ReadTimeout != new.Server.ReadTimeout)
+	check("server.write_timeout", old.Server.WriteTimeout != new.Server.WriteTimeout)
+	check("server.idle_timeout", old.Server.IdleTimeout != new.Server.IdleTimeout)
+	check("logging.format", old.Logging.Format != new.Logging.Format)
+	check("logging.output", old.Logging.Output != new.Logging.Output)
+	check("logging.file_path", old.Logging.FilePath != new.Logging.FilePath)
+	check("features.enable_metrics", old.Features.EnableMetrics != new.Features.EnableMetrics)
+	check("features.enable_tracing", old.Features.EnableTracing != new.Features.EnableTracing)
+	check("security.enable_request_id", old.Security.EnableRequestID != new.Security.EnableRequestID)
+	check("translation.cache", old.Translation.Cache.Enabled != new.Translation.Cache.Enabled ||
+		old.Translation.Cache.Backend != new.Translation.Cache.Backend ||
+		old.Translation.Cache.MaxEntries != new.Translation.Cache.MaxEntries ||
+		old.Translation.Cache.WarmFile != new.Translation.Cache.WarmFile ||
+		old.Translation.Cache.Redis != new.Translation.Cache.Redis)
+	check("translation.glossary.dir", old.Translation.Glossary.Dir != new.Translation.Glossary.Dir)
+	check("tracing", !tracingEqual(old.Tracing, new.Tracing))
+	check("reload", old.Reload != new.Reload)
+	check("usage.ledger_path", old.Usage.LedgerPath != new.Usage.LedgerPath)
+	check("usage.max_memory_entries", old.Usage.MaxMemoryEntries != new.Usage.MaxMemoryEntries)
+	check("audit", old.Audit.Enabled != new.Audit.Enabled || old.Audit.FilePath != new.Audit.FilePath ||
+		old.Audit.MaxSizeMB != new.Audit.MaxSizeMB || old.Audit.HMACKey != new.Audit.HMACKey)
+	return fields
+}
+
+func tracingEqual(a, b TracingConfig) bool {
+	if a.ServiceName != b.ServiceName || a.Exporter != b.Exporter || a.Endpoint != b.Endpoint ||
+		a.FilePath != b.FilePath || a.SampleRatio != b.SampleRatio || a.Timeout != b.Timeout ||
+		len(a.Headers) != len(b.Headers) {
+		return false
+	}
+	for k, v := range a.Headers {
+		if b.Headers[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultWatchInterval 未配置 reload.interval 时检查配置文件的间隔
+const defaultWatchInterval = 2 * time.Second
+
+// Watch 轮询配置文件（含环境配置文件）内容，变化时调用 Reload，ctx 取消后停止。
+// 使用内容摘要而非修改时间判断变化，编辑器的原子替换写入和仅 touch 都能正确处理
+func Watch(ctx context.Context, configPath string, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	logger := slog.Default().With("component", "config")
+
+	lastSum := filesSum(ConfigFiles(configPath))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sum := filesSum(ConfigFiles(configPath))
+		if sum == nil || bytes.Equal(sum, lastSum) {
+			continue
+		}
+		lastSum = sum
+
+		logger.Info("config file changed, reloading", "path", configPath)
+		ReloadAndLog(configPath)
+	}
+}
+
+// ReloadAndLog 重新加载配置并记录结果，供文件监听和 SIGHUP 共用
+func ReloadAndLog(configPath string) {
+	logger := slog.Default().With("component", "config")
+
+	old := Get()
+	if err := Reload(configPath); err != nil {
+		logger.Error("config reload rejected, keeping previous configuration", "path", configPath, "error", err)
+		return
+	}
+	logger.Info("configuration reloaded", "path", configPath)
+	if fields := RestartRequired(old, Get()); len(fields) > 0 {
+		logger.Warn("some configuration changes require a restart to take effect", "fields", fields)
+	}
+}
+
+// filesSum 计算全部配置文件内容的摘要，读取失败（如编辑器写入过程中）时返回 nil
+func filesSum(paths []string) []byte {
+	h := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		h.Write(data)
+	}
+	return h.Sum(nil)
+}
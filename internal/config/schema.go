@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaID 生成的 JSON Schema 标识，与仓库中的 config.schema.json 对应
+const SchemaID = "https://svg-generator.local/config.schema.json"
+
+// 字符串形式配置项的格式
+const (
+	durationPattern = `^(0|-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+	byteSizePattern = `^[0-9]+ *([GMKgmk][Bb]?|[Bb])?$`
+)
+
+// schemaHints 按 YAML 路径补充反射无法得到的约束，与 validate.go 中的校验保持一致
+var schemaHints = map[string]map[string]interface{}{
+	"server.port":                         {"minimum": 1, "maximum": 65535},
+	"providers.svgio.max_retries":         {"minimum": 0, "maximum": maxRetries},
+	"providers.recraft.max_retries":       {"minimum": 0, "maximum": maxRetries},
+	"providers.claude.max_retries":        {"minimum": 0, "maximum": maxRetries},
+	"providers.claude.max_tokens":         {"minimum": 0, "maximum": maxClaudeTokens},
+	"providers.claude.temperature":        {"minimum": 0, "maximum": 2},
+	"translation.max_retries":             {"minimum": 0, "maximum": maxRetries},
+	"enhancement.default_level":           {"enum": []string{"off", "light", "detailed"}},
+	"enhancement.max_tokens":              {"minimum": 0, "maximum": 32768},
+	"logging.level":                       {"enum": []string{"debug", "info", "warn", "warning", "error"}},
+	"logging.format":                      {"enum": []string{"json", "text"}},
+	"logging.max_size_mb":                 {"minimum": 0, "maximum": 10240},
+	"logging.max_backups":                 {"minimum": 0, "maximum": 1000},
+	"logging.max_age_days":                {"minimum": 0, "maximum": 3650},
+	"security.max_request_size":           {"pattern": byteSizePattern},
+	"security.download.max_size":          {"pattern": byteSizePattern},
+	"security.download.max_redirects":     {"minimum": -1, "maximum": maxRedirects},
+	"tracing.exporter":                    {"enum": []string{"otlp", "stdout", "file"}},
+	"tracing.sample_ratio":                {"minimum": 0, "maximum": 1},
+	"health.breaker_failure_threshold":    {"minimum": 0, "maximum": 1000},
+	"health.latency_window":               {"minimum": 0, "maximum": maxLatencyWindow},
+	"http_client.max_idle_conns":          {"minimum": 0, "maximum": 10000},
+	"http_client.max_idle_conns_per_host": {"minimum": 0, "maximum": 10000},
+	"usage.currency":                      {"pattern": "^[A-Z]{3}$"},
+	"usage.max_memory_entries":            {"minimum": 0, "maximum": 10000000},
+	"audit.max_size_mb":                   {"minimum": 0, "maximum": 10240},
+	"moderation.default_level":            {"enum": []string{"off", "relaxed", "standard", "strict"}},
+	"moderation.tenants.*":                {"enum": []string{"off", "relaxed", "standard", "strict"}},
+	"moderation.rules[].min_level":        {"enum": []string{"relaxed", "standard", "strict"}},
+	"moderation.classifier.min_level":     {"enum": []string{"relaxed", "standard", "strict"}},
+	"translation.translators[].type":      {"enum": []string{"openai", "dictionary"}},
+	"translation.cache.backend":           {"enum": []string{"memory", "redis"}},
+	"privacy.action":                      {"enum": []string{"mask", "reject"}},
+	"privacy.detectors[]":                 {"enum": []string{"email", "phone", "cn_id", "credit_card", "ip_address"}},
+	"privacy.tenants.*.action":            {"enum": []string{"mask", "reject"}},
+	"budgets.period":                      {"enum": []string{"daily", "weekly", "monthly"}},
+	"budgets.global.on_exceed":            {"enum": []string{"reject", "downgrade"}},
+	"budgets.default_tenant.on_exceed":    {"enum": []string{"reject", "downgrade"}},
+	"budgets.tenants.*.on_exceed":         {"enum": []string{"reject", "downgrade"}},
+}
+
+// JSONSchema 由配置结构体生成 JSON Schema (draft 2020-12)，供编辑器补全和校验 config.yaml
+func JSONSchema() ([]byte, error) {
+	root := schemaFor(reflect.TypeOf(Config{}), "")
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "svg-generator configuration"
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func schemaFor(t reflect.Type, path string) map[string]interface{} {
+	var s map[string]interface{}
+
+	switch {
+	case t == durationType:
+		s = map[string]interface{}{"type": "string", "pattern": durationPattern}
+	case t.Kind() == reflect.Struct:
+		props := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			child := tag
+			if path != "" {
+				child = path + "." + tag
+			}
+			props[tag] = schemaFor(field.Type, child)
+		}
+		s = map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case t.Kind() == reflect.String:
+		s = map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		s = map[string]interface{}{"type": "boolean"}
+	case t.Kind() == reflect.Int, t.Kind() == reflect.Int64:
+		s = map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float64:
+		s = map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		s = map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), path+"[]")}
+	case t.Kind() == reflect.Map:
+		s = map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), path+".*")}
+	default:
+		s = map[string]interface{}{}
+	}
+
+	for k, v := range schemaHints[path] {
+		s[k] = v
+	}
+	return s
+}
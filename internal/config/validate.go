@@ -0,0 +1,723 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"svg-generator/internal/requestid"
+)
+
+// ValidationError 配置校验失败，包含全部问题而不是只报告第一个
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// validator 收集校验问题，每条问题以 YAML 路径开头
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) intRange(path string, value, min, max int) {
+	if value < min || value > max {
+		v.addf(path, "must be between %d and %d (got %d)", min, max, value)
+	}
+}
+
+func (v *validator) floatRange(path string, value, min, max float64) {
+	if value < min || value > max {
+		v.addf(path, "must be between %g and %g (got %g)", min, max, value)
+	}
+}
+
+func (v *validator) duration(path string, value, max time.Duration) {
+	if value < 0 {
+		v.addf(path, "must not be negative (got %s)", value)
+	} else if max > 0 && value > max {
+		v.addf(path, "must not exceed %s (got %s)", max, value)
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+	v.addf(path, "must be one of %s (got %q)", strings.Join(quoteAll(allowed), ", "), value)
+}
+
+func (v *validator) httpURL(path, value string, required bool) {
+	if value == "" {
+		if required {
+			v.addf(path, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(path, "must be an absolute http(s) URL (got %q)", value)
+	}
+}
+
+func (v *validator) endpoint(path, value string) {
+	if value != "" && !strings.HasPrefix(value, "/") {
+		v.addf(path, "must start with \"/\" (got %q)", value)
+	}
+}
+
+func (v *validator) byteSize(path, value string, max int64) {
+	if value == "" {
+		return
+	}
+	n, err := ParseByteSize(value)
+	if err != nil {
+		v.addf(path, "%v", err)
+	} else if n > max {
+		v.addf(path, "must not exceed %s (got %q)", formatBytes(max), value)
+	}
+}
+
+// headerNamePattern HTTP 请求头名称 (RFC 7230 token)
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func (v *validator) headerName(path, value string) {
+	if value != "" && !headerNamePattern.MatchString(value) {
+		v.addf(path, "is not a valid HTTP header name (got %q)", value)
+	}
+}
+
+func (v *validator) nonEmptyUnique(path string, values []string) {
+	seen := make(map[string]bool, len(values))
+	for i, value := range values {
+		item := fmt.Sprintf("%s[%d]", path, i)
+		if strings.TrimSpace(value) == "" {
+			v.addf(item, "must not be empty")
+		} else if seen[value] {
+			v.addf(item, "duplicate value %q", value)
+		}
+		seen[value] = true
+	}
+}
+
+// hostPattern 下载白名单主机名，允许 *. 通配前缀
+var hostPattern = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+// languagePattern ISO 639-1/639-3 语言代码
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+func (v *validator) languages(path string, values []string) {
+	for i, lang := range values {
+		if lang != "*" && !languagePattern.MatchString(lang) {
+			v.addf(fmt.Sprintf("%s[%d]", path, i), "must be a lowercase ISO 639 language code or \"*\" (got %q)", lang)
+		}
+	}
+}
+
+func (v *validator) hosts(path string, values []string) {
+	v.nonEmptyUnique(path, values)
+	for i, value := range values {
+		if value != "" && !hostPattern.MatchString(value) {
+			v.addf(fmt.Sprintf("%s[%d]", path, i), "must be a host name such as \"example.com\" or \"*.example.com\" (got %q)", value)
+		}
+	}
+}
+
+// 各配置项的取值上限
+const (
+	maxTimeout       = 30 * time.Minute
+	maxRetries       = 10
+	maxRequestSize   = 1 << 30
+	maxDownloadSize  = 1 << 30
+	maxRedirects     = 10
+	maxLatencyWindow = 10000
+	maxClaudeTokens  = 200000
+)
+
+// validateConfig 校验配置的取值范围和字段间约束，返回 *ValidationError
+func validateConfig(config *Config) error {
+	v := &validator{}
+
+	validateServer(v, config.Server)
+	validateProviders(v, config.Providers)
+	validateTranslation(v, config.Translation)
+	validateEnhancement(v, config)
+	validateHTTPClient(v, config.HTTPClient)
+	validateLogging(v, config.Logging)
+	validateSecurity(v, config.Security)
+	validateTracing(v, config.Tracing, config.Features.EnableTracing)
+	validateHealth(v, config.Health)
+	validateReload(v, config.Reload)
+	validateAdmin(v, config.Admin)
+	validateUsage(v, config.Usage)
+	validateBudgets(v, config.Budgets, config.Usage.Enabled)
+	validateModeration(v, config.Moderation)
+	validatePrivacy(v, config.Privacy)
+	validateAudit(v, config.Audit)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func validateServer(v *validator, s ServerConfig) {
+	v.intRange("server.port", s.Port, 1, 65535)
+	v.duration("server.timeout", s.Timeout, maxTimeout)
+	v.duration("server.read_timeout", s.ReadTimeout, maxTimeout)
+	v.duration("server.write_timeout", s.WriteTimeout, maxTimeout)
+	v.duration("server.idle_timeout", s.IdleTimeout, maxTimeout)
+	v.duration("server.shutdown_timeout", s.ShutdownTimeout, 10*time.Minute)
+	v.duration("server.shutdown_delay", s.ShutdownDelay, 5*time.Minute)
+
+	// 写超时需覆盖整个请求处理时间，否则超时错误无法返回给客户端
+	if s.WriteTimeout > 0 && s.Timeout > s.WriteTimeout {
+		v.addf("server.timeout", "must not exceed server.write_timeout (%s > %s)", s.Timeout, s.WriteTimeout)
+	}
+}
+
+func validateProviders(v *validator, p ProvidersConfig) {
+	if !p.SVGIO.Enabled && !p.Recraft.Enabled && !p.Claude.Enabled {
+		v.addf("providers", "at least one provider must be enabled")
+	}
+
+	v.httpURL("providers.svgio.base_url", p.SVGIO.BaseURL, p.SVGIO.Enabled)
+	v.endpoint("providers.svgio.endpoints.generate", p.SVGIO.Endpoints.Generate)
+	v.endpoint("providers.svgio.endpoints.get_image", p.SVGIO.Endpoints.GetImage)
+	v.duration("providers.svgio.timeout", p.SVGIO.Timeout, maxTimeout)
+	v.intRange("providers.svgio.max_retries", p.SVGIO.MaxRetries, 0, maxRetries)
+	v.hosts("providers.svgio.allowed_download_hosts", p.SVGIO.AllowedDownloadHosts)
+	v.headerName("providers.svgio.request_id_header", p.SVGIO.RequestIDHeader)
+	v.languages("providers.svgio.supported_languages", p.SVGIO.SupportedLanguages)
+	validateKeyPool(v, "providers.svgio.key_pool", p.SVGIO.KeyPool)
+	if p.SVGIO.Enabled && p.SVGIO.Endpoints.Generate == "" {
+		v.addf("providers.svgio.endpoints.generate", "is required when the provider is enabled")
+	}
+
+	v.httpURL("providers.recraft.base_url", p.Recraft.BaseURL, p.Recraft.Enabled)
+	v.endpoint("providers.recraft.endpoints.generate", p.Recraft.Endpoints.Generate)
+	v.endpoint("providers.recraft.endpoints.vectorize", p.Recraft.Endpoints.Vectorize)
+	v.endpoint("providers.recraft.endpoints.user", p.Recraft.Endpoints.User)
+	v.duration("providers.recraft.timeout", p.Recraft.Timeout, maxTimeout)
+	v.intRange("providers.recraft.max_retries", p.Recraft.MaxRetries, 0, maxRetries)
+	v.nonEmptyUnique("providers.recraft.supported_models", p.Recraft.SupportedModels)
+	v.hosts("providers.recraft.allowed_download_hosts", p.Recraft.AllowedDownloadHosts)
+	v.headerName("providers.recraft.request_id_header", p.Recraft.RequestIDHeader)
+	v.languages("providers.recraft.supported_languages", p.Recraft.SupportedLanguages)
+	validateKeyPool(v, "providers.recraft.key_pool", p.Recraft.KeyPool)
+	if p.Recraft.Enabled && p.Recraft.Endpoints.Generate == "" {
+		v.addf("providers.recraft.endpoints.generate", "is required when the provider is enabled")
+	}
+	if p.Recraft.DefaultModel != "" && len(p.Recraft.SupportedModels) > 0 &&
+		!containsString(p.Recraft.SupportedModels, p.Recraft.DefaultModel) {
+		v.addf("providers.recraft.default_model", "%q is not listed in providers.recraft.supported_models (%s)",
+			p.Recraft.DefaultModel, strings.Join(p.Recraft.SupportedModels, ", "))
+	}
+
+	v.httpURL("providers.claude.base_url", p.Claude.BaseURL, p.Claude.Enabled)
+	v.endpoint("providers.claude.endpoints.chat", p.Claude.Endpoints.Chat)
+	v.endpoint("providers.claude.endpoints.models", p.Claude.Endpoints.Models)
+	v.duration("providers.claude.timeout", p.Claude.Timeout, maxTimeout)
+	v.intRange("providers.claude.max_retries", p.Claude.MaxRetries, 0, maxRetries)
+	v.intRange("providers.claude.max_tokens", p.Claude.MaxTokens, 0, maxClaudeTokens)
+	v.floatRange("providers.claude.temperature", p.Claude.Temperature, 0, 2)
+	v.headerName("providers.claude.request_id_header", p.Claude.RequestIDHeader)
+	v.languages("providers.claude.supported_languages", p.Claude.SupportedLanguages)
+	validateKeyPool(v, "providers.claude.key_pool", p.Claude.KeyPool)
+	if p.Claude.Enabled && p.Claude.DefaultModel == "" {
+		v.addf("providers.claude.default_model", "is required when the provider is enabled")
+	}
+}
+
+// validateKeyPool 校验密钥池配置，错误信息中不输出 Key 本身
+func validateKeyPool(v *validator, path string, k KeyPoolConfig) {
+	for i, key := range k.Keys {
+		if strings.TrimSpace(key) == "" {
+			v.addf(fmt.Sprintf("%s.keys[%d]", path, i), "must not be empty")
+		}
+	}
+	if k.Strategy != "" {
+		v.oneOf(path+".strategy", k.Strategy, "round_robin", "least_used")
+	}
+	v.duration(path+".cooldown", k.Cooldown, 24*time.Hour)
+}
+
+func validateTranslation(v *validator, t TranslationConfig) {
+	v.httpURL("translation.service_url", t.ServiceURL, t.Enabled && len(t.Translators) == 0)
+	v.duration("translation.timeout", t.Timeout, maxTimeout)
+	v.intRange("translation.max_retries", t.MaxRetries, 0, maxRetries)
+	v.floatRange("translation.min_confidence", t.MinConfidence, 0, 1)
+	v.intRange("translation.max_tokens", t.MaxTokens, 0, 32768)
+	v.nonEmptyUnique("translation.fallback_models", t.FallbackModels)
+	v.headerName("translation.request_id_header", t.RequestIDHeader)
+	if t.Enabled && t.DefaultModel == "" && len(t.Translators) == 0 {
+		v.addf("translation.default_model", "is required when translation is enabled")
+	}
+	if t.FallbackEnabled && len(t.FallbackModels) == 0 {
+		v.addf("translation.fallback_models", "must not be empty when translation.fallback_enabled is true")
+	}
+	if containsString(t.FallbackModels, t.DefaultModel) {
+		v.addf("translation.fallback_models", "must not repeat translation.default_model %q", t.DefaultModel)
+	}
+
+	names := make(map[string]bool, len(t.Translators))
+	for i, tr := range t.Translators {
+		path := fmt.Sprintf("translation.translators[%d]", i)
+		if tr.Type != "" {
+			v.oneOf(path+".type", tr.Type, TranslatorOpenAI, TranslatorDictionary)
+		}
+		v.duration(path+".timeout", tr.Timeout, maxTimeout)
+		v.intRange(path+".max_tokens", tr.MaxTokens, 0, 32768)
+		if strings.EqualFold(tr.Type, TranslatorDictionary) {
+			continue
+		}
+		if tr.ServiceURL != "" {
+			v.httpURL(path+".service_url", tr.ServiceURL, false)
+		} else if t.ServiceURL == "" {
+			v.addf(path+".service_url", "is required when translation.service_url is not set")
+		}
+		if tr.Model == "" && t.DefaultModel == "" {
+			v.addf(path+".model", "is required when translation.default_model is not set")
+		}
+	}
+	if len(t.Translators) > 0 {
+		for i, tr := range t.GetTranslators() {
+			if names[tr.Name] {
+				v.addf(fmt.Sprintf("translation.translators[%d].name", i), "duplicate translator name %q", tr.Name)
+			}
+			names[tr.Name] = true
+		}
+	}
+
+	c := t.Cache
+	if c.Backend != "" {
+		v.oneOf("translation.cache.backend", c.Backend, "memory", "redis")
+	}
+	v.intRange("translation.cache.max_entries", c.MaxEntries, 0, 10000000)
+	v.duration("translation.cache.ttl", c.TTL, 365*24*time.Hour)
+	v.duration("translation.cache.redis.timeout", c.Redis.Timeout, time.Minute)
+	v.intRange("translation.cache.redis.db", c.Redis.DB, 0, 15)
+	if c.Enabled && c.GetBackend() == "redis" {
+		if c.Redis.Addr == "" {
+			v.addf("translation.cache.redis.addr", "is required when translation.cache.backend is redis")
+		} else if _, _, err := net.SplitHostPort(c.Redis.Addr); err != nil {
+			v.addf("translation.cache.redis.addr", "must be host:port (got %q)", c.Redis.Addr)
+		}
+		if c.Redis.TLSCAFile != "" && !c.Redis.TLS {
+			v.addf("translation.cache.redis.tls_ca_file", "requires translation.cache.redis.tls to be true")
+		}
+	}
+	v.intRange("translation.glossary.max_entries", t.Glossary.MaxEntries, 0, 100000)
+}
+
+func validateEnhancement(v *validator, config *Config) {
+	e := config.Enhancement
+	if e.DefaultLevel != "" {
+		v.oneOf("enhancement.default_level", e.DefaultLevel, EnhanceOff, EnhanceLight, EnhanceDetailed)
+	}
+	v.httpURL("enhancement.service_url", e.ServiceURL, false)
+	v.duration("enhancement.timeout", e.Timeout, maxTimeout)
+	v.intRange("enhancement.max_tokens", e.MaxTokens, 0, 32768)
+	v.intRange("enhancement.max_input_length", e.MaxInputLength, 0, 10000)
+	v.intRange("enhancement.max_output_length", e.MaxOutputLength, 0, 10000)
+	if !e.Enabled {
+		return
+	}
+	if e.ServiceURL == "" && config.Translation.ServiceURL == "" {
+		v.addf("enhancement.service_url", "is required when translation.service_url is not set")
+	}
+	if e.Model == "" && config.Translation.DefaultModel == "" {
+		v.addf("enhancement.model", "is required when translation.default_model is not set")
+	}
+}
+
+func validateHTTPClient(v *validator, h HTTPClientConfig) {
+	v.duration("http_client.timeout", h.Timeout, maxTimeout)
+	v.duration("http_client.idle_conn_timeout", h.IdleConnTimeout, maxTimeout)
+	v.duration("http_client.dial_timeout", h.DialTimeout, 5*time.Minute)
+	v.duration("http_client.tls_handshake_timeout", h.TLSHandshakeTimeout, 5*time.Minute)
+	v.intRange("http_client.max_idle_conns", h.MaxIdleConns, 0, 10000)
+	v.intRange("http_client.max_idle_conns_per_host", h.MaxIdleConnsPerHost, 0, 10000)
+	if h.MaxIdleConns > 0 && h.MaxIdleConnsPerHost > h.MaxIdleConns {
+		v.addf("http_client.max_idle_conns_per_host", "must not exceed http_client.max_idle_conns (%d > %d)",
+			h.MaxIdleConnsPerHost, h.MaxIdleConns)
+	}
+}
+
+func validateLogging(v *validator, l LoggingConfig) {
+	if l.Level != "" {
+		v.oneOf("logging.level", l.Level, "debug", "info", "warn", "warning", "error")
+	}
+	if l.Format != "" {
+		v.oneOf("logging.format", l.Format, "json", "text")
+	}
+	v.intRange("logging.max_size_mb", l.MaxSizeMB, 0, 10240)
+	v.intRange("logging.max_backups", l.MaxBackups, 0, 1000)
+	v.intRange("logging.max_age_days", l.MaxAgeDays, 0, 3650)
+}
+
+func validateSecurity(v *validator, s SecurityConfig) {
+	for i, origin := range s.AllowedOrigins {
+		path := fmt.Sprintf("security.allowed_origins[%d]", i)
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			v.addf(path, "must be \"*\" or an origin such as \"https://example.com\" (got %q)", origin)
+		}
+	}
+	v.byteSize("security.max_request_size", s.MaxRequestSize, maxRequestSize)
+	v.byteSize("security.download.max_size", s.Download.MaxSize, maxDownloadSize)
+	// 负数表示不跟随重定向
+	v.intRange("security.download.max_redirects", s.Download.MaxRedirects, -1, maxRedirects)
+	if s.TenantHeader != "" {
+		v.headerName("security.tenant_header", s.TenantHeader)
+	}
+
+	owners := make(map[string]string)
+	for i, tk := range s.TenantKeys {
+		path := fmt.Sprintf("security.tenant_keys[%d]", i)
+		if !requestid.IsValid(tk.Tenant) {
+			v.addf(path+".tenant", "must be 1-128 characters of letters, digits and -_.: (got %q)", tk.Tenant)
+		}
+		if len(tk.Keys) == 0 {
+			v.addf(path+".keys", "must not be empty (set keys or keys_file)")
+		}
+		for _, key := range tk.Keys {
+			if len(key) < minTenantKeyLength {
+				v.addf(path+".keys", "each key must be at least %d characters", minTenantKeyLength)
+				break
+			}
+			if owner, ok := owners[key]; ok && owner != tk.Tenant {
+				v.addf(path+".keys", "key is also assigned to tenant %q", owner)
+				break
+			}
+			owners[key] = tk.Tenant
+		}
+	}
+	if s.EnableAPIKeyValidation && len(s.TenantKeys) == 0 {
+		v.addf("security.tenant_keys", "must not be empty when security.enable_api_key_validation is true")
+	}
+}
+
+func validateTracing(v *validator, t TracingConfig, enabled bool) {
+	v.floatRange("tracing.sample_ratio", t.SampleRatio, 0, 1)
+	v.duration("tracing.timeout", t.Timeout, 5*time.Minute)
+	for k := range t.Headers {
+		v.headerName("tracing.headers", k)
+	}
+	if t.Exporter != "" {
+		v.oneOf("tracing.exporter", t.Exporter, "otlp", "stdout", "file")
+	}
+	if !enabled {
+		return
+	}
+	switch strings.ToLower(t.Exporter) {
+	case "", "otlp":
+		v.httpURL("tracing.endpoint", t.Endpoint, true)
+	case "file":
+		if t.FilePath == "" {
+			v.addf("tracing.file_path", "is required for the file exporter")
+		}
+	}
+}
+
+func validateHealth(v *validator, h HealthConfig) {
+	v.duration("health.credential_check_interval", h.CredentialCheckInterval, 24*time.Hour)
+	v.duration("health.credential_check_timeout", h.CredentialCheckTimeout, 5*time.Minute)
+	v.intRange("health.breaker_failure_threshold", h.BreakerFailureThreshold, 0, 1000)
+	v.duration("health.breaker_open_duration", h.BreakerOpenDuration, time.Hour)
+	v.intRange("health.latency_window", h.LatencyWindow, 0, maxLatencyWindow)
+	if h.CredentialCheckInterval > 0 && h.CredentialCheckTimeout > h.CredentialCheckInterval {
+		v.addf("health.credential_check_timeout", "must not exceed health.credential_check_interval (%s > %s)",
+			h.CredentialCheckTimeout, h.CredentialCheckInterval)
+	}
+}
+
+func validateReload(v *validator, r ReloadConfig) {
+	v.duration("reload.interval", r.Interval, time.Hour)
+	if r.Watch && r.Interval > 0 && r.Interval < 100*time.Millisecond {
+		v.addf("reload.interval", "must be at least 100ms (got %s)", r.Interval)
+	}
+}
+
+// minAdminTokenLength 管理令牌的最短长度，避免使用容易猜测的短令牌
+const minAdminTokenLength = 16
+
+// minTenantKeyLength 租户 API Key 的最短长度
+const minTenantKeyLength = 16
+
+func validateAdmin(v *validator, a AdminConfig) {
+	if !a.Enabled {
+		return
+	}
+	if a.Token == "" {
+		v.addf("admin.token", "is required when admin.enabled is true (set admin.token_file or SVGGEN_ADMIN_TOKEN)")
+	} else if len(a.Token) < minAdminTokenLength {
+		v.addf("admin.token", "must be at least %d characters", minAdminTokenLength)
+	}
+}
+
+// minAuditHMACKeyLength 审计日志 HMAC 密钥的最短长度
+const minAuditHMACKeyLength = 32
+
+func validateAudit(v *validator, a AuditConfig) {
+	v.intRange("audit.max_size_mb", a.MaxSizeMB, 0, 10240)
+	if !a.Enabled {
+		return
+	}
+	if a.HMACKey == "" {
+		v.addf("audit.hmac_key", "is required when audit.enabled is true (set audit.hmac_key_file or SVGGEN_AUDIT_HMAC_KEY)")
+	} else if len(a.HMACKey) < minAuditHMACKeyLength {
+		v.addf("audit.hmac_key", "must be at least %d characters", minAuditHMACKeyLength)
+	}
+}
+
+func validateUsage(v *validator, u UsageConfig) {
+	v.intRange("usage.max_memory_entries", u.MaxMemoryEntries, 0, 10000000)
+	if u.Currency != "" && !currencyPattern.MatchString(u.Currency) {
+		v.addf("usage.currency", "must be a three-letter ISO 4217 code such as USD (got %q)", u.Currency)
+	}
+	for _, name := range []string{"svgio", "recraft", "claude"} {
+		path := "usage.pricing." + name
+		price := u.Pricing.GetPrice(name)
+		v.floatRange(path+".input_per_1k_tokens", price.InputPer1KTokens, 0, 1000)
+		v.floatRange(path+".output_per_1k_tokens", price.OutputPer1KTokens, 0, 1000)
+		v.floatRange(path+".per_image", price.PerImage, 0, 1000)
+		v.floatRange(path+".per_vectorize", price.PerVectorize, 0, 1000)
+	}
+}
+
+func validateBudgets(v *validator, b BudgetsConfig, usageEnabled bool) {
+	if b.Period != "" {
+		v.oneOf("budgets.period", b.Period, "daily", "weekly", "monthly")
+	}
+	if b.Timezone != "" {
+		if _, err := time.LoadLocation(b.Timezone); err != nil {
+			v.addf("budgets.timezone", "is not a known IANA time zone (got %q)", b.Timezone)
+		}
+	}
+	validateBudgetLimit(v, "budgets.global", b.Global)
+	validateBudgetLimit(v, "budgets.default_tenant", b.DefaultTenant)
+	tenants := make([]string, 0, len(b.Tenants))
+	for tenant := range b.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		path := "budgets.tenants." + tenant
+		if !tenantPattern.MatchString(tenant) {
+			v.addf(path, "tenant must be 1-128 characters of letters, digits and -_.:")
+		}
+		validateBudgetLimit(v, path, b.Tenants[tenant])
+	}
+	v.httpURL("budgets.alerts.webhook_url", b.Alerts.WebhookURL, false)
+	v.duration("budgets.alerts.timeout", b.Alerts.Timeout, time.Minute)
+	if b.Enabled && !hasBudgetLimit(b) {
+		v.addf("budgets.enabled", "requires at least one soft_limit or hard_limit")
+	}
+	if b.Enabled && !usageEnabled {
+		v.addf("budgets.enabled", "requires usage.enabled, spend is computed from the usage ledger")
+	}
+}
+
+func validateBudgetLimit(v *validator, path string, l BudgetLimit) {
+	v.floatRange(path+".soft_limit", l.SoftLimit, 0, 1e9)
+	v.floatRange(path+".hard_limit", l.HardLimit, 0, 1e9)
+	if l.SoftLimit > 0 && l.HardLimit > 0 && l.SoftLimit > l.HardLimit {
+		v.addf(path+".soft_limit", "must not exceed hard_limit (%g > %g)", l.SoftLimit, l.HardLimit)
+	}
+	if l.OnExceed != "" {
+		v.oneOf(path+".on_exceed", l.OnExceed, "reject", "downgrade")
+	}
+	if l.OnExceed == "downgrade" && l.DowngradeTo == "" {
+		v.addf(path+".downgrade_to", "is required when on_exceed is downgrade")
+	}
+	if l.DowngradeTo != "" {
+		v.oneOf(path+".downgrade_to", l.DowngradeTo, "svgio", "recraft", "claude")
+	}
+}
+
+// hasBudgetLimit 开启预算时至少要配置一个上限
+func hasBudgetLimit(b BudgetsConfig) bool {
+	limits := []BudgetLimit{b.Global, b.DefaultTenant}
+	for _, l := range b.Tenants {
+		limits = append(limits, l)
+	}
+	for _, l := range limits {
+		if l.SoftLimit > 0 || l.HardLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// moderationLevels 审核级别，由低到高
+var moderationLevels = []string{"off", "relaxed", "standard", "strict"}
+
+// piiDetectors 内置个人信息检测器，与 internal/privacy 保持一致
+var piiDetectors = []string{"email", "phone", "cn_id", "credit_card", "ip_address"}
+
+var (
+	privacyActions = []string{"mask", "reject"}
+	piiNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+func validatePrivacy(v *validator, p PrivacyConfig) {
+	if p.Action != "" {
+		v.oneOf("privacy.action", p.Action, privacyActions...)
+	}
+	for i, name := range p.Detectors {
+		v.oneOf(fmt.Sprintf("privacy.detectors[%d]", i), name, piiDetectors...)
+	}
+	for i, pattern := range p.Patterns {
+		path := fmt.Sprintf("privacy.patterns[%d]", i)
+		if !piiNamePattern.MatchString(pattern.Name) {
+			v.addf(path+".name", "must be 1-32 lowercase letters, digits and underscores, starting with a letter")
+		}
+		if pattern.Pattern == "" {
+			v.addf(path+".pattern", "is required")
+		} else if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			v.addf(path+".pattern", "is not a valid regular expression: %v", err)
+		}
+	}
+	tenants := make([]string, 0, len(p.Tenants))
+	for tenant := range p.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		path := "privacy.tenants." + tenant
+		if !tenantPattern.MatchString(tenant) {
+			v.addf(path, "tenant must be 1-128 characters of letters, digits and -_.:")
+		}
+		if action := p.Tenants[tenant].Action; action != "" {
+			v.oneOf(path+".action", action, privacyActions...)
+		}
+	}
+}
+
+func validateModeration(v *validator, m ModerationConfig) {
+	if m.DefaultLevel != "" {
+		v.oneOf("moderation.default_level", m.DefaultLevel, moderationLevels...)
+	}
+	tenants := make([]string, 0, len(m.Tenants))
+	for tenant := range m.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		path := "moderation.tenants." + tenant
+		if !tenantPattern.MatchString(tenant) {
+			v.addf(path, "tenant must be 1-128 characters of letters, digits and -_.:")
+		}
+		v.oneOf(path, m.Tenants[tenant], moderationLevels...)
+	}
+	for i, word := range m.Blocklist {
+		if strings.TrimSpace(word) == "" {
+			v.addf(fmt.Sprintf("moderation.blocklist[%d]", i), "must not be empty")
+		}
+	}
+	for i, rule := range m.Rules {
+		path := fmt.Sprintf("moderation.rules[%d]", i)
+		if rule.Pattern == "" {
+			v.addf(path+".pattern", "is required")
+		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
+			v.addf(path+".pattern", "is not a valid regular expression: %v", err)
+		}
+		if rule.Category == "" {
+			v.addf(path+".category", "is required")
+		}
+		if rule.MinLevel != "" {
+			v.oneOf(path+".min_level", rule.MinLevel, moderationLevels[1:]...)
+		}
+	}
+
+	c := m.Classifier
+	v.httpURL("moderation.classifier.service_url", c.ServiceURL, c.Enabled)
+	v.duration("moderation.classifier.timeout", c.Timeout, 2*time.Minute)
+	if c.MinLevel != "" {
+		v.oneOf("moderation.classifier.min_level", c.MinLevel, moderationLevels[1:]...)
+	}
+	if c.Enabled && c.APIKey == "" {
+		v.addf("moderation.classifier.api_key", "is required when the classifier is enabled (set moderation.classifier.api_key_file or SVGGEN_MODERATION_CLASSIFIER_API_KEY)")
+	}
+}
+
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,128}$`)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = fmt.Sprintf("%q", v)
+	}
+	return out
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+var (
+	unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type config\.(\w+)$`)
+	badDurationPattern  = regexp.MustCompile("^line (\\d+): cannot unmarshal !!\\w+ `(.*)` into time\\.Duration$")
+	badValuePattern     = regexp.MustCompile("^line (\\d+): cannot unmarshal !!(\\w+) `(.*)` into (\\S+)$")
+)
+
+// describeYAMLError 将 yaml.v3 的类型错误改写为带文件名、更易理解的提示
+func describeYAMLError(path string, err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	problems := make([]string, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+			problems = append(problems, fmt.Sprintf("%s:%s: unknown field %q in %s", path, m[1], m[2], m[3]))
+		} else if m := badDurationPattern.FindStringSubmatch(msg); m != nil {
+			problems = append(problems, fmt.Sprintf("%s:%s: invalid duration %q (use values such as 500ms, 30s, 2m, 1h)", path, m[1], m[2]))
+		} else if m := badValuePattern.FindStringSubmatch(msg); m != nil {
+			problems = append(problems, fmt.Sprintf("%s:%s: expected %s, got %s %q", path, m[1], m[4], m[2], m[3]))
+		} else {
+			problems = append(problems, path+": "+msg)
+		}
+	}
+	return &ValidationError{Problems: problems}
+}
@@ -0,0 +1,401 @@
+// Package glossary 按租户维护的翻译术语表。每个租户的术语表保存在一个追加写入的 JSONL 文件中，
+// 每行是一个完整版本，修改和回滚都追加新版本，历史版本不会被改写
+package glossary
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"svg-generator/internal/tenant"
+)
+
+var (
+	// ErrInvalid 术语表内容不合法
+	ErrInvalid = errors.New("invalid glossary")
+	// ErrVersionNotFound 指定的版本不存在
+	ErrVersionNotFound = errors.New("glossary version not found")
+)
+
+// Entry 一条术语：Source 固定译为 Target，DoNotTranslate 时保留原文不翻译
+type Entry struct {
+	Source         string `json:"source"`
+	Target         string `json:"target,omitempty"`
+	DoNotTranslate bool   `json:"do_not_translate,omitempty"`
+}
+
+// Version 术语表的一个版本，版本号从 1 开始递增
+type Version struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor,omitempty"`
+	// RollbackOf 由回滚生成时为被恢复的版本号
+	RollbackOf int     `json:"rollback_of,omitempty"`
+	Entries    []Entry `json:"entries"`
+}
+
+// Summary 版本列表中的一项，不含条目
+type Summary struct {
+	Version    int       `json:"version"`
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor,omitempty"`
+	RollbackOf int       `json:"rollback_of,omitempty"`
+	Entries    int       `json:"entries"`
+}
+
+// Store 术语表存储，启动时读入全部版本，写入时追加到租户文件
+type Store struct {
+	mu       sync.RWMutex
+	dir      string
+	versions map[string][]Version
+	compiled map[string]*Glossary
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore *Store
+)
+
+// Open 读取 dir 下全部租户的术语表，目录不存在时视为没有术语表
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, versions: make(map[string][]Version), compiled: make(map[string]*Glossary)}
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		if !tenant.IsValid(id) {
+			continue
+		}
+		versions, err := readVersions(file)
+		if err != nil {
+			return nil, err
+		}
+		s.versions[id] = versions
+	}
+	return s, nil
+}
+
+// Init 打开术语表存储并设为全局实例
+func Init(dir string) (*Store, error) {
+	s, err := Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defaultMu.Lock()
+	defaultStore = s
+	defaultMu.Unlock()
+	return s, nil
+}
+
+// Default 返回全局术语表存储，未初始化时返回 nil
+func Default() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+func readVersions(path string) ([]Version, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var versions []Version
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var v Version
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		versions = append(versions, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return versions, nil
+}
+
+// Current 返回租户的最新版本，没有术语表时返回 nil
+func (s *Store) Current(id string) *Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[id]
+	if len(versions) == 0 {
+		return nil
+	}
+	v := versions[len(versions)-1]
+	return &v
+}
+
+// TenantSummary 租户术语表的最新版本摘要
+type TenantSummary struct {
+	Tenant string `json:"tenant"`
+	Summary
+}
+
+// Tenants 返回所有有术语表的租户及其最新版本，按租户排序
+func (s *Store) Tenants() []TenantSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]TenantSummary, 0, len(s.versions))
+	for id, versions := range s.versions {
+		if len(versions) == 0 {
+			continue
+		}
+		v := versions[len(versions)-1]
+		list = append(list, TenantSummary{Tenant: id, Summary: summarize(v)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tenant < list[j].Tenant })
+	return list
+}
+
+func summarize(v Version) Summary {
+	return Summary{Version: v.Version, Time: v.Time, Actor: v.Actor, RollbackOf: v.RollbackOf, Entries: len(v.Entries)}
+}
+
+// Versions 返回租户全部版本的摘要，按版本号升序
+func (s *Store) Versions(id string) []Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	summaries := make([]Summary, 0, len(s.versions[id]))
+	for _, v := range s.versions[id] {
+		summaries = append(summaries, summarize(v))
+	}
+	return summaries
+}
+
+// Version 返回租户的指定版本
+func (s *Store) Version(id string, version int) (*Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, v := range s.versions[id] {
+		if v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrVersionNotFound, id, version)
+}
+
+// Put 校验并保存新版本，maxEntries 为条目数上限
+func (s *Store) Put(id string, entries []Entry, actor string, maxEntries int) (*Version, error) {
+	entries, err := Normalize(entries, maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return s.append(id, Version{Actor: actor, Entries: entries})
+}
+
+// Rollback 以指定版本的条目生成新版本
+func (s *Store) Rollback(id string, version int, actor string) (*Version, error) {
+	old, err := s.Version(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.append(id, Version{Actor: actor, RollbackOf: old.Version, Entries: old.Entries})
+}
+
+func (s *Store) append(id string, v Version) (*Version, error) {
+	if !tenant.IsValid(id) || id == "." || id == ".." {
+		return nil, fmt.Errorf("%w: invalid tenant %q", ErrInvalid, id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v.Version = 1
+	if n := len(s.versions[id]); n > 0 {
+		v.Version = s.versions[id][n-1].Version + 1
+	}
+	v.Time = time.Now().UTC()
+	line, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create glossary dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, id+".jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open glossary file: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write glossary file: %w", err)
+	}
+
+	s.versions[id] = append(s.versions[id], v)
+	s.compiled = make(map[string]*Glossary)
+	return &v, nil
+}
+
+// Normalize 去除首尾空白并校验条目：原文非空且不重复（不区分大小写），
+// 非 DoNotTranslate 条目必须有译文，条目数不超过 maxEntries
+func Normalize(entries []Entry, maxEntries int) ([]Entry, error) {
+	if maxEntries > 0 && len(entries) > maxEntries {
+		return nil, fmt.Errorf("%w: %d entries exceeds the limit of %d", ErrInvalid, len(entries), maxEntries)
+	}
+	seen := make(map[string]int, len(entries))
+	out := make([]Entry, 0, len(entries))
+	for i, e := range entries {
+		e.Source = strings.TrimSpace(e.Source)
+		e.Target = strings.TrimSpace(e.Target)
+		switch {
+		case e.Source == "":
+			return nil, fmt.Errorf("%w: entries[%d].source is required", ErrInvalid, i)
+		case e.DoNotTranslate && e.Target != "":
+			return nil, fmt.Errorf("%w: entries[%d] sets both target and do_not_translate", ErrInvalid, i)
+		case !e.DoNotTranslate && e.Target == "":
+			return nil, fmt.Errorf("%w: entries[%d].target is required unless do_not_translate is set", ErrInvalid, i)
+		}
+		key := strings.ToLower(e.Source)
+		if j, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%w: entries[%d].source duplicates entries[%d] (%q)", ErrInvalid, i, j, e.Source)
+		}
+		seen[key] = i
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Glossary 租户生效的术语表：default 租户的条目加上租户自己的条目，同一原文以租户的为准
+type Glossary struct {
+	// Version 生效的版本，如 "default@2,acme@5"，没有条目时为空
+	Version string
+	// entries 按原文长度降序，保证最长匹配
+	entries []Entry
+}
+
+// For 返回租户生效的术语表，没有任何条目时返回 nil
+func (s *Store) For(id string) *Glossary {
+	s.mu.RLock()
+	if len(s.versions[id]) == 0 {
+		// 没有自己术语表的租户共用 default 的编译结果
+		id = tenant.Default
+	}
+	g, ok := s.compiled[id]
+	s.mu.RUnlock()
+	if ok {
+		return g
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.compiled[id]; ok {
+		return g
+	}
+	byKey := make(map[string]Entry)
+	var versions []string
+	owners := []string{tenant.Default}
+	if id != tenant.Default {
+		owners = append(owners, id)
+	}
+	for _, owner := range owners {
+		list := s.versions[owner]
+		if len(list) == 0 {
+			continue
+		}
+		current := list[len(list)-1]
+		if len(current.Entries) == 0 {
+			continue
+		}
+		versions = append(versions, fmt.Sprintf("%s@%d", owner, current.Version))
+		for _, e := range current.Entries {
+			byKey[strings.ToLower(e.Source)] = e
+		}
+	}
+	if len(byKey) > 0 {
+		g = &Glossary{Version: strings.Join(versions, ",")}
+		for _, e := range byKey {
+			g.entries = append(g.entries, e)
+		}
+		sort.Slice(g.entries, func(i, j int) bool {
+			li, lj := utf8.RuneCountInString(g.entries[i].Source), utf8.RuneCountInString(g.entries[j].Source)
+			if li != lj {
+				return li > lj
+			}
+			return g.entries[i].Source < g.entries[j].Source
+		})
+	}
+	s.compiled[id] = g
+	return g
+}
+
+// Apply 从左到右按最长匹配替换术语（不区分大小写），DoNotTranslate 条目保留原文。
+// 以字母或数字开头、结尾的原文只在词边界处匹配，避免替换单词的一部分。
+// 返回替换后的文本和命中的固定术语（译文或保留的原文），供翻译提示词约束
+func (g *Glossary) Apply(text string) (string, []string) {
+	if g == nil || len(g.entries) == 0 {
+		return text, nil
+	}
+	var b strings.Builder
+	var terms []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(text); {
+		matched := false
+		for _, e := range g.entries {
+			n := len(e.Source)
+			if i+n > len(text) || !strings.EqualFold(text[i:i+n], e.Source) || !atBoundary(text, i, i+n) {
+				continue
+			}
+			term := e.Target
+			if e.DoNotTranslate {
+				term = text[i : i+n]
+			}
+			b.WriteString(term)
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+			i += n
+			matched = true
+			break
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			b.WriteString(text[i : i+size])
+			i += size
+		}
+	}
+	return b.String(), terms
+}
+
+// atBoundary 判断 text[start:end] 两端是否在词边界上：只有匹配内容和相邻字符都是字母或数字时才不是边界。
+// 中文等不以空格分词的文字不受限制
+func atBoundary(text string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:end])
+	last, _ := utf8.DecodeLastRuneInString(text[start:end])
+	if start > 0 && isWordRune(first) {
+		if prev, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(prev) {
+			return false
+		}
+	}
+	if end < len(text) && isWordRune(last) {
+		if next, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(next) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) ||
+		unicode.Is(unicode.Latin, r) || unicode.Is(unicode.Cyrillic, r) || unicode.Is(unicode.Greek, r)
+}
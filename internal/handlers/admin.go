@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"svg-generator/internal/audit"
+	"svg-generator/internal/budget"
+	"svg-generator/internal/cache"
+	"svg-generator/internal/config"
+	"svg-generator/internal/jobs"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/requestid"
+	"svg-generator/internal/service"
+	"svg-generator/internal/types"
+	"svg-generator/pkg/utils"
+)
+
+// providerSettings 管理接口中 Provider 可修改的字段，对应 providers.<name>.<field> 配置项
+var providerSettings = []string{"enabled", "timeout", "max_retries", "default_model", "max_tokens", "temperature"}
+
+// AdminAuth 校验管理令牌；admin.enabled 为 false 时管理接口表现为不存在
+func AdminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminCfg := config.Get().Admin
+		if !adminCfg.Enabled {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		if !isAdminRequest(r) {
+			logging.Component("admin").WarnContext(r.Context(), "admin request rejected", "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+			audit.Log(audit.Record{
+				Event:     audit.EventAdminDenied,
+				RequestID: requestid.FromContext(r.Context()),
+				Actor:     auditActor(r, false),
+				Action:    r.Method + " " + r.URL.Path,
+				Status:    http.StatusUnauthorized,
+				Outcome:   outcomeForStatus(http.StatusUnauthorized),
+			})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid admin token", nil)
+			return
+		}
+		auditAdmin(next)(w, r)
+	}
+}
+
+// isAdminRequest 判断请求是否携带有效的管理令牌，管理接口未开启时始终返回 false
+func isAdminRequest(r *http.Request) bool {
+	return utils.IsAdminToken(utils.BearerToken(r))
+}
+
+// adminProvider 管理接口中的 Provider 视图：当前生效的配置加上健康统计
+type adminProvider struct {
+	types.ProviderHealth
+	DefaultModel string            `json:"default_model,omitempty"`
+	Timeout      string            `json:"timeout"`
+	MaxRetries   int               `json:"max_retries"`
+	Temperature  *float64          `json:"temperature,omitempty"`
+	MaxTokens    int               `json:"max_tokens,omitempty"`
+	ActiveJobs   int               `json:"active_jobs"`
+	Overrides    map[string]string `json:"overrides,omitempty"`
+}
+
+// AdminProvidersHandler GET /admin/providers 列出 Provider 的配置、运行时覆盖项和实时统计
+func AdminProvidersHandler(serviceManager *service.ServiceManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is allowed", nil)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+			"providers": adminProviders(serviceManager),
+			"time":      time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// AdminProviderHandler 修改单个 Provider：
+// PATCH /admin/providers/{name} 修改 enabled、default_model、temperature、timeout 等字段，值为 null 时恢复配置文件中的值；
+// POST /admin/providers/{name}/enable 和 /disable 快捷启用、禁用
+func AdminProviderHandler(serviceManager *service.ServiceManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !isKnownProvider(name) {
+			utils.WriteError(w, http.StatusNotFound, "unknown_provider", "unknown provider: "+name, nil)
+			return
+		}
+
+		var changes map[string]*string
+		switch action := r.PathValue("action"); {
+		case action == "" && r.Method == http.MethodPatch:
+			values, err := decodeSettings(w, r)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			changes = make(map[string]*string, len(values))
+			for field, value := range values {
+				if !containsField(providerSettings, field) {
+					utils.WriteError(w, http.StatusBadRequest, "invalid_setting", "setting cannot be changed: "+field,
+						"allowed: "+strings.Join(providerSettings, ", "))
+					return
+				}
+				changes["providers."+name+"."+field] = value
+			}
+		case (action == "enable" || action == "disable") && r.Method == http.MethodPost:
+			enabled := fmt.Sprint(action == "enable")
+			changes = map[string]*string{"providers." + name + ".enabled": &enabled}
+		case action == "" || action == "enable" || action == "disable":
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use PATCH /admin/providers/{name} or POST /admin/providers/{name}/enable|disable", nil)
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if !applyOverrides(w, r, changes) {
+			return
+		}
+		for _, p := range adminProviders(serviceManager) {
+			if string(p.Provider) == name {
+				writeAdminJSON(w, http.StatusOK, p)
+				return
+			}
+		}
+	}
+}
+
+// AdminProviderKeysHandler 管理 Provider 的密钥池：
+// GET /admin/providers/{name}/keys 列出各 Key 的使用情况（只返回指纹和末尾几位）；
+// POST /admin/providers/{name}/keys 以 {"key": "..."} 添加 Key；DELETE /admin/providers/{name}/keys/{id} 移除 Key；
+// POST /admin/providers/{name}/keys/{id}/release 提前结束 Key 的隔离期
+func AdminProviderKeysHandler(serviceManager *service.ServiceManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !isKnownProvider(name) {
+			utils.WriteError(w, http.StatusNotFound, "unknown_provider", "unknown provider: "+name, nil)
+			return
+		}
+		provider := types.Provider(name)
+		logger := logging.Component("admin")
+		id, action := r.PathValue("id"), r.PathValue("action")
+
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+		case id == "" && r.Method == http.MethodPost:
+			var req struct {
+				Key string `json:"key"`
+			}
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			if req.Key = strings.TrimSpace(req.Key); req.Key == "" {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "key is required", nil)
+				return
+			}
+			keyID, err := serviceManager.AddProviderKey(provider, req.Key)
+			if errors.Is(err, service.ErrKeyExists) {
+				utils.WriteError(w, http.StatusConflict, "key_exists", "api key already in pool", map[string]string{"id": keyID})
+				return
+			}
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "add_key_failed", "failed to add api key", err.Error())
+				return
+			}
+			logger.InfoContext(r.Context(), "api key added", "remote_addr", r.RemoteAddr, "provider", name, "key_id", keyID)
+			audit.Annotate(r.Context(), "key_id", keyID)
+		case id != "" && action == "" && r.Method == http.MethodDelete:
+			if !serviceManager.RemoveProviderKey(provider, id) {
+				utils.WriteError(w, http.StatusNotFound, "key_not_found", "api key not found: "+id, nil)
+				return
+			}
+			logger.InfoContext(r.Context(), "api key removed", "remote_addr", r.RemoteAddr, "provider", name, "key_id", id)
+		case id != "" && action == "release" && r.Method == http.MethodPost:
+			if !serviceManager.ReleaseProviderKey(provider, id) {
+				utils.WriteError(w, http.StatusNotFound, "key_not_found", "api key not found: "+id, nil)
+				return
+			}
+			logger.InfoContext(r.Context(), "api key released from quarantine", "remote_addr", r.RemoteAddr, "provider", name, "key_id", id)
+		case action != "" && action != "release":
+			http.NotFound(w, r)
+			return
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed",
+				"use GET|POST /admin/providers/{name}/keys, DELETE /admin/providers/{name}/keys/{id} or POST /admin/providers/{name}/keys/{id}/release", nil)
+			return
+		}
+
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+			"provider": name,
+			"keys":     serviceManager.ProviderKeys(provider),
+		})
+	}
+}
+
+// AdminSettingsHandler 运行时配置覆盖项：
+// GET 返回当前覆盖项和可修改的配置项；PATCH 以 {"<yaml 路径>": 值} 修改，值为 null 时删除覆盖项；DELETE 清除全部覆盖项
+func AdminSettingsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPatch:
+			changes, err := decodeSettings(w, r)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			if !applyOverrides(w, r, changes) {
+				return
+			}
+		case http.MethodDelete:
+			if _, err := config.ResetOverrides(); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "reset_failed", "failed to reset overrides", err.Error())
+				return
+			}
+			logging.Component("admin").InfoContext(r.Context(), "runtime overrides cleared", "remote_addr", r.RemoteAddr)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET, PATCH and DELETE are allowed", nil)
+			return
+		}
+
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+			"overrides":   config.Overrides(),
+			"overridable": config.OverridablePaths(),
+			"persisted":   config.Get().Admin.OverridesFile != "",
+		})
+	}
+}
+
+// AdminFlagsHandler 功能开关：GET 返回当前值；PATCH 以 {"enable_caching": true} 修改，值为 null 时恢复配置文件中的值
+func AdminFlagsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPatch:
+			values, err := decodeSettings(w, r)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			changes := make(map[string]*string, len(values))
+			for flag, value := range values {
+				changes["features."+flag] = value
+			}
+			if !applyOverrides(w, r, changes) {
+				return
+			}
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET and PATCH are allowed", nil)
+			return
+		}
+		features := config.Get().Features
+		writeAdminJSON(w, http.StatusOK, map[string]bool{
+			"enable_cors":          features.EnableCORS,
+			"enable_metrics":       features.EnableMetrics,
+			"enable_tracing":       features.EnableTracing,
+			"enable_rate_limiting": features.EnableRateLimiting,
+			"enable_caching":       features.EnableCaching,
+		})
+	}
+}
+
+// AdminCachesHandler GET /admin/caches 列出已登记的缓存；POST /admin/caches/flush 清空缓存，
+// 可选请求体 {"names": [...]} 指定缓存，默认清空全部
+func AdminCachesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/admin/caches":
+			writeAdminJSON(w, http.StatusOK, map[string]interface{}{"caches": cache.Names()})
+		case r.Method == http.MethodPost && r.URL.Path == "/admin/caches/flush":
+			var req struct {
+				Names []string `json:"names"`
+			}
+			if r.ContentLength != 0 {
+				if err := decodeJSONBody(w, r, &req); err != nil {
+					utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+					return
+				}
+			}
+			flushed := cache.Flush(req.Names...)
+			audit.Annotate(r.Context(), "flushed", flushed)
+			logging.Component("admin").InfoContext(r.Context(), "caches flushed", "remote_addr", r.RemoteAddr, "flushed", flushed)
+			writeAdminJSON(w, http.StatusOK, map[string]interface{}{"flushed": flushed})
+		case r.URL.Path == "/admin/caches" || r.URL.Path == "/admin/caches/flush":
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use GET /admin/caches or POST /admin/caches/flush", nil)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// AdminJobsHandler GET /admin/jobs 列出进行中的生成任务；DELETE /admin/jobs/{id} 取消任务
+func AdminJobsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+			writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+				"jobs": jobs.List(),
+				"time": time.Now().Format(time.RFC3339),
+			})
+		case id != "" && r.Method == http.MethodDelete:
+			if !jobs.Cancel(id) {
+				utils.WriteError(w, http.StatusNotFound, "job_not_found", "job not found or already finished: "+id, nil)
+				return
+			}
+			logging.Component("admin").InfoContext(r.Context(), "job cancelled", "remote_addr", r.RemoteAddr, "job_id", id)
+			writeAdminJSON(w, http.StatusOK, map[string]string{"id": id, "status": "cancelled"})
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use GET /admin/jobs or DELETE /admin/jobs/{id}", nil)
+		}
+	}
+}
+
+// AdminBudgetsHandler GET /admin/budgets 当期支出与预算上限
+func AdminBudgetsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is allowed", nil)
+			return
+		}
+		tracker := budget.Default()
+		if tracker == nil {
+			utils.WriteError(w, http.StatusNotFound, "budgets_disabled", "budget tracking is not initialized", nil)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, tracker.Status())
+	}
+}
+
+// adminProviders 合并健康统计、当前配置、覆盖项和进行中的任务数
+func adminProviders(serviceManager *service.ServiceManager) []adminProvider {
+	cfg := config.Get()
+	overrides := config.Overrides()
+	active := make(map[string]int)
+	for _, job := range jobs.List() {
+		active[job.Provider]++
+	}
+
+	health := serviceManager.ProviderHealth()
+	out := make([]adminProvider, 0, len(health))
+	for _, h := range health {
+		p := adminProvider{ProviderHealth: h, ActiveJobs: active[string(h.Provider)]}
+		switch h.Provider {
+		case types.ProviderSVGIO:
+			p.Timeout, p.MaxRetries = cfg.Providers.SVGIO.Timeout.String(), cfg.Providers.SVGIO.MaxRetries
+		case types.ProviderRecraft:
+			p.Timeout, p.MaxRetries = cfg.Providers.Recraft.Timeout.String(), cfg.Providers.Recraft.MaxRetries
+			p.DefaultModel = cfg.Providers.Recraft.DefaultModel
+		case types.ProviderClaude:
+			claude := cfg.Providers.Claude
+			p.Timeout, p.MaxRetries = claude.Timeout.String(), claude.MaxRetries
+			p.DefaultModel, p.MaxTokens, p.Temperature = claude.DefaultModel, claude.MaxTokens, &claude.Temperature
+		}
+
+		prefix := "providers." + string(h.Provider) + "."
+		for path, value := range overrides {
+			if field, ok := strings.CutPrefix(path, prefix); ok {
+				if p.Overrides == nil {
+					p.Overrides = make(map[string]string)
+				}
+				p.Overrides[field] = value
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// applyOverrides 应用覆盖项并记录操作，失败时写入错误响应并返回 false
+func applyOverrides(w http.ResponseWriter, r *http.Request, changes map[string]*string) bool {
+	if len(changes) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "no settings given", nil)
+		return false
+	}
+	logged := make(map[string]string, len(changes))
+	for path, value := range changes {
+		if value == nil {
+			logged[path] = "<reset>"
+		} else {
+			logged[path] = *value
+		}
+	}
+	audit.Annotate(r.Context(), "changes", logged)
+
+	if _, err := config.SetOverrides(changes); err != nil {
+		logging.Component("admin").WarnContext(r.Context(), "runtime override rejected", "remote_addr", r.RemoteAddr, "error", err)
+		audit.Annotate(r.Context(), "error", err.Error())
+		utils.WriteError(w, http.StatusBadRequest, "invalid_setting", "setting rejected", err.Error())
+		return false
+	}
+
+	logging.Component("admin").InfoContext(r.Context(), "runtime configuration changed", "remote_addr", r.RemoteAddr, "changes", logged)
+	return true
+}
+
+// decodeSettings 解析 {"key": 值} 形式的请求体；字符串、数字和布尔值转为配置值，null 表示恢复默认
+func decodeSettings(w http.ResponseWriter, r *http.Request) (map[string]*string, error) {
+	var raw map[string]json.RawMessage
+	if err := decodeJSONBody(w, r, &raw); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]*string, len(raw))
+	for key, msg := range raw {
+		msg = bytes.TrimSpace(msg)
+		switch {
+		case bytes.Equal(msg, []byte("null")):
+			values[key] = nil
+		case len(msg) > 0 && msg[0] == '"':
+			var s string
+			if err := json.Unmarshal(msg, &s); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			values[key] = &s
+		case len(msg) > 0 && (msg[0] == '{' || msg[0] == '['):
+			return nil, fmt.Errorf("%s: expected a string, number, boolean or null", key)
+		default:
+			s := string(msg)
+			values[key] = &s
+		}
+	}
+	return values, nil
+}
+
+func isKnownProvider(name string) bool {
+	switch types.Provider(name) {
+	case types.ProviderSVGIO, types.ProviderRecraft, types.ProviderClaude:
+		return true
+	}
+	return false
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAdminJSON 写入管理接口响应
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"svg-generator/internal/audit"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/requestid"
+	"svg-generator/internal/tenant"
+	"svg-generator/internal/types"
+	"svg-generator/pkg/utils"
+)
+
+// auditGeneration 记录一次生成请求：调用方、提示词、Provider 和结果
+func auditGeneration(r *http.Request, req types.GenerateRequest, prompt, outcome string, details map[string]interface{}) {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	if req.Style != "" {
+		details["style"] = req.Style
+	}
+	if req.NumImages > 0 {
+		details["n"] = req.NumImages
+	}
+	if len(details) == 0 {
+		details = nil
+	}
+	audit.Log(audit.Record{
+		Event:     audit.EventGeneration,
+		RequestID: requestid.FromContext(r.Context()),
+		Actor:     auditActor(r, false),
+		Action:    r.Method + " " + r.URL.Path,
+		Provider:  string(req.Provider),
+		Model:     req.Model,
+		Prompt:    prompt,
+		Outcome:   outcome,
+		Details:   details,
+	})
+}
+
+// auditActor 从请求中提取操作者信息；凭据只记录指纹。API 调用方的凭据与 WithTenant 认证时使用的相同，
+// 管理请求记录通过 AdminAuth 校验的 Bearer 令牌
+func auditActor(r *http.Request, admin bool) audit.Actor {
+	actor := audit.Actor{
+		Tenant:       tenant.FromContext(r.Context()),
+		Admin:        admin,
+		ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		UserAgent:    r.UserAgent(),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		actor.IP = host
+	} else {
+		actor.IP = r.RemoteAddr
+	}
+	credential := utils.RequestCredential(r)
+	if admin {
+		credential = utils.BearerToken(r)
+	}
+	if credential != "" {
+		actor.APIKey = audit.Fingerprint(credential)
+	}
+	return actor
+}
+
+// auditStatusWriter 记录管理接口的响应状态码
+type auditStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditStatusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditStatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// auditAdmin 记录修改类管理请求（非 GET/HEAD）的操作、结果和处理器通过 audit.Annotate 附加的详情
+func auditAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || audit.Default() == nil {
+			next(w, r)
+			return
+		}
+		ctx, details := audit.WithDetails(r.Context())
+		rec := &auditStatusWriter{ResponseWriter: w}
+		next(rec, r.WithContext(ctx))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		audit.Log(audit.Record{
+			Event:     audit.EventAdmin,
+			RequestID: requestid.FromContext(r.Context()),
+			Actor:     auditActor(r, true),
+			Action:    r.Method + " " + r.URL.Path,
+			Status:    rec.status,
+			Outcome:   outcomeForStatus(rec.status),
+			Details:   details.Map(),
+		})
+	}
+}
+
+// outcomeForStatus 将状态码转换为审计记录的结果
+func outcomeForStatus(status int) string {
+	if status < 400 {
+		return "success"
+	}
+	return "rejected_" + strconv.Itoa(status)
+}
+
+// AdminAuditHandler GET /admin/audit 查询审计记录，GET /admin/audit/verify 校验哈希链
+func AdminAuditHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is allowed", nil)
+			return
+		}
+		logger := audit.Default()
+		if logger == nil {
+			utils.WriteError(w, http.StatusNotFound, "audit_disabled", "audit log is disabled", nil)
+			return
+		}
+
+		if r.URL.Path == "/admin/audit/verify" {
+			result, err := logger.Verify()
+			if err != nil {
+				logging.Component("audit").ErrorContext(r.Context(), "audit verification failed", "error", err)
+				utils.WriteError(w, http.StatusInternalServerError, "audit_unavailable", "failed to read audit log", nil)
+				return
+			}
+			if !result.Valid {
+				logging.Component("audit").WarnContext(r.Context(), "audit log chain is broken", "error", result.Error)
+			}
+			writeAdminJSON(w, http.StatusOK, result)
+			return
+		}
+
+		q := r.URL.Query()
+		filter := audit.Filter{
+			Event:     q.Get("event"),
+			Tenant:    q.Get("tenant"),
+			RequestID: q.Get("request_id"),
+			Limit:     defaultUsageLimit,
+		}
+		var err error
+		if raw := q.Get("from"); raw != "" {
+			if filter.From, err = parseUsageTime(raw, time.Time{}, false); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid from", err.Error())
+				return
+			}
+		}
+		if raw := q.Get("to"); raw != "" {
+			if filter.To, err = parseUsageTime(raw, time.Time{}, true); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid to", err.Error())
+				return
+			}
+		}
+		if raw := q.Get("limit"); raw != "" {
+			filter.Limit, err = strconv.Atoi(raw)
+			if err != nil || filter.Limit < 1 || filter.Limit > maxUsageLimit {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid limit",
+					"must be an integer between 1 and "+strconv.Itoa(maxUsageLimit))
+				return
+			}
+		}
+
+		records, truncated, err := logger.Query(filter)
+		if err != nil {
+			logging.Component("audit").ErrorContext(r.Context(), "audit query failed", "error", err)
+			utils.WriteError(w, http.StatusInternalServerError, "audit_unavailable", "failed to read audit log", nil)
+			return
+		}
+		if records == nil {
+			records = []audit.Record{}
+		}
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+			"records":   records,
+			"truncated": truncated,
+		})
+	}
+}
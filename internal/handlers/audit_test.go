@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"svg-generator/internal/audit"
+)
+
+func TestAuditActorCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		admin   bool
+		want    string
+	}{
+		{"x-api-key", map[string]string{"X-API-Key": "tenant-key"}, false, audit.Fingerprint("tenant-key")},
+		{"bearer", map[string]string{"Authorization": "Bearer tenant-key"}, false, audit.Fingerprint("tenant-key")},
+		{"x-api-key wins", map[string]string{"X-API-Key": "tenant-key", "Authorization": "Bearer other"}, false, audit.Fingerprint("tenant-key")},
+		{"no credential", nil, false, ""},
+		{"basic auth is ignored", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, false, ""},
+		{"admin uses bearer", map[string]string{"X-API-Key": "tenant-key", "Authorization": "Bearer admin-token"}, true, audit.Fingerprint("admin-token")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/images/svgio", nil)
+			r.RemoteAddr = "192.0.2.10:51234"
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			actor := auditActor(r, tt.admin)
+			if actor.APIKey != tt.want {
+				t.Errorf("APIKey = %q, want %q", actor.APIKey, tt.want)
+			}
+			if actor.IP != "192.0.2.10" || actor.Admin != tt.admin {
+				t.Errorf("actor = %+v", actor)
+			}
+		})
+	}
+}
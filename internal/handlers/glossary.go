@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"svg-generator/internal/audit"
+	"svg-generator/internal/config"
+	"svg-generator/internal/glossary"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/tenant"
+	"svg-generator/pkg/utils"
+)
+
+// AdminGlossariesHandler 租户术语表管理：
+// GET /admin/glossaries 列出所有租户的最新版本；
+// GET|PUT /admin/glossaries/{tenant} 查看或替换术语表（每次 PUT 生成新版本）；
+// GET /admin/glossaries/{tenant}/versions[/{version}] 查看历史版本；
+// POST /admin/glossaries/{tenant}/rollback 以 {"version": n} 的条目生成新版本
+func AdminGlossariesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := glossary.Default()
+		if store == nil {
+			utils.WriteError(w, http.StatusNotFound, "glossary_disabled", "glossary store is not initialized", nil)
+			return
+		}
+		id, action, version := r.PathValue("tenant"), r.PathValue("action"), r.PathValue("version")
+		if id != "" && !tenant.IsValid(id) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_tenant", "invalid tenant identifier: "+id, nil)
+			return
+		}
+		logger := logging.Component("admin")
+
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+			writeAdminJSON(w, http.StatusOK, map[string]interface{}{"glossaries": store.Tenants()})
+		case id != "" && action == "" && r.Method == http.MethodGet:
+			current := store.Current(id)
+			if current == nil {
+				utils.WriteError(w, http.StatusNotFound, "glossary_not_found", "no glossary for tenant: "+id, nil)
+				return
+			}
+			writeGlossaryVersion(w, id, current)
+		case id != "" && action == "" && r.Method == http.MethodPut:
+			var req struct {
+				Entries []glossary.Entry `json:"entries"`
+			}
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			saved, err := store.Put(id, req.Entries, auditActor(r, true).IP, config.Get().Translation.Glossary.GetMaxEntries())
+			if !writeGlossaryError(w, r, err) {
+				return
+			}
+			audit.Annotate(r.Context(), "glossary_version", saved.Version)
+			logger.InfoContext(r.Context(), "glossary updated", "remote_addr", r.RemoteAddr, "glossary_tenant", id,
+				"version", saved.Version, "entries", len(saved.Entries))
+			writeGlossaryVersion(w, id, saved)
+		case action == "versions" && version == "" && r.Method == http.MethodGet:
+			writeAdminJSON(w, http.StatusOK, map[string]interface{}{"tenant": id, "versions": store.Versions(id)})
+		case action == "versions" && r.Method == http.MethodGet:
+			n, err := strconv.Atoi(version)
+			if err != nil || n < 1 {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "version must be a positive integer", nil)
+				return
+			}
+			v, err := store.Version(id, n)
+			if !writeGlossaryError(w, r, err) {
+				return
+			}
+			writeGlossaryVersion(w, id, v)
+		case action == "rollback" && r.Method == http.MethodPost:
+			var req struct {
+				Version int `json:"version"`
+			}
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+				return
+			}
+			saved, err := store.Rollback(id, req.Version, auditActor(r, true).IP)
+			if !writeGlossaryError(w, r, err) {
+				return
+			}
+			audit.Annotate(r.Context(), "glossary_version", saved.Version)
+			audit.Annotate(r.Context(), "rollback_of", saved.RollbackOf)
+			logger.InfoContext(r.Context(), "glossary rolled back", "remote_addr", r.RemoteAddr, "glossary_tenant", id,
+				"version", saved.Version, "rollback_of", saved.RollbackOf)
+			writeGlossaryVersion(w, id, saved)
+		case action != "" && action != "versions" && action != "rollback":
+			http.NotFound(w, r)
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed",
+				"use GET /admin/glossaries, GET|PUT /admin/glossaries/{tenant}, GET /admin/glossaries/{tenant}/versions[/{version}] or POST /admin/glossaries/{tenant}/rollback", nil)
+		}
+	}
+}
+
+func writeGlossaryVersion(w http.ResponseWriter, id string, v *glossary.Version) {
+	writeAdminJSON(w, http.StatusOK, struct {
+		Tenant string `json:"tenant"`
+		*glossary.Version
+	}{id, v})
+}
+
+// writeGlossaryError 写入术语表操作的错误响应，err 为 nil 时返回 true
+func writeGlossaryError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, glossary.ErrInvalid):
+		utils.WriteError(w, http.StatusBadRequest, "invalid_glossary", "invalid glossary", err.Error())
+	case errors.Is(err, glossary.ErrVersionNotFound):
+		utils.WriteError(w, http.StatusNotFound, "version_not_found", "glossary version not found", err.Error())
+	default:
+		logging.Component("admin").ErrorContext(r.Context(), "glossary store failed", "error", err)
+		utils.WriteError(w, http.StatusInternalServerError, "glossary_unavailable", "failed to save glossary", nil)
+	}
+	return false
+}
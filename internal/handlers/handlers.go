@@ -1,18 +1,34 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
-
+	"unicode/utf8"
+
+	"svg-generator/internal/budget"
+	"svg-generator/internal/config"
+	"svg-generator/internal/jobs"
+	"svg-generator/internal/langdetect"
+	"svg-generator/internal/lifecycle"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/moderation"
+	"svg-generator/internal/privacy"
+	"svg-generator/internal/requestid"
 	"svg-generator/internal/service"
+	"svg-generator/internal/tenant"
+	"svg-generator/internal/tracing"
 	"svg-generator/internal/types"
+	"svg-generator/internal/usage"
 	"svg-generator/pkg/utils"
 )
 
@@ -49,18 +65,46 @@ func ClaudeImageHandler(serviceManager *service.ServiceManager, translateService
 // generateHandler 通用图像生成处理器
 func generateHandler(serviceManager *service.ServiceManager, translateService utils.TranslateService, provider types.Provider, directSVG bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// 预算降级可能更换本次请求的 Provider，不能修改闭包共享的变量
+		provider := provider
 		providerName := string(provider)
-		log.Printf("[%s] Request from %s: %s %s", providerName, r.RemoteAddr, r.Method, r.URL.Path)
+		start := time.Now()
+		// 整个请求使用同一份配置快照，处理过程中重新加载配置不影响本请求
+		cfg := config.Get()
+		logger := logging.Component("handler")
+		reqCtx := logging.With(r.Context(), slog.String("provider", providerName))
+		logger.DebugContext(reqCtx, "generate request received", "remote_addr", r.RemoteAddr, "method", r.Method, "path", r.URL.Path)
 
 		if r.Method != http.MethodPost {
-			log.Printf("[%s] Method not allowed: %s", providerName, r.Method)
+			logger.WarnContext(reqCtx, "method not allowed", "method", r.Method)
 			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only POST is allowed", nil)
 			return
 		}
 
+		// 运行时在配置中禁用或未配置 API Key 的 Provider 视为路由不存在
+		if serviceManager.GetProvider(provider) == nil {
+			utils.WriteError(w, http.StatusNotFound, "provider_disabled", "provider is disabled: "+providerName, nil)
+			return
+		}
+
+		// 排空阶段不再接受新的生成任务
+		if lifecycle.IsDraining() {
+			logger.WarnContext(reqCtx, "rejecting request during shutdown")
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "5")
+			utils.WriteError(w, http.StatusServiceUnavailable, "shutting_down", "server is shutting down", nil)
+			return
+		}
+
 		var req types.GenerateRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			log.Printf("[%s] JSON decode error: %v", providerName, err)
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logger.WarnContext(reqCtx, "invalid request body", "error", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large",
+					"max request size: "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+				return
+			}
 			utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
 			return
 		}
@@ -68,44 +112,249 @@ func generateHandler(serviceManager *service.ServiceManager, translateService ut
 		// 强制设置提供商
 		req.Provider = provider
 
-		log.Printf("[%s] Request parsed - prompt: %q, style: %q, provider: %s", providerName, req.Prompt, req.Style, req.Provider)
+		// 个人信息脱敏：在记录日志、翻译和调用上游之前处理，原文只在租户允许时写入审计日志
+		rawPrompt := req.Prompt
+		piiResult := privacy.Redact(tenant.FromContext(r.Context()), &req.Prompt, &req.NegativePrompt)
+		// 所有审计记录使用同一份提示词：脱敏后的提示词，租户允许保存原文时使用原文
+		auditPrompt := req.Prompt
+		auditDetails := map[string]interface{}{}
+		if piiResult != nil {
+			auditDetails["pii_types"] = piiResult.Types
+			if piiResult.StoreOriginal {
+				auditPrompt = rawPrompt
+			}
+		}
+		if piiResult != nil && piiResult.Action == privacy.ActionReject {
+			logger.WarnContext(reqCtx, "prompt contains personal data, rejecting request", "pii_types", piiResult.Types)
+			if !piiResult.StoreOriginal {
+				auditPrompt = ""
+			}
+			auditGeneration(r, req, auditPrompt, "pii_detected", auditDetails)
+			utils.WriteError(w, http.StatusUnprocessableEntity, "pii_detected", "prompt contains personal data",
+				map[string]interface{}{"types": piiResult.Types})
+			return
+		}
+
+		logger.InfoContext(reqCtx, "request parsed", "prompt", req.Prompt, "style", req.Style, "model", req.Model)
 
-		if len(req.Prompt) < 3 {
-			log.Printf("[%s] Prompt too short: %d chars", providerName, len(req.Prompt))
-			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "prompt must be at least 3 characters", nil)
+		if fieldErrors := validateGenerateRequest(&req); len(fieldErrors) > 0 {
+			logger.WarnContext(reqCtx, "request validation failed", "field_errors", len(fieldErrors))
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "request validation failed", fieldErrors)
 			return
 		}
 
-		// 翻译处理 (仅对 SVG.IO 提供商进行翻译，Recraft 和 Claude 支持中文)
+		// 预算检查：达到硬上限时按策略拒绝或降级到更便宜的 Provider。
+		// 租户由 WithTenant 按 API Key 认证得出，未认证的请求一律计入 default 租户，调用方无法借请求头使用其他租户的额度
+		if decision := budget.Check(tenant.FromContext(r.Context()), providerName); decision.Action != budget.ActionAllow {
+			target := types.Provider(decision.Provider)
+			if decision.Action == budget.ActionDowngrade && serviceManager.GetProvider(target) != nil {
+				downgraded := req
+				downgraded.Provider = target
+				// 模型和子风格只对原 Provider 有效
+				downgraded.Model = ""
+				downgraded.Substyle = ""
+				if len(validateGenerateRequest(&downgraded)) == 0 {
+					logger.WarnContext(reqCtx, "budget exceeded, downgrading provider",
+						"scope", decision.Scope, "spend", decision.Spend, "limit", decision.Limit, "downgrade_to", target)
+					metrics.BudgetActions.Inc(decision.Scope, budget.ActionDowngrade)
+					w.Header().Set("X-Budget-Downgraded-From", providerName)
+					provider, providerName, req = target, string(target), downgraded
+					reqCtx = logging.With(reqCtx, slog.String("downgraded_to", providerName))
+				} else {
+					decision.Action = budget.ActionReject
+				}
+			} else {
+				decision.Action = budget.ActionReject
+			}
+			if decision.Action == budget.ActionReject {
+				auditGeneration(r, req, auditPrompt, "budget_exceeded", auditDetails)
+				writeBudgetExceeded(w, r, decision)
+				return
+			}
+		}
+
+		// 翻译处理：检测提示词语言，Provider 不支持该语言时翻译成英文；
+		// 以英文为主但夹杂 Provider 不支持的文字（如 "a sign that says 欢迎"）时同样翻译
+		detected := langdetect.Detect(req.Prompt)
+		needsTranslation := (detected.Lang != langdetect.Undetermined &&
+			detected.Confidence >= cfg.Translation.GetMinConfidence() &&
+			!cfg.ProviderSupportsLanguage(providerName, detected.Lang)) ||
+			len(langdetect.ForeignScripts(req.Prompt, cfg.GetProviderLanguages(providerName)...)) > 0
 		originalPrompt := req.Prompt
 		translatedPrompt := req.Prompt
 		wasTranslated := false
+		var translateInfo *utils.TranslateInfo
+
+		metrics.GenerationsInFlight.Inc(providerName)
+		defer metrics.GenerationsInFlight.Dec(providerName)
+
+		// 整个请求（翻译 + 生成 + 下载）受 server.timeout 约束
+		reqCtx, cancelReq := context.WithTimeout(reqCtx, cfg.Server.GetRequestTimeout())
+		defer cancelReq()
+
+		// 登记为进行中的任务，管理接口可以查看和取消
+		reqCtx, job := jobs.Start(reqCtx, providerName, r.URL.Path, requestid.FromContext(r.Context()))
+		defer job.Finish()
+
+		// 用量核算：各 Provider 服务把 token、图片和向量化调用计入 meter
+		reqCtx, meter := usage.NewContext(reqCtx)
+
+		// 链路追踪：延续上游传入的 traceparent
+		reqCtx, span := tracing.Start(tracing.Extract(reqCtx, r.Header), "generateHandler",
+			tracing.WithSpanKind(tracing.SpanKindServer),
+			tracing.WithAttributes(
+				tracing.String("http.route", r.URL.Path),
+				tracing.String("provider", providerName),
+				tracing.String("model", req.Model),
+				tracing.Int("prompt.length", utf8.RuneCountInString(req.Prompt)),
+				tracing.Bool("direct_svg", directSVG),
+				tracing.String("request_id", requestid.FromContext(r.Context())),
+			))
+		defer span.End()
+
+		// 生成前内容审核：先检查调用方提交的原文，翻译和增强的结果在调用上游之前再检查一次
+		moderationResult := moderation.CheckInput(reqCtx, tenant.FromContext(r.Context()), req.Prompt, req.NegativePrompt)
+		if moderationResult != nil && moderationResult.Action == moderation.ActionBlock {
+			auditDetails["moderation"] = moderationResult
+			auditGeneration(r, req, auditPrompt, "content_blocked", auditDetails)
+			writeContentBlocked(w, r, moderationResult)
+			return
+		}
 
-		if !req.SkipTranslate && translateService != nil && provider == types.ProviderSVGIO {
-			translateCtx, cancel := context.WithTimeout(r.Context(), 45*time.Second)
-			defer cancel()
+		span.SetAttributes(tracing.String("prompt.language", detected.Lang))
+		if req.SkipTranslate && needsTranslation {
+			metrics.Translations.Inc("skipped")
+		} else if translateService != nil && cfg.Translation.Enabled && needsTranslation {
+			// 每个翻译器的超时由翻译器链控制
+			job.SetStage(jobs.StageTranslating)
+			var translateCtx context.Context
+			translateCtx, translateInfo = utils.WithTranslateInfo(reqCtx)
 
+			translateStart := time.Now()
 			translated, err := translateService.Translate(translateCtx, req.Prompt)
+			outcome := "unchanged"
 			if err != nil {
-				log.Printf("[%s] Translation failed: %v", providerName, err)
+				outcome = "failed"
+				logger.WarnContext(reqCtx, "translation failed, using original prompt", "error", err)
 				// 翻译失败时使用原文继续处理，不中断流程
 			} else if translated != req.Prompt {
+				outcome = "translated"
 				translatedPrompt = translated
 				wasTranslated = true
-				log.Printf("[%s] Prompt translated: %q -> %q", providerName, originalPrompt, translatedPrompt)
+				logger.InfoContext(reqCtx, "prompt translated", "original_prompt", originalPrompt, "translated_prompt", translatedPrompt,
+					"translator", translateInfo.Translator)
 			}
+			metrics.Translations.Inc(outcome)
+			metrics.TranslationDuration.Observe(time.Since(translateStart).Seconds(), outcome)
 		}
 
 		// 使用翻译后的提示词
 		req.Prompt = translatedPrompt
+		span.SetAttributes(tracing.Bool("prompt.translated", wasTranslated))
+
+		// 提示词增强：把简短的提示词扩写为针对 Provider 的画面描述，失败时使用原提示词继续处理
+		enhanceLevel := req.Enhance
+		if enhanceLevel == "" {
+			enhanceLevel = cfg.Enhancement.GetDefaultLevel()
+		}
+		enhancedPrompt := ""
+		if cfg.Enhancement.Enabled && enhanceLevel != config.EnhanceOff {
+			if utf8.RuneCountInString(req.Prompt) > cfg.Enhancement.GetMaxInputLength() {
+				metrics.PromptEnhancements.Inc(enhanceLevel, "skipped")
+			} else {
+				job.SetStage(jobs.StageEnhancing)
+				enhanced, err := utils.EnhancePrompt(reqCtx, req.Prompt, provider, enhanceLevel)
+				if err != nil {
+					logger.WarnContext(reqCtx, "prompt enhancement failed, using original prompt", "level", enhanceLevel, "error", err)
+				} else if enhanced != req.Prompt {
+					enhancedPrompt = enhanced
+					req.Prompt = enhanced
+					logger.InfoContext(reqCtx, "prompt enhanced", "level", enhanceLevel, "enhanced_prompt", enhancedPrompt)
+				}
+			}
+		}
+		span.SetAttributes(tracing.Bool("prompt.enhanced", enhancedPrompt != ""))
 
-		ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-		defer cancel()
+		// 译文和增强后的提示词是实际发往上游的文本，同样需要通过审核
+		var derivedTexts []string
+		if wasTranslated {
+			derivedTexts = append(derivedTexts, translatedPrompt)
+		}
+		if enhancedPrompt != "" {
+			derivedTexts = append(derivedTexts, enhancedPrompt)
+		}
+		moderationResult = moderation.RecheckInput(reqCtx, moderationResult, derivedTexts...)
+		if moderationResult != nil && moderationResult.Action == moderation.ActionBlock {
+			auditDetails["moderation"] = moderationResult
+			auditGeneration(r, req, auditPrompt, "content_blocked", auditDetails)
+			writeContentBlocked(w, r, moderationResult)
+			return
+		}
 
-		log.Printf("[%s] Calling upstream API...", providerName)
+		ctx := reqCtx
+		job.SetStage(jobs.StageGenerating)
+		logger.DebugContext(ctx, "calling upstream API")
 		img, err := serviceManager.GenerateImage(ctx, req)
+		outcome := usage.OutcomeSuccess
 		if err != nil {
-			log.Printf("[%s] Upstream generation failed: %v", providerName, err)
+			outcome = service.ClassifyError(err)
+		}
+		recordUsage(r, provider, req.Model, meter, outcome, start)
+		// 生成后内容审核：根据上游的 NSFW 标记决定是否放行
+		auditOutcome := outcome
+		if err == nil {
+			moderationResult = moderation.CheckOutput(img, moderationResult)
+			if moderationResult != nil && moderationResult.Action == moderation.ActionBlock {
+				auditOutcome = "content_blocked"
+			}
+		}
+		if moderationResult != nil {
+			auditDetails["moderation"] = moderationResult
+		}
+		if wasTranslated {
+			auditDetails["translated_prompt"] = translatedPrompt
+			auditDetails["translator"] = translateInfo.Translator
+			if translateInfo.Cached {
+				auditDetails["translation_cached"] = true
+			}
+			if translateInfo.GlossaryVersion != "" {
+				auditDetails["glossary_version"] = translateInfo.GlossaryVersion
+			}
+		}
+		if enhancedPrompt != "" {
+			auditDetails["enhanced_prompt"] = enhancedPrompt
+			auditDetails["enhancement_level"] = enhanceLevel
+		}
+		if from := w.Header().Get("X-Budget-Downgraded-From"); from != "" {
+			auditDetails["downgraded_from"] = from
+		}
+		if img != nil {
+			auditDetails["image_id"] = img.ID
+		}
+		auditReq := req
+		if model := meter.Model(); model != "" {
+			auditReq.Model = model
+		}
+		auditGeneration(r, auditReq, auditPrompt, auditOutcome, auditDetails)
+		if err != nil {
+			logger.ErrorContext(ctx, "upstream generation failed", "error", err)
+			span.RecordError(err)
+			if errors.Is(err, service.ErrCircuitOpen) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(cfg.Health.GetBreakerOpenDuration().Seconds())))
+				utils.WriteError(w, http.StatusServiceUnavailable, "provider_unavailable", "provider temporarily unavailable", err.Error())
+				return
+			}
+			if errors.Is(err, service.ErrNoKeyAvailable) {
+				retryAfter := int(serviceManager.KeyRetryAfter(provider).Seconds()) + 1
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				utils.WriteError(w, http.StatusServiceUnavailable, "provider_unavailable", "provider temporarily unavailable", err.Error())
+				return
+			}
+			// 客户端仍在等待而上下文被取消，说明任务被管理接口取消
+			if errors.Is(err, context.Canceled) && r.Context().Err() == nil {
+				utils.WriteError(w, http.StatusServiceUnavailable, "job_cancelled", "generation was cancelled by an administrator", nil)
+				return
+			}
 			status := http.StatusBadGateway
 			if errors.Is(err, context.DeadlineExceeded) {
 				status = http.StatusGatewayTimeout
@@ -114,64 +363,105 @@ func generateHandler(serviceManager *service.ServiceManager, translateService ut
 			return
 		}
 
-		log.Printf("[%s] Generation successful - ID: %s, SVG URL: %s", providerName, img.ID, img.SVGURL)
+		if moderationResult != nil && moderationResult.Action == moderation.ActionBlock {
+			writeContentBlocked(w, r, moderationResult)
+			return
+		}
+
+		logger.InfoContext(ctx, "generation succeeded", "image_id", img.ID)
 
 		if directSVG {
 			// 直接返回 SVG 文件
-			log.Printf("[%s] Processing SVG content from: %s", providerName, img.SVGURL)
+			logger.DebugContext(ctx, "processing SVG content", "svg_url", truncateURL(img.SVGURL))
 
-			var svgBytes []byte
-			var err error
+			var body io.Reader
+			contentType := utils.ContentTypeSVG
 
 			// 检查是否是data URL
 			if strings.HasPrefix(img.SVGURL, "data:") {
 				// 处理data URL
-				svgBytes, err = parseDataURL(img.SVGURL)
+				svgBytes, err := parseDataURL(img.SVGURL)
 				if err != nil {
-					log.Printf("[%s] Failed to parse data URL: %v", providerName, err)
+					logger.ErrorContext(ctx, "failed to parse data URL", "error", err)
 					utils.WriteError(w, http.StatusInternalServerError, "parse_error", "failed to parse data URL", err.Error())
 					return
 				}
-				log.Printf("[%s] Parsed data URL - size: %d bytes", providerName, len(svgBytes))
+				logger.DebugContext(ctx, "parsed data URL", "bytes", len(svgBytes))
+				body = bytes.NewReader(svgBytes)
 			} else {
-				// 处理HTTP/HTTPS URL
-				svgBytes, err = utils.DownloadFile(ctx, img.SVGURL)
+				// 处理HTTP/HTTPS URL，经过主机白名单和大小校验后流式转发
+				job.SetStage(jobs.StageDownloading)
+				download, err := serviceManager.GetDownloader(provider).Open(ctx, img.SVGURL)
 				if err != nil {
-					log.Printf("[%s] Download failed: %v", providerName, err)
+					logger.ErrorContext(ctx, "download failed", "error", err)
 					utils.WriteError(w, http.StatusBadGateway, "download_error", "failed to download generated svg", err.Error())
 					return
 				}
-				log.Printf("[%s] Download successful - size: %d bytes", providerName, len(svgBytes))
+				defer download.Body.Close()
+				logger.DebugContext(ctx, "download started", "content_type", download.ContentType, "content_length", download.ContentLength)
+				body = download.Body
+				contentType = download.ContentType
 			}
 
-			w.Header().Set("Content-Type", "image/svg+xml")
-			w.Header().Set("Content-Disposition", "attachment; filename=\""+img.ID+".svg\"")
+			ext := ".svg"
+			if contentType == utils.ContentTypePNG {
+				ext = ".png"
+			}
+
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", "attachment; filename=\""+img.ID+ext+"\"")
 			// 可以附带元信息 header
 			w.Header().Set("X-Image-Id", img.ID)
 			w.Header().Set("X-Image-Width", strconv.Itoa(img.Width))
 			w.Header().Set("X-Image-Height", strconv.Itoa(img.Height))
 			w.Header().Set("X-Provider", string(provider))
+			if moderationResult != nil {
+				w.Header().Set("X-Moderation", moderationResult.Action)
+			}
+			if piiResult != nil {
+				w.Header().Set("X-PII-Redacted", strings.Join(piiResult.Types, ","))
+			}
 			// 添加翻译信息到响应头
 			if wasTranslated {
 				w.Header().Set("X-Original-Prompt", originalPrompt)
 				w.Header().Set("X-Translated-Prompt", translatedPrompt)
 				w.Header().Set("X-Was-Translated", "true")
+				w.Header().Set("X-Translator", translateInfo.Translator)
+				if translateInfo.Cached {
+					w.Header().Set("X-Translation-Cached", "true")
+				}
+				if translateInfo.GlossaryVersion != "" {
+					w.Header().Set("X-Glossary-Version", translateInfo.GlossaryVersion)
+				}
+			}
+			// 增强后的提示词可能很长且含非 ASCII 字符，不放在响应头中，只在 JSON 响应中返回
+			if enhancedPrompt != "" {
+				w.Header().Set("X-Enhancement-Level", enhanceLevel)
 			}
 			utils.SetCORSHeaders(w)
 			w.WriteHeader(http.StatusOK)
-			if _, err := w.Write(svgBytes); err != nil {
-				log.Printf("[%s] Write response error: %v", providerName, err)
+			if n, err := io.Copy(w, body); err != nil {
+				logger.WarnContext(ctx, "write response failed", "bytes", n, "error", err)
 			} else {
-				log.Printf("[%s] Response sent successfully", providerName)
+				if contentType == utils.ContentTypeSVG {
+					metrics.SVGBytes.Observe(float64(n), providerName)
+				}
+				logger.InfoContext(ctx, "SVG response sent", "bytes", n)
 			}
 		} else {
 			// 返回 JSON 元数据
 			response := types.ImageResponse{
-				ID:       img.ID,
-				SVGURL:   img.SVGURL,
-				Width:    img.Width,
-				Height:   img.Height,
-				Provider: provider,
+				ID:         img.ID,
+				SVGURL:     img.SVGURL,
+				Width:      img.Width,
+				Height:     img.Height,
+				Provider:   provider,
+				RequestID:  requestid.FromContext(r.Context()),
+				Moderation: moderationResult,
+			}
+
+			if piiResult != nil {
+				response.PIIRedacted = piiResult.Types
 			}
 
 			// 添加翻译信息
@@ -179,6 +469,17 @@ func generateHandler(serviceManager *service.ServiceManager, translateService ut
 				response.OriginalPrompt = originalPrompt
 				response.TranslatedPrompt = translatedPrompt
 				response.WasTranslated = wasTranslated
+				response.Translator = translateInfo.Translator
+				response.TranslationCached = translateInfo.Cached
+				response.GlossaryVersion = translateInfo.GlossaryVersion
+			}
+			if enhancedPrompt != "" {
+				response.OriginalPrompt = originalPrompt
+				response.EnhancedPrompt = enhancedPrompt
+				response.EnhancementLevel = enhanceLevel
+			}
+			if detected.Lang != langdetect.Undetermined {
+				response.DetectedLanguage = detected.Lang
 			}
 
 			w.Header().Set("Content-Type", "application/json")
@@ -186,27 +487,93 @@ func generateHandler(serviceManager *service.ServiceManager, translateService ut
 			w.WriteHeader(http.StatusOK)
 
 			if err := json.NewEncoder(w).Encode(response); err != nil {
-				log.Printf("[%s] JSON encode error: %v", providerName, err)
+				logger.WarnContext(ctx, "JSON encode failed", "error", err)
 			} else {
-				log.Printf("[%s] JSON response sent successfully", providerName)
+				logger.InfoContext(ctx, "JSON response sent")
 			}
 		}
 	}
 }
 
-// HealthHandler 健康检查处理器
+// writeContentBlocked 返回 422 content_blocked，details 为审核结果
+func writeContentBlocked(w http.ResponseWriter, r *http.Request, result *types.ModerationResult) {
+	logging.Component("handler").WarnContext(r.Context(), "content blocked by moderation",
+		"stage", result.Stage, "level", result.Level, "categories", result.Categories)
+	utils.WriteError(w, http.StatusUnprocessableEntity, "content_blocked", "content rejected by moderation policy", result)
+}
+
+// writeBudgetExceeded 返回 402 budget_exceeded，Retry-After 为当前周期结束的时间
+func writeBudgetExceeded(w http.ResponseWriter, r *http.Request, decision budget.Decision) {
+	logging.Component("handler").WarnContext(r.Context(), "budget exceeded, rejecting request",
+		"scope", decision.Scope, "spend", decision.Spend, "limit", decision.Limit)
+	metrics.BudgetActions.Inc(decision.Scope, budget.ActionReject)
+	if wait := time.Until(decision.ResetAt); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	}
+	utils.WriteError(w, http.StatusPaymentRequired, "budget_exceeded", "spend limit reached for the current period", map[string]interface{}{
+		"scope":     decision.Scope,
+		"tenant":    decision.Tenant,
+		"spend":     decision.Spend,
+		"limit":     decision.Limit,
+		"currency":  config.Get().Usage.GetCurrency(),
+		"resets_at": decision.ResetAt,
+	})
+}
+
+// recordUsage 将一次上游调用写入用量台账，失败的调用同样记录（可能已产生上游费用）
+func recordUsage(r *http.Request, provider types.Provider, model string, meter *usage.Meter, outcome string, start time.Time) {
+	usage.Record(usage.Entry{
+		Time:       start.UTC(),
+		RequestID:  requestid.FromContext(r.Context()),
+		Tenant:     tenant.FromContext(r.Context()),
+		Provider:   string(provider),
+		Model:      model,
+		Route:      r.URL.Path,
+		Outcome:    outcome,
+		DurationMs: time.Since(start).Milliseconds(),
+	}, meter)
+}
+
+// HealthHandler 健康检查处理器，排空阶段返回 503 以便负载均衡摘除实例
 func HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		utils.SetCORSHeaders(w)
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		status, text := http.StatusOK, "ok"
+		if !lifecycle.IsReady() {
+			status, text = http.StatusServiceUnavailable, "shutting_down"
+		}
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]string{
-			"status": "ok",
+			"status": text,
 			"time":   time.Now().Format(time.RFC3339),
 		})
 	}
 }
 
+// decodeJSONBody 按配置的大小上限严格解析请求体，拒绝未知字段和多余内容
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, config.Get().GetMaxRequestSize())
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("request body must contain a single JSON object")
+	}
+	return nil
+}
+
+// truncateURL 截断 data URL 等超长地址用于日志
+func truncateURL(u string) string {
+	if len(u) <= 200 {
+		return u
+	}
+	return u[:200] + "..."
+}
+
 // parseDataURL 解析data URL并返回解码后的数据
 func parseDataURL(dataURL string) ([]byte, error) {
 	// data URL格式: data:[<mediatype>][;base64],<data>
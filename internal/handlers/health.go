@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"svg-generator/internal/lifecycle"
+	"svg-generator/internal/service"
+	"svg-generator/pkg/utils"
+)
+
+// startTime 进程启动时间，用于计算运行时长
+var startTime = time.Now()
+
+// LivenessHandler 存活检查：进程能够处理请求即返回 200，不检查上游
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealthJSON(w, http.StatusOK, map[string]interface{}{
+			"status":         "ok",
+			"uptime_seconds": int64(time.Since(startTime).Seconds()),
+			"time":           time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// ReadinessHandler 就绪检查：排空阶段或没有可用 Provider 时返回 503，供编排系统摘除流量
+func ReadinessHandler(serviceManager *service.ServiceManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, reason := http.StatusOK, ""
+		switch {
+		case lifecycle.IsDraining():
+			status, reason = http.StatusServiceUnavailable, "shutting_down"
+		case !lifecycle.IsReady():
+			status, reason = http.StatusServiceUnavailable, "starting"
+		case !serviceManager.Ready():
+			status, reason = http.StatusServiceUnavailable, "no_available_provider"
+		}
+
+		body := map[string]interface{}{
+			"status": "ready",
+			"time":   time.Now().Format(time.RFC3339),
+		}
+		if status != http.StatusOK {
+			body["status"] = "not_ready"
+			body["reason"] = reason
+		}
+		writeHealthJSON(w, status, body)
+	}
+}
+
+// ProviderHealthHandler 返回各 Provider 的配置状态、最近调用结果、凭据检查、熔断状态和 p95 延迟
+func ProviderHealthHandler(serviceManager *service.ServiceManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		providers := serviceManager.ProviderHealth()
+
+		status := http.StatusOK
+		if !serviceManager.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealthJSON(w, status, map[string]interface{}{
+			"providers": providers,
+			"time":      time.Now().Format(time.RFC3339),
+		})
+	}
+}
+
+// writeHealthJSON 写入健康检查响应，禁止缓存
+func writeHealthJSON(w http.ResponseWriter, status int, body interface{}) {
+	utils.SetCORSHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
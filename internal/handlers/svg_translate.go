@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/jobs"
+	"svg-generator/internal/lifecycle"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/privacy"
+	"svg-generator/internal/requestid"
+	"svg-generator/internal/service"
+	"svg-generator/internal/svgtext"
+	"svg-generator/internal/tenant"
+	"svg-generator/internal/types"
+	"svg-generator/internal/usage"
+	"svg-generator/pkg/utils"
+)
+
+// maxSVGTextSegments 单个 SVG 最多翻译的文字节点数
+const maxSVGTextSegments = 500
+
+// targetLanguagePattern ISO 639-1/639-3 语言代码
+var targetLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// svgTranslateProvider 任务列表和用量台账中 SVG 翻译请求使用的 Provider 名称
+const svgTranslateProvider types.Provider = "translation"
+
+// SVGTranslateHandler 翻译 SVG 中 <text> 元素的文字：需要翻译的文字节点合并为一次批量翻译后写回，
+// 坐标和样式保持不变，可选在译文明显变长时缩小字号
+func SVGTranslateHandler(translateService utils.TranslateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := logging.Component("handler")
+		ctx := r.Context()
+
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only POST is allowed", nil)
+			return
+		}
+		if translateService == nil || !config.Get().Translation.Enabled {
+			utils.WriteError(w, http.StatusServiceUnavailable, "translation_unavailable", "translation service is not configured", nil)
+			return
+		}
+
+		// 排空阶段不再接受新的翻译任务
+		if lifecycle.IsDraining() {
+			logger.WarnContext(ctx, "rejecting request during shutdown")
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "5")
+			utils.WriteError(w, http.StatusServiceUnavailable, "shutting_down", "server is shutting down", nil)
+			return
+		}
+
+		var req types.SVGTranslateRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large",
+					"max request size: "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, "invalid_json", "invalid request body", err.Error())
+			return
+		}
+		if req.TargetLanguage == "" {
+			req.TargetLanguage = utils.DefaultTargetLanguage
+		}
+		var fieldErrors []types.FieldError
+		if req.SVG == "" {
+			fieldErrors = append(fieldErrors, types.FieldError{Field: "svg", Message: "is required"})
+		}
+		if !targetLanguagePattern.MatchString(req.TargetLanguage) {
+			fieldErrors = append(fieldErrors, types.FieldError{Field: "target_language", Message: "must be a lowercase ISO 639 language code"})
+		}
+		if req.MinFontScale != 0 && (req.MinFontScale < 0.1 || req.MinFontScale > 1) {
+			fieldErrors = append(fieldErrors, types.FieldError{Field: "min_font_scale", Message: "must be between 0.1 and 1"})
+		}
+		if len(fieldErrors) > 0 {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "request validation failed", fieldErrors)
+			return
+		}
+
+		doc, err := svgtext.Parse([]byte(req.SVG))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_svg", "svg could not be parsed", err.Error())
+			return
+		}
+		texts := doc.Texts()
+		if len(texts) > maxSVGTextSegments {
+			utils.WriteError(w, http.StatusBadRequest, "too_many_segments", "svg contains too many text nodes",
+				"max text nodes: "+strconv.Itoa(maxSVGTextSegments))
+			return
+		}
+
+		// 个人信息检测：占位符无法可靠地还原成原值，含个人信息的片段不发往翻译服务，在译文中保留原文
+		segments := make([]string, len(texts))
+		pointers := make([]*string, len(texts))
+		for i := range texts {
+			segments[i] = texts[i]
+			pointers[i] = &segments[i]
+		}
+		piiResult := privacy.Redact(tenant.FromContext(ctx), pointers...)
+		piiSegments := make(map[int]bool)
+		for i := range segments {
+			if segments[i] != texts[i] {
+				piiSegments[i] = true
+				segments[i] = "" // 空白片段不翻译
+			}
+		}
+		if piiResult != nil && piiResult.Action == privacy.ActionReject {
+			logger.WarnContext(ctx, "svg text contains personal data, rejecting request", "pii_types", piiResult.Types)
+			utils.WriteError(w, http.StatusUnprocessableEntity, "pii_detected", "svg text contains personal data",
+				map[string]interface{}{"types": piiResult.Types})
+			return
+		}
+
+		response := types.SVGTranslateResponse{
+			SVG:            req.SVG,
+			TargetLanguage: req.TargetLanguage,
+			Segments:       len(texts),
+			RequestID:      requestid.FromContext(ctx),
+		}
+		if piiResult != nil {
+			response.PIIRedacted = piiResult.Types
+		}
+		if len(texts) > 0 {
+			// 登记为进行中的任务，管理接口可以查看和取消
+			ctx, job := jobs.Start(ctx, string(svgTranslateProvider), r.URL.Path, requestid.FromContext(r.Context()))
+			defer job.Finish()
+			job.SetStage(jobs.StageTranslating)
+			ctx, meter := usage.NewContext(ctx)
+
+			translateCtx, info := utils.WithTranslateInfo(utils.WithTargetLanguage(ctx, req.TargetLanguage))
+			translated, err := utils.TranslateSegments(translateCtx, translateService, segments)
+			// 所有片段都已是目标语言时没有调用翻译服务，不计入用量
+			if err != nil || info.Translator != "" {
+				outcome := usage.OutcomeSuccess
+				if err != nil {
+					outcome = service.ClassifyError(err)
+				}
+				recordUsage(r, svgTranslateProvider, info.Translator, meter, outcome, start)
+			}
+			if err != nil {
+				logger.WarnContext(ctx, "svg text translation failed", "segments", len(texts), "error", err)
+				utils.WriteError(w, http.StatusBadGateway, "translation_failed", "failed to translate svg text", nil)
+				return
+			}
+			for i := range piiSegments {
+				translated[i] = strings.Join(strings.Fields(texts[i]), " ")
+			}
+			svg, adjusted, err := doc.Render(translated, svgtext.Options{AdjustFontSize: req.AdjustFontSize, MinScale: req.MinFontScale})
+			if err != nil {
+				logger.ErrorContext(ctx, "svg text rewrite failed", "error", err)
+				utils.WriteError(w, http.StatusInternalServerError, "internal_error", "failed to rewrite svg", nil)
+				return
+			}
+			response.SVG = string(svg)
+			response.FontAdjusted = adjusted
+			response.Translator = info.Translator
+			response.TranslationCached = info.Cached
+			logger.InfoContext(ctx, "svg text translated", "segments", len(texts), "target", req.TargetLanguage,
+				"pii_segments", len(piiSegments), "font_adjusted", adjusted, "translator", info.Translator)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		utils.SetCORSHeaders(w)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.WarnContext(ctx, "write response failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/tenant"
+	"svg-generator/internal/usage"
+	"svg-generator/pkg/utils"
+)
+
+// JSON 明细模式默认和最多返回的记录数，CSV 导出不受限制
+const (
+	defaultUsageLimit = 1000
+	maxUsageLimit     = 10000
+)
+
+// usageReport GET /v1/usage 的 JSON 响应
+type usageReport struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Tenant    string          `json:"tenant,omitempty"`
+	Provider  string          `json:"provider,omitempty"`
+	GroupBy   string          `json:"group_by,omitempty"`
+	Currency  string          `json:"currency"`
+	Total     usage.Summary   `json:"total"`
+	Groups    []usage.Summary `json:"groups,omitempty"`
+	Entries   []usage.Entry   `json:"entries,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// UsageHandler GET /v1/usage 查询用量和成本。
+// 携带管理令牌时可查询全部租户（可用 tenant 参数过滤），携带租户 API Key 时只能查询本租户，未认证时返回 401
+func UsageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "only GET is allowed", nil)
+			return
+		}
+		ledger := usage.Default()
+		usageCfg := config.Get().Usage
+		if !usageCfg.Enabled || ledger == nil {
+			utils.WriteError(w, http.StatusNotFound, "usage_disabled", "usage accounting is disabled", nil)
+			return
+		}
+
+		q := r.URL.Query()
+		now := time.Now().UTC()
+		from, err := parseUsageTime(q.Get("from"), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), false)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid from", err.Error())
+			return
+		}
+		to, err := parseUsageTime(q.Get("to"), now.Add(time.Second), true)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid to", err.Error())
+			return
+		}
+		if !from.Before(to) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "from must be before to", nil)
+			return
+		}
+
+		groupBy := q.Get("group_by")
+		if groupBy != "" && !usage.IsGroupBy(groupBy) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid group_by",
+				"must be one of tenant, provider, model")
+			return
+		}
+
+		limit := defaultUsageLimit
+		if raw := q.Get("limit"); raw != "" {
+			limit, err = strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxUsageLimit {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "invalid limit",
+					fmt.Sprintf("must be an integer between 1 and %d", maxUsageLimit))
+				return
+			}
+		}
+
+		tenantFilter := q.Get("tenant")
+		if !isAdminRequest(r) {
+			// 租户只能由 API Key 证明，未认证的调用方不能查询任何租户的用量
+			if !tenant.IsAuthenticated(r.Context()) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "usage reports require a tenant API key or the admin token", nil)
+				return
+			}
+			own := tenant.FromContext(r.Context())
+			if tenantFilter != "" && tenantFilter != own {
+				utils.WriteError(w, http.StatusForbidden, "forbidden", "usage of other tenants requires the admin token", nil)
+				return
+			}
+			tenantFilter = own
+		}
+		providerFilter := q.Get("provider")
+		if providerFilter != "" && !isKnownProvider(providerFilter) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_argument", "unknown provider: "+providerFilter, nil)
+			return
+		}
+
+		entries, err := ledger.Query(from, to, func(e usage.Entry) bool {
+			return (tenantFilter == "" || e.Tenant == tenantFilter) &&
+				(providerFilter == "" || e.Provider == providerFilter)
+		})
+		if err != nil {
+			logging.Component("usage").ErrorContext(r.Context(), "failed to query usage ledger", "error", err)
+			utils.WriteError(w, http.StatusInternalServerError, "usage_unavailable", "failed to read usage ledger", nil)
+			return
+		}
+		groups, total := usage.Summarize(entries, groupBy)
+
+		w.Header().Set("Cache-Control", "no-store")
+		if q.Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			writeUsageCSV(w, from, to, groupBy, usageCfg.GetCurrency(), groups, entries)
+			return
+		}
+
+		report := usageReport{
+			From:     from,
+			To:       to,
+			Tenant:   tenantFilter,
+			Provider: providerFilter,
+			GroupBy:  groupBy,
+			Currency: usageCfg.GetCurrency(),
+			Total:    total,
+			Groups:   groups,
+		}
+		if groupBy == "" {
+			// 明细按时间倒序，截断时保留最新的记录
+			if len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+				report.Truncated = true
+			}
+			report.Entries = make([]usage.Entry, len(entries))
+			for i, e := range entries {
+				report.Entries[len(entries)-1-i] = e
+			}
+		}
+		utils.SetCORSHeaders(w)
+		utils.WriteJSON(w, http.StatusOK, report)
+	}
+}
+
+// parseUsageTime 解析 RFC3339 时间或 YYYY-MM-DD 日期（UTC）；
+// 日期作为结束时间时包含当天，即取次日零点
+func parseUsageTime(raw string, fallback time.Time, end bool) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD date, got %q", raw)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// writeUsageCSV 以 CSV 导出汇总（指定 group_by 时）或明细
+func writeUsageCSV(w http.ResponseWriter, from, to time.Time, groupBy, currency string, groups []usage.Summary, entries []usage.Entry) {
+	filename := fmt.Sprintf("usage-%s-%s.csv", from.Format("20060102"), to.Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	utils.SetCORSHeaders(w)
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	itoa := strconv.Itoa
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	if groupBy != "" {
+		_ = cw.Write([]string{groupBy, "requests", "successes", "input_tokens", "output_tokens", "images", "vectorize_calls", "cost", "currency"})
+		for _, g := range groups {
+			_ = cw.Write([]string{g.Key, itoa(g.Requests), itoa(g.Successes), itoa(g.InputTokens), itoa(g.OutputTokens),
+				itoa(g.Images), itoa(g.VectorizeCalls), money(g.Cost), currency})
+		}
+	} else {
+		_ = cw.Write([]string{"time", "request_id", "tenant", "provider", "model", "route", "outcome",
+			"input_tokens", "output_tokens", "images", "vectorize_calls", "cost", "currency", "duration_ms"})
+		for _, e := range entries {
+			_ = cw.Write([]string{e.Time.Format(time.RFC3339Nano), e.RequestID, e.Tenant, e.Provider, e.Model, e.Route, e.Outcome,
+				itoa(e.InputTokens), itoa(e.OutputTokens), itoa(e.Images), itoa(e.VectorizeCalls), money(e.Cost), e.Currency,
+				strconv.FormatInt(e.DurationMs, 10)})
+		}
+	}
+	cw.Flush()
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/service"
+	"svg-generator/internal/types"
+)
+
+// 请求参数长度限制（按字符数计算，而非字节数）
+const (
+	minPromptLength         = 3
+	maxPromptLength         = 500
+	maxNegativePromptLength = 200
+	maxStyleLength          = 50
+	minNumImages            = 1
+	maxNumImages            = 6
+)
+
+// sizePattern 图像尺寸格式，如 "1024x1024"
+var sizePattern = regexp.MustCompile(`^[1-9][0-9]{1,4}x[1-9][0-9]{1,4}$`)
+
+// fieldRule 单个字段的校验规则，返回空字符串表示通过
+type fieldRule struct {
+	field string
+	check func(req *types.GenerateRequest, caps service.Capabilities) string
+}
+
+// generateRequestRules GenerateRequest 的声明式校验规则
+var generateRequestRules = []fieldRule{
+	{"prompt", func(req *types.GenerateRequest, _ service.Capabilities) string {
+		return checkRuneLength(req.Prompt, minPromptLength, maxPromptLength)
+	}},
+	{"negative_prompt", func(req *types.GenerateRequest, _ service.Capabilities) string {
+		return checkRuneLength(req.NegativePrompt, 0, maxNegativePromptLength)
+	}},
+	{"style", func(req *types.GenerateRequest, caps service.Capabilities) string {
+		if req.Style == "" {
+			return ""
+		}
+		if msg := checkRuneLength(req.Style, 0, maxStyleLength); msg != "" {
+			return msg
+		}
+		if len(caps.Styles) > 0 && !containsString(caps.Styles, req.Style) {
+			return fmt.Sprintf("unsupported style %q, expected one of: %s", req.Style, strings.Join(caps.Styles, ", "))
+		}
+		return ""
+	}},
+	{"model", func(req *types.GenerateRequest, caps service.Capabilities) string {
+		if req.Model == "" {
+			return ""
+		}
+		if len(caps.Models) == 0 {
+			return fmt.Sprintf("provider %s does not accept a model parameter", req.Provider)
+		}
+		if !containsString(caps.Models, req.Model) {
+			return fmt.Sprintf("unsupported model %q, expected one of: %s", req.Model, strings.Join(caps.Models, ", "))
+		}
+		return ""
+	}},
+	{"size", func(req *types.GenerateRequest, _ service.Capabilities) string {
+		if req.Size != "" && !sizePattern.MatchString(req.Size) {
+			return fmt.Sprintf("invalid size %q, expected format WIDTHxHEIGHT (e.g. 1024x1024)", req.Size)
+		}
+		return ""
+	}},
+	{"enhance", func(req *types.GenerateRequest, _ service.Capabilities) string {
+		switch req.Enhance {
+		case "", config.EnhanceOff, config.EnhanceLight, config.EnhanceDetailed:
+			return ""
+		}
+		return fmt.Sprintf("unsupported level %q, expected one of: %s, %s, %s",
+			req.Enhance, config.EnhanceOff, config.EnhanceLight, config.EnhanceDetailed)
+	}},
+	{"n", func(req *types.GenerateRequest, _ service.Capabilities) string {
+		// 0 表示未指定，使用Provider默认值
+		if req.NumImages != 0 && (req.NumImages < minNumImages || req.NumImages > maxNumImages) {
+			return fmt.Sprintf("must be between %d and %d", minNumImages, maxNumImages)
+		}
+		return ""
+	}},
+}
+
+// validateGenerateRequest 校验生成请求，返回所有字段错误
+func validateGenerateRequest(req *types.GenerateRequest) []types.FieldError {
+	caps := service.GetCapabilities(req.Provider)
+
+	var fieldErrors []types.FieldError
+	for _, rule := range generateRequestRules {
+		if msg := rule.check(req, caps); msg != "" {
+			fieldErrors = append(fieldErrors, types.FieldError{Field: rule.field, Message: msg})
+		}
+	}
+	return fieldErrors
+}
+
+// checkRuneLength 检查字符串字符数是否在 [min, max] 范围内
+func checkRuneLength(s string, min, max int) string {
+	n := utf8.RuneCountInString(strings.TrimSpace(s))
+	if n < min {
+		return fmt.Sprintf("must be at least %d characters (got %d)", min, n)
+	}
+	if n > max {
+		return fmt.Sprintf("must be at most %d characters (got %d)", max, n)
+	}
+	return ""
+}
+
+// containsString 检查切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Package jobs 记录进行中的生成任务，供管理接口查看和取消
+package jobs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 任务阶段
+const (
+	StageTranslating = "translating"
+	StageEnhancing   = "enhancing"
+	StageGenerating  = "generating"
+	StageDownloading = "downloading"
+)
+
+// Job 一个进行中的生成任务
+type Job struct {
+	id        string
+	requestID string
+	provider  string
+	route     string
+	startedAt time.Time
+	stage     atomic.Value
+	cancel    context.CancelFunc
+}
+
+// Snapshot 任务状态快照
+type Snapshot struct {
+	ID             string    `json:"id"`
+	RequestID      string    `json:"request_id,omitempty"`
+	Provider       string    `json:"provider"`
+	Route          string    `json:"route"`
+	Stage          string    `json:"stage"`
+	StartedAt      time.Time `json:"started_at"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+}
+
+var (
+	mu     sync.Mutex
+	active = map[string]*Job{}
+	nextID atomic.Uint64
+)
+
+// Start 登记任务并返回可被管理接口取消的上下文，任务结束时必须调用 Finish
+func Start(ctx context.Context, provider, route, requestID string) (context.Context, *Job) {
+	ctx, cancel := context.WithCancel(ctx)
+	job := &Job{
+		id:        "job-" + strconv.FormatUint(nextID.Add(1), 10),
+		requestID: requestID,
+		provider:  provider,
+		route:     route,
+		startedAt: time.Now(),
+		cancel:    cancel,
+	}
+	job.stage.Store(StageGenerating)
+
+	mu.Lock()
+	active[job.id] = job
+	mu.Unlock()
+	return ctx, job
+}
+
+// SetStage 更新任务所处阶段
+func (j *Job) SetStage(stage string) {
+	j.stage.Store(stage)
+}
+
+// Finish 移除任务并释放上下文
+func (j *Job) Finish() {
+	mu.Lock()
+	delete(active, j.id)
+	mu.Unlock()
+	j.cancel()
+}
+
+// List 返回全部进行中的任务，按开始时间排序
+func List() []Snapshot {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	out := make([]Snapshot, 0, len(active))
+	for _, j := range active {
+		out = append(out, Snapshot{
+			ID:             j.id,
+			RequestID:      j.requestID,
+			Provider:       j.provider,
+			Route:          j.route,
+			Stage:          j.stage.Load().(string),
+			StartedAt:      j.startedAt,
+			ElapsedSeconds: now.Sub(j.startedAt).Seconds(),
+		})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].StartedAt.Before(out[b].StartedAt) })
+	return out
+}
+
+// Cancel 取消指定任务，任务不存在时返回 false
+func Cancel(id string) bool {
+	mu.Lock()
+	job, ok := active[id]
+	mu.Unlock()
+	if ok {
+		job.cancel()
+	}
+	return ok
+}
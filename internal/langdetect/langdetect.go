@@ -0,0 +1,243 @@
+// Package langdetect 纯 Go 的提示词语言检测：先按文字系统（汉字、假名、谚文、西里尔字母等）判断，
+// 拉丁字母文本再按常用词、字符三元组和变音字母打分
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Undetermined 文本中没有可识别的字母
+const Undetermined = "und"
+
+// Result 检测结果
+type Result struct {
+	// Lang ISO 639-1 语言代码，无法判断时为 und
+	Lang string `json:"lang"`
+	// Script 占主导的文字系统
+	Script string `json:"script"`
+	// Confidence 置信度，0-1
+	Confidence float64 `json:"confidence"`
+}
+
+// scriptWeights 每个字符的权重：汉字、假名和谚文一个字符约相当于拉丁字母的一个词
+var scriptWeights = map[string]float64{
+	"Han": 3, "Kana": 3, "Hangul": 2,
+}
+
+// scriptTables 按检查顺序排列的文字系统
+var scriptTables = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Han", unicode.Han},
+	{"Kana", unicode.Hiragana},
+	{"Kana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Arabic", unicode.Arabic},
+	{"Hebrew", unicode.Hebrew},
+	{"Thai", unicode.Thai},
+	{"Greek", unicode.Greek},
+	{"Devanagari", unicode.Devanagari},
+	{"Latin", unicode.Latin},
+}
+
+// scriptLanguages 只对应一种语言的文字系统
+var scriptLanguages = map[string]string{
+	"Hangul": "ko", "Hebrew": "he", "Thai": "th", "Greek": "el", "Devanagari": "hi",
+}
+
+// minForeignShare 非拉丁文字的加权占比达到该值时按该文字判断，中英混排的提示词按中文处理
+const minForeignShare = 1.0 / 3
+
+// languageScripts 非拉丁字母语言使用的文字系统，未收录的语言按拉丁字母处理
+var languageScripts = map[string][]string{
+	"zh": {"Han"}, "ja": {"Han", "Kana"}, "ko": {"Hangul", "Han"},
+	"ru": {"Cyrillic"}, "uk": {"Cyrillic"}, "be": {"Cyrillic"},
+	"ar": {"Arabic"}, "fa": {"Arabic"},
+	"he": {"Hebrew"}, "th": {"Thai"}, "el": {"Greek"}, "hi": {"Devanagari"},
+}
+
+// ForeignScripts 返回文本中出现、但 languages 都不使用的文字系统，按出现顺序排列；
+// languages 含 "*" 时返回 nil。少量外文混排（如英文提示词中引用的中文招牌文字）
+// 不影响 Detect 的结果，只能由此判断上游是否能理解整段文本
+func ForeignScripts(text string, languages ...string) []string {
+	known := map[string]bool{}
+	for _, lang := range languages {
+		if lang == "*" {
+			return nil
+		}
+		scripts, ok := languageScripts[strings.ToLower(lang)]
+		if !ok {
+			scripts = []string{"Latin"}
+		}
+		for _, s := range scripts {
+			known[s] = true
+		}
+	}
+
+	var foreign []string
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scriptTables {
+			if unicode.Is(s.table, r) {
+				if !known[s.name] {
+					known[s.name] = true
+					foreign = append(foreign, s.name)
+				}
+				break
+			}
+		}
+	}
+	return foreign
+}
+
+// Detect 检测文本的语言
+func Detect(text string) Result {
+	r := detect(text)
+	r.Confidence = round2(r.Confidence)
+	return r
+}
+
+func detect(text string) Result {
+	weights := map[string]float64{}
+	total := 0.0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scriptTables {
+			if unicode.Is(s.table, r) {
+				w := scriptWeights[s.name]
+				if w == 0 {
+					w = 1
+				}
+				weights[s.name] += w
+				total += w
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return Result{Lang: Undetermined}
+	}
+
+	// 日文混用汉字和假名，有一定比例的假名即判断为日文
+	if kana := weights["Kana"]; kana > 0 && kana >= 0.1*(kana+weights["Han"]) {
+		weights["Kana"] += weights["Han"]
+		delete(weights, "Han")
+	}
+
+	script, best := "", 0.0
+	for _, s := range scriptTables {
+		if s.name == "Latin" {
+			continue
+		}
+		if w := weights[s.name]; w > best {
+			script, best = s.name, w
+		}
+	}
+	if script == "" || best/total < minForeignShare {
+		return detectLatin(text, weights["Latin"]/total)
+	}
+
+	share := best / total
+	switch script {
+	case "Han":
+		return Result{Lang: "zh", Script: script, Confidence: share}
+	case "Kana":
+		return Result{Lang: "ja", Script: "Kana", Confidence: share}
+	case "Cyrillic":
+		return detectByMarkers(text, script, share, "ru", cyrillicMarkers)
+	case "Arabic":
+		return detectByMarkers(text, script, share, "ar", arabicMarkers)
+	default:
+		return Result{Lang: scriptLanguages[script], Script: script, Confidence: share}
+	}
+}
+
+// cyrillicMarkers 乌克兰语、白俄罗斯语特有的字母
+var cyrillicMarkers = map[rune]string{'і': "uk", 'ї': "uk", 'є': "uk", 'ґ': "uk", 'ў': "be"}
+
+// arabicMarkers 波斯语特有的字母
+var arabicMarkers = map[rune]string{'پ': "fa", 'چ': "fa", 'ژ': "fa", 'گ': "fa"}
+
+// detectByMarkers 同一文字系统的多种语言按特有字母区分，没有特有字母时为 fallback
+func detectByMarkers(text, script string, share float64, fallback string, markers map[rune]string) Result {
+	counts := map[string]int{}
+	for _, r := range strings.ToLower(text) {
+		if lang, ok := markers[r]; ok {
+			counts[lang]++
+		}
+	}
+	lang, best := fallback, 0
+	for l, n := range counts {
+		if n > best || (n == best && l < lang) {
+			lang, best = l, n
+		}
+	}
+	if best == 0 {
+		// 没有特有字母时无法排除其他语言，置信度打折
+		return Result{Lang: fallback, Script: script, Confidence: share * 0.8}
+	}
+	return Result{Lang: lang, Script: script, Confidence: share}
+}
+
+// detectLatin 拉丁字母文本：常用词每个计 1 分，字符三元组每个计 0.25 分，变音字母按使用它的语言数平分 1.5 分
+func detectLatin(text string, share float64) Result {
+	scores := make(map[string]float64, len(latinLanguages))
+	lower := strings.ToLower(text)
+
+	words := strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' })
+	for _, word := range words {
+		for _, lang := range latinLanguages {
+			if lang.stopwords[word] {
+				scores[lang.code]++
+			}
+		}
+		runes := []rune(word)
+		for i := 0; i+3 <= len(runes); i++ {
+			tri := string(runes[i : i+3])
+			for _, lang := range latinLanguages {
+				if lang.trigrams[tri] {
+					scores[lang.code] += 0.25
+				}
+			}
+		}
+	}
+	for _, r := range lower {
+		if langs, ok := diacritics[r]; ok {
+			for _, code := range langs {
+				scores[code] += 1.5 / float64(len(langs))
+			}
+		}
+	}
+
+	best, sum := "", 0.0
+	for _, lang := range latinLanguages {
+		s := scores[lang.code]
+		sum += s
+		if best == "" || s > scores[best] {
+			best = lang.code
+		}
+	}
+	if sum == 0 {
+		// 没有任何特征（如单个专有名词），默认按英文处理，置信度低
+		return Result{Lang: "en", Script: "Latin", Confidence: 0.2 * share}
+	}
+
+	// 置信度 = 得分占比 × 证据充分程度（最高分达到 3 分视为充分）
+	evidence := scores[best] / 3
+	if evidence > 1 {
+		evidence = 1
+	}
+	confidence := scores[best] / sum * (0.5 + 0.5*evidence) * share
+	return Result{Lang: best, Script: "Latin", Confidence: confidence}
+}
+
+func round2(f float64) float64 {
+	return float64(int(f*100+0.5)) / 100
+}
@@ -0,0 +1,52 @@
+package langdetect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"一只可爱的小猫", "zh"},
+		{"かわいい猫のイラスト", "ja"},
+		{"귀여운 고양이", "ko"},
+		{"милый котёнок", "ru"},
+		{"a cute cat sitting on a sofa", "en"},
+		{"un chat mignon sur le canapé", "fr"},
+		{"12345 !!!", Undetermined},
+		// 少量中文不改变主要语言，由 ForeignScripts 判断是否需要翻译
+		{"a cute cartoon cat holding a sign that says 欢迎", "en"},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text).Lang; got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestForeignScripts(t *testing.T) {
+	tests := []struct {
+		text      string
+		languages []string
+		want      []string
+	}{
+		{"a cute cartoon cat holding a sign that says 欢迎", []string{"en"}, []string{"Han"}},
+		{"logo for 小米 coffee shop", []string{"en"}, []string{"Han"}},
+		{"logo for 小米 coffee shop", []string{"en", "zh"}, nil},
+		{"logo for 小米 coffee shop", []string{"*"}, nil},
+		{"a cute cat on a café terrace", []string{"en"}, nil},
+		{"a poster saying Привет and こんにちは", []string{"en"}, []string{"Cyrillic", "Kana"}},
+		{"東京の猫", []string{"ja"}, nil},
+		{"東京の猫", []string{"zh"}, []string{"Kana"}},
+		{"a cat", []string{"sv"}, nil}, // 未收录的语言按拉丁字母处理
+		{"2024 ©", []string{"en"}, nil},
+	}
+	for _, tt := range tests {
+		if got := ForeignScripts(tt.text, tt.languages...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ForeignScripts(%q, %v) = %v, want %v", tt.text, tt.languages, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package langdetect
+
+import "strings"
+
+// latinLanguage 拉丁字母语言的特征：常用词和高频字符三元组
+type latinLanguage struct {
+	code      string
+	stopwords map[string]bool
+	trigrams  map[string]bool
+}
+
+// latinLanguages 按优先级排列，得分相同时靠前的语言胜出
+var latinLanguages = []latinLanguage{
+	newLatin("en",
+		"the a an and of in on with for to is are this that at by from it its as be or over under into some very",
+		"the and ing ion tio ent her for tha nth int ere ter hat his con res ver all ons nce men ith ted ers pro thi wit are ess not ive was ect rea com eve per oun our igh ght ful"),
+	newLatin("fr",
+		"le la les un une des du de et en dans sur avec pour est sont ce cette au aux qui que sous très petit petite",
+		"les ent que ion des ait est tio ant ous par men eur con res lle our une pou dan ans ell qui sur ien ais ire pas tre eme ons oir eau aux ett ieu"),
+	newLatin("de",
+		"der die das ein eine einer einem und mit auf im in ist sind den dem des zu von für unter über sehr nicht klein kleine",
+		"ein ich der sch die und cht den end gen ine che ung nde ter ben ver eit ste hen mit auf ere ier lic nen ang ach cha ges rei uch ert bei ist das enn"),
+	newLatin("es",
+		"el la los las un una unos unas y de del en con por para es son que sobre muy al pequeño pequeña",
+		"que ent ade los ion con del las est nte ado par cio aci por res era una sta com ien mos ara ero tra nto ene dad pre osa ida ndo"),
+	newLatin("it",
+		"il lo la gli le un una uno e di del della dei delle in con per su sul sulla che sono sotto molto nel nella piccolo piccola",
+		"che ell ent con del per ion are ato lla ere ono nte zio azi ess tto gli ant sta ica ame non tta ali ett olo ita ore ggi"),
+	newLatin("pt",
+		"o a os as um uma uns umas e de do da dos das em no na com por para é são que sobre muito pequeno pequena",
+		"que ent ade com est par nte men dos das uma ara ado ros ida ndo ais sta pre ões ção açã ito nho lha eir inh"),
+	newLatin("nl",
+		"de het een en van in op met voor is zijn dat die onder over zeer niet klein kleine",
+		"een van het aar oor ijk sch ver ing ede ter gen den and erd nde oet ten ijn lij ond eer ste cht ach wor eid aan"),
+	newLatin("pl",
+		"i w z na do się jest nie że to od pod nad oraz dla przez bardzo mały mała",
+		"nie prz ych owa ani sta rze ego cze szy kie iej wie ski dzi jak pod ach ymi ają owi yst zen ści"),
+	newLatin("tr",
+		"ve bir bu ile için da de çok olan gibi altında üzerinde küçük",
+		"lar ler bir ini eri nda ara ası rin yor bil içi len nde dır lan ile eki ard aya ama kal esi mak mek"),
+	newLatin("vi",
+		"và của một các những là trong trên với cho không có rất con",
+		"ong ngu anh inh ang ươn ười của các khô hôn ông ước"),
+	newLatin("id",
+		"dan yang di ke dari dengan untuk ini itu adalah sebuah seekor sangat pada kecil",
+		"ang kan nya ada eng ber men ter yan dan gan ari per aka ala ata aan uka ung era sia ela ena"),
+}
+
+func newLatin(code, stopwords, trigrams string) latinLanguage {
+	lang := latinLanguage{code: code, stopwords: map[string]bool{}, trigrams: map[string]bool{}}
+	for _, w := range strings.Fields(stopwords) {
+		lang.stopwords[w] = true
+	}
+	for _, t := range strings.Fields(trigrams) {
+		lang.trigrams[t] = true
+	}
+	return lang
+}
+
+// diacritics 变音字母及使用它们的语言
+var diacritics = map[rune][]string{
+	'ñ': {"es"},
+	'ç': {"fr", "pt", "tr"},
+	'ã': {"pt", "vi"}, 'õ': {"pt"},
+	'ß': {"de"},
+	'ä': {"de"}, 'ö': {"de", "tr"}, 'ü': {"de", "tr"},
+	'ğ': {"tr"}, 'ş': {"tr"}, 'ı': {"tr"},
+	'ł': {"pl"}, 'ą': {"pl"}, 'ę': {"pl"}, 'ś': {"pl"}, 'ź': {"pl"}, 'ż': {"pl"}, 'ć': {"pl"}, 'ń': {"pl"},
+	'à': {"fr", "it", "vi"}, 'è': {"fr", "it", "vi"}, 'ù': {"fr", "it", "vi"},
+	'â': {"fr", "pt", "vi"}, 'ê': {"fr", "pt", "vi"}, 'î': {"fr"}, 'ô': {"fr", "pt", "vi"}, 'û': {"fr"},
+	'ë': {"fr", "nl"}, 'ï': {"fr", "nl"}, 'œ': {"fr"},
+	'é': {"fr", "es", "pt", "it", "vi"}, 'á': {"es", "pt", "vi"}, 'í': {"es", "pt", "vi"},
+	'ó': {"es", "pt", "pl", "vi"}, 'ú': {"es", "pt", "vi"}, 'ì': {"it", "vi"}, 'ò': {"it", "vi"},
+	'đ': {"vi"}, 'ơ': {"vi"}, 'ư': {"vi"}, 'ạ': {"vi"}, 'ả': {"vi"}, 'ấ': {"vi"}, 'ầ': {"vi"}, 'ẩ': {"vi"},
+	'ậ': {"vi"}, 'ắ': {"vi"}, 'ằ': {"vi"}, 'ẹ': {"vi"}, 'ẻ': {"vi"}, 'ế': {"vi"}, 'ề': {"vi"}, 'ể': {"vi"},
+	'ệ': {"vi"}, 'ỉ': {"vi"}, 'ị': {"vi"}, 'ọ': {"vi"}, 'ỏ': {"vi"}, 'ố': {"vi"}, 'ồ': {"vi"}, 'ổ': {"vi"},
+	'ộ': {"vi"}, 'ớ': {"vi"}, 'ờ': {"vi"}, 'ở': {"vi"}, 'ợ': {"vi"}, 'ụ': {"vi"}, 'ủ': {"vi"}, 'ứ': {"vi"},
+	'ừ': {"vi"}, 'ử': {"vi"}, 'ữ': {"vi"}, 'ự': {"vi"}, 'ỳ': {"vi"}, 'ỷ': {"vi"}, 'ỹ': {"vi"}, 'ỵ': {"vi"},
+}
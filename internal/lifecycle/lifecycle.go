@@ -0,0 +1,73 @@
+// Package lifecycle 管理服务的就绪/排空状态和退出时的清理钩子
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ready    atomic.Bool
+	draining atomic.Bool
+
+	hooksMu sync.Mutex
+	hooks   []hook
+)
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// SetReady 设置就绪状态，就绪后才接收流量
+func SetReady(v bool) {
+	ready.Store(v)
+}
+
+// IsReady 服务是否就绪且未进入排空阶段
+func IsReady() bool {
+	return ready.Load() && !draining.Load()
+}
+
+// BeginDrain 进入排空阶段：就绪状态置为 false，不再接受新的生成任务
+func BeginDrain() {
+	ready.Store(false)
+	draining.Store(true)
+}
+
+// IsDraining 是否处于排空阶段
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// OnShutdown 注册退出钩子，退出时按注册的逆序执行
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook{name: name, fn: fn})
+}
+
+// RunShutdownHooks 逆序执行全部退出钩子，单个钩子失败不影响其他钩子
+func RunShutdownHooks(ctx context.Context) error {
+	hooksMu.Lock()
+	pending := make([]hook, len(hooks))
+	copy(pending, hooks)
+	hooks = nil
+	hooksMu.Unlock()
+
+	var errs []error
+	for i := len(pending) - 1; i >= 0; i-- {
+		h := pending[i]
+		if err := h.fn(ctx); err != nil {
+			slog.Error("shutdown hook failed", "component", "lifecycle", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		slog.Debug("shutdown hook completed", "component", "lifecycle", "hook", h.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,155 @@
+// Package logging 基于 log/slog 的结构化日志：级别过滤、JSON/文本输出、
+// 文件轮转、请求级上下文字段以及敏感信息脱敏
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"svg-generator/internal/config"
+)
+
+// Init 根据配置创建日志器并设置为 slog 和标准库 log 的默认输出，
+// 返回的 io.Closer 用于在退出时关闭日志文件
+func Init(cfg config.LoggingConfig) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, closer, err := openOutput(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levelVar.Set(level)
+	activeRedactor.redactPrompts.Store(cfg.RedactPrompts)
+	opts := &slog.HandlerOptions{
+		Level:       levelVar,
+		AddSource:   cfg.EnableErrorStack && level <= slog.LevelDebug,
+		ReplaceAttr: activeRedactor.replaceAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		return nil, nil, fmt.Errorf("invalid logging.format: %q", cfg.Format)
+	}
+
+	logger := slog.New(&contextHandler{Handler: handler})
+	slog.SetDefault(logger)
+	// 仍使用标准库 log 的代码按 info 级别输出到同一处理器
+	log.SetFlags(0)
+	log.SetOutput(slog.NewLogLogger(logger.Handler(), slog.LevelInfo).Writer())
+
+	return logger, closer, nil
+}
+
+// levelVar 当前日志级别，配置重新加载时原地更新
+var levelVar = new(slog.LevelVar)
+
+// Apply 应用可在运行时变更的日志配置（级别和提示词脱敏），输出目标和格式需重启生效
+func Apply(cfg config.LoggingConfig) error {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	levelVar.Set(level)
+	activeRedactor.redactPrompts.Store(cfg.RedactPrompts)
+	return nil
+}
+
+// ParseLevel 解析日志级别
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid logging.level: %q", level)
+	}
+}
+
+// openOutput 打开日志输出目标：stdout、stderr 或轮转文件
+func openOutput(cfg config.LoggingConfig) (io.Writer, io.Closer, error) {
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		return os.Stdout, nopCloser{}, nil
+	case "stderr":
+		return os.Stderr, nopCloser{}, nil
+	}
+
+	// "file" 使用 file_path，其他值视为文件路径
+	path := cfg.FilePath
+	if strings.ToLower(cfg.Output) != "file" {
+		path = cfg.Output
+	}
+	if path == "" {
+		path = "logs/app.log"
+	}
+
+	w, err := NewRotatingFile(path, cfg.MaxSizeMB, cfg.MaxBackups, cfg.MaxAgeDays)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, w, nil
+}
+
+// nopCloser 标准输出无需关闭
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Component 返回带组件名字段的日志器
+func Component(name string) *slog.Logger {
+	return slog.Default().With("component", name)
+}
+
+// ========== 请求级上下文字段 ==========
+
+type attrsKey struct{}
+
+// With 返回附加了日志字段的上下文，之后使用该上下文的日志都会带上这些字段
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// contextHandler 在输出前附加上下文中的请求级字段
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// redactedValue 脱敏后的占位符
+const redactedValue = "[REDACTED]"
+
+// secretKeyMarkers 字段名包含这些片段时整体脱敏
+var secretKeyMarkers = []string{"api_key", "apikey", "authorization", "secret", "password", "token"}
+
+// promptKeys 提示词相关字段，开启 redact_prompts 时脱敏
+var promptKeys = map[string]bool{
+	"prompt":            true,
+	"negative_prompt":   true,
+	"original_prompt":   true,
+	"translated_prompt": true,
+	"enhanced_prompt":   true,
+	"text":              true,
+	"translated":        true,
+	"model_output":      true, // 翻译、增强模型的原始输出，可能包含译文或复述提示词的思考过程
+}
+
+// secretValuePatterns 出现在任意字符串值（如错误信息）中的凭据
+var secretValuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-~+/=]+`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{8,}`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret)=)[^&\s"]+`),
+}
+
+// redactor 日志字段脱敏规则
+type redactor struct {
+	redactPrompts atomic.Bool
+}
+
+// activeRedactor 全局脱敏规则，配置重新加载时原地更新
+var activeRedactor = &redactor{}
+
+// replaceAttr 实现 slog.HandlerOptions.ReplaceAttr
+func (r *redactor) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+
+	if a.Value.Kind() == slog.KindString || a.Value.Kind() == slog.KindAny {
+		if isSecretKey(key) && a.Value.Kind() == slog.KindString {
+			return slog.String(a.Key, redactedValue)
+		}
+		if r.redactPrompts.Load() && promptKeys[key] {
+			s := a.Value.String()
+			return slog.String(a.Key, fmt.Sprintf("%s len=%d", redactedValue, utf8.RuneCountInString(s)))
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactSecrets(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactSecrets(err.Error()))
+		}
+	}
+	return a
+}
+
+// isSecretKey 字段名是否表示凭据；token 计数类字段（如 input_tokens）不视为凭据
+func isSecretKey(key string) bool {
+	if strings.HasSuffix(key, "_tokens") || key == "tokens" {
+		return false
+	}
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactSecrets 替换字符串中的 Bearer 令牌和 API Key
+func RedactSecrets(s string) string {
+	for _, re := range secretValuePatterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+redactedValue)
+		} else {
+			s = re.ReplaceAllString(s, redactedValue)
+		}
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 轮转默认值
+const (
+	defaultMaxSizeMB  = 100
+	defaultMaxBackups = 7
+)
+
+// KeepAllBackups 作为 maxBackups 传入时不清理任何备份文件，用于审计日志这类不能丢失的文件
+const KeepAllBackups = -1
+
+// RotatingFile 按大小轮转的日志文件，轮转后按数量和天数清理旧文件
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile 打开日志文件，maxSizeMB/maxBackups 为0时使用默认值，maxAgeDays 为0表示不按天数清理；
+// maxBackups 为 KeepAllBackups 时忽略 maxAgeDays，备份文件永不删除
+func NewRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*RotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxSizeMB
+	}
+	if maxBackups == 0 {
+		maxBackups = defaultMaxBackups
+	}
+	if maxBackups == KeepAllBackups {
+		maxAgeDays = 0
+	}
+
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write 实现 io.Writer，写入前超过大小上限时轮转
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size+int64(len(p)) > r.maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync 将缓冲写入磁盘
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close 关闭文件
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("create log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate 将当前文件重命名为带时间戳的备份并重新打开
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	backup := fmt.Sprintf("%s-%s%s", base, time.Now().Format("20060102T150405.000"), ext)
+	// 同一毫秒内再次轮转时等到下一毫秒，避免覆盖已有的备份
+	for {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+		backup = fmt.Sprintf("%s-%s%s", base, time.Now().Format("20060102T150405.000"), ext)
+	}
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.cleanup()
+	return nil
+}
+
+// cleanup 删除超过数量或天数的备份文件
+func (r *RotatingFile) cleanup() {
+	if r.maxBackups == KeepAllBackups {
+		return
+	}
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(filepath.Base(r.path), ext)
+	pattern := filepath.Join(filepath.Dir(r.path), base+"-*"+ext)
+
+	backups, err := filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+	// 时间戳格式保证字典序即时间序，最新的在后
+	sort.Strings(backups)
+
+	cutoff := time.Time{}
+	if r.maxAge > 0 {
+		cutoff = time.Now().Add(-r.maxAge)
+	}
+
+	for i, backup := range backups {
+		expired := len(backups)-i > r.maxBackups
+		if !expired && !cutoff.IsZero() {
+			if info, err := os.Stat(backup); err == nil && info.ModTime().Before(cutoff) {
+				expired = true
+			}
+		}
+		if expired {
+			_ = os.Remove(backup)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package metrics 提供无外部依赖的 Prometheus 指标采集与导出
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 常用的直方图桶
+var (
+	// latencyBuckets 请求耗时（秒），覆盖从毫秒级到上游生成的分钟级
+	latencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 45, 60, 90, 120}
+	// sizeBuckets SVG 字节数
+	sizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+)
+
+// Default 默认指标注册表
+var Default = NewRegistry()
+
+// 服务指标
+var (
+	// HTTPRequests HTTP 请求总数
+	HTTPRequests = Default.NewCounterVec("svggen_http_requests_total",
+		"Total HTTP requests by route, provider and status code.",
+		"route", "provider", "status")
+	// HTTPRequestDuration HTTP 请求耗时
+	HTTPRequestDuration = Default.NewHistogramVec("svggen_http_request_duration_seconds",
+		"HTTP request latency by route, provider and status code.",
+		latencyBuckets, "route", "provider", "status")
+
+	// UpstreamDuration 上游 Provider 调用耗时
+	UpstreamDuration = Default.NewHistogramVec("svggen_upstream_request_duration_seconds",
+		"Upstream provider call latency.",
+		latencyBuckets, "provider", "outcome")
+	// UpstreamErrors 上游 Provider 调用错误数
+	UpstreamErrors = Default.NewCounterVec("svggen_upstream_errors_total",
+		"Upstream provider errors by error class.",
+		"provider", "class")
+
+	// UpstreamKeyRequests 按 API Key 统计的上游调用次数，key 为 Key 的指纹
+	UpstreamKeyRequests = Default.NewCounterVec("svggen_upstream_key_requests_total",
+		"Upstream provider calls by API key fingerprint and outcome (success or error class).",
+		"provider", "key", "outcome")
+
+	// TranslationDuration 翻译耗时
+	TranslationDuration = Default.NewHistogramVec("svggen_translation_duration_seconds",
+		"Prompt translation latency.",
+		latencyBuckets, "outcome")
+	// Translations 翻译结果计数
+	Translations = Default.NewCounterVec("svggen_translations_total",
+		"Prompt translations by outcome (translated, unchanged, skipped, failed).",
+		"outcome")
+
+	// SVGBytes 返回给客户端的 SVG 大小
+	SVGBytes = Default.NewHistogramVec("svggen_svg_bytes",
+		"Size in bytes of SVG documents returned to clients.",
+		sizeBuckets, "provider")
+
+	// ClaudeTokens Claude token 用量
+	ClaudeTokens = Default.NewCounterVec("svggen_claude_tokens_total",
+		"Claude token usage by token type (input, output).",
+		"type")
+
+	// UsageCost 按 usage.pricing 计算的累计成本
+	UsageCost = Default.NewCounterVec("svggen_usage_cost_total",
+		"Accumulated generation cost computed from usage.pricing, by provider and currency.",
+		"provider", "currency")
+
+	// BudgetActions 预算触发的告警、拒绝和降级次数
+	BudgetActions = Default.NewCounterVec("svggen_budget_actions_total",
+		"Budget enforcement actions (soft_alert, hard_alert, reject, downgrade) by scope (global, tenant).",
+		"scope", "action")
+
+	// ModerationDecisions 内容审核结果
+	ModerationDecisions = Default.NewCounterVec("svggen_moderation_decisions_total",
+		"Content moderation decisions by stage (input, output), action (allow, flag, block) and level.",
+		"stage", "action", "level")
+
+	// TranslatorAttempts 翻译器链中各翻译器的调用结果
+	TranslatorAttempts = Default.NewCounterVec("svggen_translator_attempts_total",
+		"Translation attempts by translator name and outcome (success, error).",
+		"translator", "outcome")
+
+	// TranslationRejected 未通过校验的译文，按原因统计
+	TranslationRejected = Default.NewCounterVec("svggen_translation_rejected_total",
+		"Translations rejected after post-processing by reason (empty, truncated, not_english, wrong_language, untranslated).",
+		"reason")
+
+	// PromptEnhancements 提示词增强结果计数
+	PromptEnhancements = Default.NewCounterVec("svggen_prompt_enhancements_total",
+		"Prompt enhancements by level and outcome (enhanced, unchanged, skipped, empty, truncated, too_long, wrong_language, failed).",
+		"level", "outcome")
+	// PromptEnhancementDuration 提示词增强耗时
+	PromptEnhancementDuration = Default.NewHistogramVec("svggen_prompt_enhancement_duration_seconds",
+		"Prompt enhancement latency.",
+		latencyBuckets, "level")
+
+	// TranslationCache 翻译缓存查询结果
+	TranslationCache = Default.NewCounterVec("svggen_translation_cache_total",
+		"Translation cache lookups by result (hit, miss, error).",
+		"result")
+
+	// PIIDetections 提示词中检测到的个人信息
+	PIIDetections = Default.NewCounterVec("svggen_pii_detections_total",
+		"Personal data detected in prompts by type and action taken (mask, reject).",
+		"type", "action")
+
+	// GenerationsInFlight 正在进行的生成任务数
+	GenerationsInFlight = Default.NewGaugeVec("svggen_generations_in_flight",
+		"Number of image generations currently in progress.",
+		"provider")
+)
+
+// Handler 返回 /metrics 端点处理器
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = Default.WriteTo(w)
+	}
+}
+
+// InstrumentHandler 记录路由的请求数和耗时
+func InstrumentHandler(route, provider string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(rec, r)
+
+		status := strconv.Itoa(rec.status)
+		HTTPRequests.Inc(route, provider, status)
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, provider, status)
+	}
+}
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap 支持 http.ResponseController 访问底层 ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
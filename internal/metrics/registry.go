@@ -0,0 +1,346 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// labelSeparator 组合标签值作为map键时使用的分隔符
+const labelSeparator = "\xff"
+
+// collector 可导出为 Prometheus 文本格式的指标
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo 以 Prometheus 文本格式 (version 0.0.4) 输出全部指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ========== Counter ==========
+
+// CounterVec 带标签的单调递增计数器
+type CounterVec struct {
+	metricName string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{metricName: name, help: help, labelNames: labelNames, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 增加指定值，负数会被忽略
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	key := labelKey(c.labelNames, labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.metricName, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		writeSample(w, c.metricName, c.labelNames, splitKey(key), "", "", c.values[key])
+	}
+}
+
+// ========== Gauge ==========
+
+// GaugeVec 带标签的可增可减指标
+type GaugeVec struct {
+	metricName string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec 创建并注册仪表盘指标
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{metricName: name, help: help, labelNames: labelNames, values: make(map[string]float64)}
+	r.register(g)
+	return g
+}
+
+// Inc 加一
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec 减一
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Add 增加指定值
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	key := labelKey(g.labelNames, labelValues)
+	g.mu.Lock()
+	g.values[key] += v
+	g.mu.Unlock()
+}
+
+// Set 设置为指定值
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := labelKey(g.labelNames, labelValues)
+	g.mu.Lock()
+	g.values[key] = v
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) name() string { return g.metricName }
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	writeHeader(w, g.metricName, g.help, "gauge")
+	for _, key := range sortedKeys(g.values) {
+		writeSample(w, g.metricName, g.labelNames, splitKey(key), "", "", g.values[key])
+	}
+}
+
+// ========== Histogram ==========
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	metricName string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // 与 buckets 一一对应，非累计
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 需按升序排列
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(h.labelNames, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) name() string { return h.metricName }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		values := splitKey(key)
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.metricName+"_bucket", h.labelNames, values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labelNames, values, "le", "+Inf", float64(s.count))
+		writeSample(w, h.metricName+"_sum", h.labelNames, values, "", "", s.sum)
+		writeSample(w, h.metricName+"_count", h.labelNames, values, "", "", float64(s.count))
+	}
+}
+
+// ========== 输出辅助函数 ==========
+
+// labelKey 将标签值组合为map键，缺失的标签值补空字符串
+func labelKey(labelNames, labelValues []string) string {
+	if len(labelValues) != len(labelNames) {
+		values := make([]string, len(labelNames))
+		copy(values, labelValues)
+		labelValues = values
+	}
+	return strings.Join(labelValues, labelSeparator)
+}
+
+func splitKey(key string) []string {
+	return strings.Split(key, labelSeparator)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(w *bufio.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// writeSample 输出一行样本，extraName/extraValue 用于直方图的 le 标签
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+
+	pairs := 0
+	for i, labelName := range labelNames {
+		if pairs == 0 {
+			w.WriteByte('{')
+		} else {
+			w.WriteByte(',')
+		}
+		w.WriteString(labelName)
+		w.WriteString(`="`)
+		w.WriteString(escapeLabelValue(labelValues[i]))
+		w.WriteByte('"')
+		pairs++
+	}
+	if extraName != "" {
+		if pairs == 0 {
+			w.WriteByte('{')
+		} else {
+			w.WriteByte(',')
+		}
+		w.WriteString(extraName)
+		w.WriteString(`="`)
+		w.WriteString(extraValue)
+		w.WriteByte('"')
+		pairs++
+	}
+	if pairs > 0 {
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
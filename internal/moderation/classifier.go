@@ -0,0 +1,52 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"svg-generator/internal/config"
+	"svg-generator/pkg/utils"
+)
+
+// classifierPrompt 分类器的系统提示词，要求只返回 JSON
+const classifierPrompt = `You are a content moderation classifier for an image generation service.
+Decide whether the user's image prompt requests sexual content, content involving minors in a sexual or violent context, graphic violence or gore, hate symbols or harassment, self-harm, or instructions for weapons or illegal activity.
+Respond with JSON only, no explanation: {"flagged": true|false, "categories": ["sexual", "minors", "violence", "hate", "self_harm", "illegal"]}`
+
+// classifierVerdict 分类器返回的判定
+type classifierVerdict struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+}
+
+// classify 调用 OpenAI 兼容接口判定提示词，返回是否违规及类别
+func classify(ctx context.Context, cfg config.ModerationClassifierConfig, text string) (bool, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.GetTimeout())
+	defer cancel()
+
+	client := utils.NewChatClient(cfg.ServiceURL, cfg.APIKey, cfg.GetModel())
+	content, _, err := client.Complete(ctx, utils.ChatRequest{
+		Messages: []utils.ChatMessage{
+			{Role: "system", Content: classifierPrompt},
+			{Role: "user", Content: text},
+		},
+		MaxTokens:   100,
+		Temperature: 0,
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("classifier: %w", err)
+	}
+
+	// 模型偶尔会用代码块包裹 JSON
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(strings.TrimPrefix(content, "```json"), "```")
+	content = strings.TrimSpace(strings.TrimSuffix(content, "```"))
+
+	var verdict classifierVerdict
+	if err := json.Unmarshal([]byte(content), &verdict); err != nil {
+		return false, nil, fmt.Errorf("unexpected classifier output: %w", err)
+	}
+	return verdict.Flagged, verdict.Categories, nil
+}
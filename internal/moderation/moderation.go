@@ -0,0 +1,207 @@
+// Package moderation 内容审核：生成前按屏蔽词、正则规则和可选的 LLM 分类器检查提示词，
+// 生成后根据上游返回的 NSFW 标记决定是否放行结果
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/types"
+)
+
+// 审核级别
+const (
+	LevelOff      = "off"
+	LevelRelaxed  = "relaxed"
+	LevelStandard = "standard"
+	LevelStrict   = "strict"
+)
+
+// 审核结果
+const (
+	ActionAllow = "allow"
+	ActionFlag  = "flag"
+	ActionBlock = "block"
+)
+
+// 审核阶段
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+)
+
+// levelRank 级别由低到高的顺序
+var levelRank = map[string]int{LevelOff: 0, LevelRelaxed: 1, LevelStandard: 2, LevelStrict: 3}
+
+// atLeast 判断 level 是否不低于 min
+func atLeast(level, min string) bool {
+	return levelRank[level] >= levelRank[min]
+}
+
+// compiledRule 预编译的正则规则
+type compiledRule struct {
+	re       *regexp.Regexp
+	category string
+	minLevel string
+}
+
+// policy 由当前配置编译的审核策略
+type policy struct {
+	blocklist []string
+	rules     []compiledRule
+}
+
+// currentPolicy 当前配置对应的策略，配置重新加载后按需重建
+var currentPolicy = config.NewDerived(func(cfg *config.Config) *policy {
+	p := &policy{}
+	for _, word := range cfg.Moderation.Blocklist {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			p.blocklist = append(p.blocklist, word)
+		}
+	}
+	for i, rule := range cfg.Moderation.Rules {
+		if re := config.CompileValidated(fmt.Sprintf("moderation.rules[%d].pattern", i), rule.Pattern); re != nil {
+			p.rules = append(p.rules, compiledRule{re: re, category: rule.Category, minLevel: rule.GetMinLevel()})
+		}
+	}
+	return p
+})
+
+// CheckInput 生成前审核调用方提交的原文（提示词和反向提示词），应在翻译、增强等处理之前调用。
+// 屏蔽词和规则命中时拒绝；达到分类器级别时再调用分类器。
+// 拒绝时记录审核指标；放行时由随后的 RecheckInput 记录，调用方必须把结果交给 RecheckInput
+func CheckInput(ctx context.Context, tenant string, texts ...string) *types.ModerationResult {
+	cfg := config.Get()
+	level := cfg.Moderation.TenantLevel(tenant)
+	if level == LevelOff {
+		return nil
+	}
+	result := &types.ModerationResult{Level: level, Action: ActionAllow}
+	checkTexts(ctx, cfg, result, texts)
+	if result.Action == ActionBlock {
+		record(result, StageInput)
+	}
+	return result
+}
+
+// RecheckInput 审核由原文派生、实际发往上游的文本（译文、增强后的提示词），沿用 CheckInput 的级别并合并结果。
+// input 已拒绝或没有需要检查的文本时不再检查，返回最终的生成前审核结果并记录指标
+func RecheckInput(ctx context.Context, input *types.ModerationResult, texts ...string) *types.ModerationResult {
+	if input == nil {
+		return nil
+	}
+	result := *input
+	if result.Action != ActionBlock {
+		checkTexts(ctx, config.Get(), &result, texts)
+	}
+	if input.Action != ActionBlock {
+		record(&result, StageInput)
+	}
+	return &result
+}
+
+// checkTexts 按 result.Level 检查非空的 texts，命中时把结果标记为拒绝
+func checkTexts(ctx context.Context, cfg *config.Config, result *types.ModerationResult, texts []string) {
+	nonEmpty := texts[:0:0]
+	for _, t := range texts {
+		if t != "" {
+			nonEmpty = append(nonEmpty, t)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return
+	}
+	level := result.Level
+
+	text := strings.Join(nonEmpty, "\n")
+	lower := strings.ToLower(text)
+	p := currentPolicy.Get(cfg)
+	for _, word := range p.blocklist {
+		if strings.Contains(lower, word) {
+			block(result, StageInput, "blocklist", "prompt contains a blocked term")
+			break
+		}
+	}
+	for _, rule := range p.rules {
+		if atLeast(level, rule.minLevel) && rule.re.MatchString(text) {
+			block(result, StageInput, rule.category, "prompt matches moderation rule: "+rule.category)
+		}
+	}
+
+	classifierCfg := cfg.Moderation.Classifier
+	if result.Action != ActionBlock && classifierCfg.Enabled && atLeast(level, classifierCfg.GetMinLevel()) {
+		flagged, categories, err := classify(ctx, classifierCfg, text)
+		switch {
+		case err != nil && classifierCfg.FailOpen:
+			logging.Component("moderation").WarnContext(ctx, "moderation classifier unavailable, allowing request", "error", err)
+			result.Reasons = append(result.Reasons, "classifier unavailable, not checked")
+		case err != nil:
+			logging.Component("moderation").WarnContext(ctx, "moderation classifier unavailable, blocking request", "error", err)
+			block(result, StageInput, "classifier_unavailable", "moderation classifier unavailable")
+		case flagged:
+			if len(categories) == 0 {
+				categories = []string{"classifier"}
+			}
+			for _, category := range categories {
+				block(result, StageInput, category, "prompt flagged by classifier: "+category)
+			}
+		}
+	}
+}
+
+// CheckOutput 生成后根据上游 NSFW 标记审核结果：relaxed 只拒绝 NSFW 内容、标记 NSFW 文本，
+// standard 及以上两者都拒绝。input 为生成前的审核结果，返回合并后的结果
+func CheckOutput(img *types.ImageResponse, input *types.ModerationResult) *types.ModerationResult {
+	if input == nil {
+		return nil
+	}
+	result := *input
+	if img.NSFWContentDetected {
+		block(&result, StageOutput, "nsfw_content", "provider detected NSFW content in the generated image")
+	}
+	if img.NSFWTextDetected {
+		if atLeast(result.Level, LevelStandard) {
+			block(&result, StageOutput, "nsfw_text", "provider detected NSFW text in the prompt")
+		} else if result.Action == ActionAllow {
+			result.Action = ActionFlag
+			result.Stage = StageOutput
+			addUnique(&result.Categories, "nsfw_text")
+			result.Reasons = append(result.Reasons, "provider detected NSFW text in the prompt")
+		}
+	}
+	if img.NSFWContentDetected || img.NSFWTextDetected {
+		record(&result, StageOutput)
+	}
+	return &result
+}
+
+// block 将结果标记为拒绝，保留第一个触发拒绝的阶段
+func block(result *types.ModerationResult, stage, category, reason string) {
+	if result.Action != ActionBlock {
+		result.Action = ActionBlock
+		result.Stage = stage
+	}
+	addUnique(&result.Categories, category)
+	result.Reasons = append(result.Reasons, reason)
+}
+
+func addUnique(list *[]string, value string) {
+	for _, v := range *list {
+		if v == value {
+			return
+		}
+	}
+	*list = append(*list, value)
+	sort.Strings(*list)
+}
+
+// record 记录审核指标
+func record(result *types.ModerationResult, stage string) {
+	metrics.ModerationDecisions.Inc(stage, result.Action, result.Level)
+}
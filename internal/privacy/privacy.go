@@ -0,0 +1,203 @@
+// Package privacy 个人信息脱敏：在提示词发往翻译服务和上游 Provider 之前
+// 检测邮箱、电话、身份证号等，按租户策略掩码或拒绝
+package privacy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/metrics"
+)
+
+// 处理方式
+const (
+	ActionMask   = "mask"
+	ActionReject = "reject"
+)
+
+// detector 个人信息检测器；valid 用于校验位、上下文等正则无法表达的检查，参数为全文和匹配的起止位置
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(text string, start, end int) bool
+}
+
+// builtinDetectors 内置检测器，按顺序执行，先执行的检测器替换后的内容不会再被后面的检测器匹配：
+// 邮箱先于电话（邮箱的用户名可能是手机号），电话先于银行卡号（带区号的国际号码也是一串 13 位以上的数字）
+var builtinDetectors = []detector{
+	{name: "email", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{name: "phone", re: regexp.MustCompile(
+		`\+\d{1,3}[ -]?\(?\d{1,4}\)?(?:[ -]?\d{2,4}){2,4}\b` + // 国际格式
+			`|(?:\b86[ -]?)?\b1[3-9]\d[ -]?\d{4}[ -]?\d{4}\b` + // 中国大陆手机号
+			`|\b0\d{2,3}-\d{7,8}\b`)}, // 固定电话
+	{name: "cn_id", re: regexp.MustCompile(`\b\d{17}[\dXx]\b`), valid: validCNID},
+	{name: "credit_card", re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: validCard},
+	{name: "ip_address", re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`), valid: validIPv4},
+}
+
+// Result 脱敏结果
+type Result struct {
+	Action string
+	// Types 检测到的个人信息类型（不含具体内容）
+	Types []string
+	// StoreOriginal 租户是否允许审计日志保存脱敏前的原文
+	StoreOriginal bool
+}
+
+// redactor 由当前配置编译的检测器列表
+type redactor struct {
+	detectors []detector
+}
+
+// currentRedactor 当前配置对应的检测器，配置重新加载后按需重建
+var currentRedactor = config.NewDerived(func(cfg *config.Config) *redactor {
+	r := &redactor{}
+	enabled := make(map[string]bool, len(cfg.Privacy.Detectors))
+	for _, name := range cfg.Privacy.Detectors {
+		enabled[strings.ToLower(name)] = true
+	}
+	for _, d := range builtinDetectors {
+		if len(enabled) == 0 || enabled[d.name] {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+	for i, p := range cfg.Privacy.Patterns {
+		if re := config.CompileValidated(fmt.Sprintf("privacy.patterns[%d].pattern", i), p.Pattern); re != nil {
+			r.detectors = append(r.detectors, detector{name: p.Name, re: re})
+		}
+	}
+	return r
+})
+
+// Redact 检测 texts 中的个人信息。action 为 mask 时原地替换为 [TYPE] 占位符，
+// 为 reject 时不修改文本，由调用方拒绝请求。未开启或未检测到时返回 nil
+func Redact(tenant string, texts ...*string) *Result {
+	cfg := config.Get()
+	if !cfg.Privacy.Enabled {
+		return nil
+	}
+	action, storeOriginal := cfg.Privacy.TenantPolicy(tenant)
+	r := currentRedactor.Get(cfg)
+
+	found := map[string]bool{}
+	for _, text := range texts {
+		if text == nil || *text == "" {
+			continue
+		}
+		redacted := redact(r.detectors, *text, found)
+		if action == ActionMask {
+			*text = redacted
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	result := &Result{Action: action, StoreOriginal: storeOriginal}
+	for name := range found {
+		result.Types = append(result.Types, name)
+		metrics.PIIDetections.Inc(name, action)
+	}
+	sort.Strings(result.Types)
+	return result
+}
+
+// redact 依次执行检测器，把通过校验的匹配替换为 [TYPE] 占位符，命中的类型写入 found
+func redact(detectors []detector, text string, found map[string]bool) string {
+	for _, d := range detectors {
+		placeholder := "[" + strings.ToUpper(d.name) + "]"
+		var b strings.Builder
+		last := 0
+		for _, loc := range d.re.FindAllStringIndex(text, -1) {
+			if d.valid != nil && !d.valid(text, loc[0], loc[1]) {
+				continue
+			}
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(placeholder)
+			last = loc[1]
+			found[d.name] = true
+		}
+		if last > 0 {
+			b.WriteString(text[last:])
+			text = b.String()
+		}
+	}
+	return text
+}
+
+// validCNID 校验 18 位居民身份证号的校验码（GB 11643）
+func validCNID(text string, start, end int) bool {
+	id := text[start:end]
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	const codes = "10X98765432"
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(id[i]-'0') * weights[i]
+	}
+	return codes[sum%11] == strings.ToUpper(id[17:])[0]
+}
+
+// validCard 校验银行卡号：以 + 开头的数字串是国际电话号码，其余使用 Luhn 算法校验
+func validCard(text string, start, end int) bool {
+	if start > 0 && text[start-1] == '+' {
+		return false
+	}
+	return validLuhn(text[start:end])
+}
+
+// validLuhn 使用 Luhn 算法校验银行卡号
+func validLuhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// versionWords 紧跟版本号的词，其后形如 IPv4 地址的数字串视为版本号。
+// 中文词前面通常没有空格，按后缀匹配
+var (
+	versionWords   = []string{"version", "ver", "v", "release", "build"}
+	versionWordsZH = []string{"版本", "版本号"}
+)
+
+// validIPv4 排除形如 IPv4 地址的版本号：位于更长的点分数字串中（1.2.3.4.5、.1.2.3.4），
+// 或者前面是 version、v、版本等词
+func validIPv4(text string, start, end int) bool {
+	if start > 0 && text[start-1] == '.' {
+		return false
+	}
+	if end+1 < len(text) && text[end] == '.' && text[end+1] >= '0' && text[end+1] <= '9' {
+		return false
+	}
+	words := strings.Fields(text[:start])
+	if len(words) == 0 {
+		return true
+	}
+	prev := strings.ToLower(strings.TrimRight(words[len(words)-1], ":："))
+	for _, w := range versionWords {
+		if prev == w {
+			return false
+		}
+	}
+	for _, w := range versionWordsZH {
+		if strings.HasSuffix(prev, w) {
+			return false
+		}
+	}
+	return true
+}
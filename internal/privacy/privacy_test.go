@@ -0,0 +1,103 @@
+package privacy
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type redactCase struct {
+	text  string
+	want  string
+	types []string
+}
+
+func runRedact(t *testing.T, tests []redactCase) {
+	t.Helper()
+	for _, tt := range tests {
+		found := map[string]bool{}
+		got := redact(builtinDetectors, tt.text, found)
+		if got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		var types []string
+		for name := range found {
+			types = append(types, name)
+		}
+		sort.Strings(types)
+		if !reflect.DeepEqual(types, tt.types) {
+			t.Errorf("redact(%q) types = %v, want %v", tt.text, types, tt.types)
+		}
+	}
+}
+
+func TestRedactEmail(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"contact alice.smith+svg@example.co.uk today", "contact [EMAIL] today", []string{"email"}},
+		{"邮箱 13812345678@qq.com", "邮箱 [EMAIL]", []string{"email"}},
+		{"a logo with an @ sign", "a logo with an @ sign", nil},
+	})
+}
+
+func TestRedactPhone(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"call +86 138 1234 5678 now", "call [PHONE] now", []string{"phone"}},
+		{"call +1 415-555-0132", "call [PHONE]", []string{"phone"}},
+		{"+4915112345678", "[PHONE]", []string{"phone"}},
+		{"手机 13812345678", "手机 [PHONE]", []string{"phone"}},
+		{"手机 86 138-1234-5678", "手机 [PHONE]", []string{"phone"}},
+		{"电话 010-12345678", "电话 [PHONE]", []string{"phone"}},
+		{"12345678901", "12345678901", nil}, // 不是手机号段
+		{"a 1920x1080 poster", "a 1920x1080 poster", nil},
+	})
+}
+
+func TestRedactCNID(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"身份证 11010519491231002X", "身份证 [CN_ID]", []string{"cn_id"}},
+		{"id 130102199003071233", "id [CN_ID]", []string{"cn_id"}},
+		{"id 130102199003071234", "id 130102199003071234", nil}, // 校验码错误
+	})
+}
+
+func TestRedactCreditCard(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"card 4111 1111 1111 1111", "card [CREDIT_CARD]", []string{"credit_card"}},
+		{"card 4111-1111-1111-1111", "card [CREDIT_CARD]", []string{"credit_card"}},
+		{"card 4111111111111111", "card [CREDIT_CARD]", []string{"credit_card"}},
+		{"card 4111 1111 1111 1112", "card 4111 1111 1111 1112", nil}, // Luhn 校验失败
+		{"+4111111111111111", "[PHONE]", []string{"phone"}},           // 以 + 开头的是电话号码
+	})
+}
+
+func TestValidCardRejectsLeadingPlus(t *testing.T) {
+	text := "+4111111111111111"
+	if validCard(text, 1, len(text)) {
+		t.Errorf("validCard(%q) = true, want false", text)
+	}
+	if !validCard(text[1:], 0, len(text)-1) {
+		t.Errorf("validCard(%q) = false, want true", text[1:])
+	}
+}
+
+func TestRedactIPAddress(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"server at 192.168.1.10", "server at [IP_ADDRESS]", []string{"ip_address"}},
+		{"ip: 10.0.0.1.", "ip: [IP_ADDRESS].", []string{"ip_address"}},
+		{"version 1.2.3.4", "version 1.2.3.4", nil},
+		{"Version: 1.2.3.4 release notes", "Version: 1.2.3.4 release notes", nil},
+		{"build 10.0.19041.1", "build 10.0.19041.1", nil},
+		{"v1.2.3.4", "v1.2.3.4", nil},
+		{"1.2.3.4.5", "1.2.3.4.5", nil},
+		{"软件版本 1.2.3.4", "软件版本 1.2.3.4", nil},
+		{"256.1.1.1", "256.1.1.1", nil},
+	})
+}
+
+func TestRedactMixed(t *testing.T) {
+	runRedact(t, []redactCase{
+		{"email bob@example.com or call +86 138 1234 5678, card 4111 1111 1111 1111",
+			"email [EMAIL] or call [PHONE], card [CREDIT_CARD]", []string{"credit_card", "email", "phone"}},
+		{"a cute cat", "a cute cat", nil},
+	})
+}
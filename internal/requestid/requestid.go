@@ -0,0 +1,50 @@
+// Package requestid 请求ID的生成与上下文传递
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header 请求ID使用的HTTP头
+const Header = "X-Request-Id"
+
+// maxLength 接受客户端传入请求ID的最大长度
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext 返回携带请求ID的上下文
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 获取上下文中的请求ID，不存在时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Generate 生成32位十六进制请求ID
+func Generate() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// IsValid 校验客户端传入的请求ID：非空、长度受限，且只包含字母数字和 -_.:
+func IsValid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
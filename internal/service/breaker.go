@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"svg-generator/internal/types"
+)
+
+// ErrCircuitOpen Provider 处于熔断状态，请求未发往上游
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// circuitBreaker 连续失败计数熔断器：
+// 连续失败达到阈值后熔断，熔断期满后放行一个试探请求，成功则恢复，失败则继续熔断
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	openFor   time.Duration
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// newCircuitBreaker 创建熔断器，threshold 为0时不熔断
+func newCircuitBreaker(threshold int, openFor time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openFor: openFor, state: BreakerClosed}
+}
+
+// configure 更新熔断参数，当前状态保持不变
+func (b *circuitBreaker) configure(threshold int, openFor time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.openFor = openFor
+	if threshold <= 0 {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.probing = false
+	}
+}
+
+// Allow 判断是否放行请求
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return true
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.openFor {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		// 同一时间只放行一个试探请求
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record 记录一次调用结果，countsAsFailure 为 false 的错误（如客户端取消）不影响熔断
+func (b *circuitBreaker) Record(countsAsFailure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.probing = false
+	if !countsAsFailure {
+		b.failures = 0
+		b.state = BreakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release 放行后未产生可计入的结果（如请求被取消）时归还试探名额
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Status 返回熔断器当前状态
+func (b *circuitBreaker) Status() types.BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := types.BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openFor {
+		// 熔断期已满，下一次请求将作为试探
+		status.State = BreakerHalfOpen
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
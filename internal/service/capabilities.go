@@ -0,0 +1,47 @@
+package service
+
+import (
+	"svg-generator/internal/config"
+	"svg-generator/internal/types"
+)
+
+// Capabilities 描述Provider可接受的参数取值
+type Capabilities struct {
+	// Styles 支持的风格；为空表示接受任意风格描述
+	Styles []string
+	// Models 支持的模型；为空表示不接受 model 参数
+	Models []string
+}
+
+// svgioStyles SVG.IO 支持的风格
+var svgioStyles = []string{
+	"FLAT_VECTOR",
+	"FLAT_VECTOR_OUTLINE",
+	"FLAT_VECTOR_SILHOUETTE",
+	"FLAT_VECTOR_ONE_LINE_ART",
+	"FLAT_VECTOR_LINE_ART",
+}
+
+// recraftStyles Recraft 支持的风格
+var recraftStyles = []string{
+	"realistic_image",
+	"digital_illustration",
+	"vector_illustration",
+	"icon",
+}
+
+// GetCapabilities 获取指定Provider的参数能力
+func GetCapabilities(provider types.Provider) Capabilities {
+	switch provider {
+	case types.ProviderSVGIO:
+		return Capabilities{Styles: svgioStyles}
+	case types.ProviderRecraft:
+		return Capabilities{
+			Styles: recraftStyles,
+			Models: config.Get().Providers.Recraft.SupportedModels,
+		}
+	default:
+		// Claude 接受自由文本风格描述，模型由服务端配置决定
+		return Capabilities{}
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
 	"svg-generator/internal/types"
+	"svg-generator/internal/usage"
 	"svg-generator/pkg/utils"
 	"time"
 )
@@ -20,6 +24,7 @@ import (
 type ClaudeService struct {
 	apiKey  string
 	baseURL string
+	logger  *slog.Logger
 }
 
 // NewClaudeService 创建 Claude 服务实例
@@ -30,21 +35,41 @@ func NewClaudeService(apiKey, baseURL string) *ClaudeService {
 	return &ClaudeService{
 		apiKey:  apiKey,
 		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logging.Component("claude"),
 	}
 }
 
+// CheckCredentials 通过模型列表接口校验 API Key
+func (s *ClaudeService) CheckCredentials(ctx context.Context) error {
+	endpoint := config.Get().Providers.Claude.Endpoints.Models
+	if endpoint == "" {
+		endpoint = "/models"
+	}
+	return probeCredentials(ctx, s.baseURL+endpoint, s.apiKey)
+}
+
 // GenerateImage 使用 Claude 生成 SVG 代码
 func (s *ClaudeService) GenerateImage(ctx context.Context, req types.GenerateRequest) (*types.ImageResponse, error) {
-	log.Printf("[CLAUDE] Starting SVG generation request...")
+	s.logger.DebugContext(ctx, "starting SVG generation request")
 
 	// 构建 Claude 提示词
 	prompt := s.buildSVGPrompt(req.Prompt, req.Style, req.NegativePrompt)
 
+	// 模型、最大 token 数和温度取自当前配置，可通过重新加载配置或管理接口在运行时调整
+	claudeCfg := config.Get().Providers.Claude
+	model := claudeCfg.DefaultModel
+	if model == "" {
+		model = "claude-4.0-sonnet"
+	}
+	maxTokens := claudeCfg.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4000
+	}
 	// 构建 Claude API 请求
 	claudeReq := types.ClaudeGenerateReq{
-		Model:       "claude-4.0-sonnet",
-		MaxTokens:   4000,
-		Temperature: 0.7,
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: claudeCfg.Temperature,
 		System: `You are a world-class SVG graphics designer and vector artist with expertise in creating stunning, precise, and semantically meaningful SVG illustrations. Your specialties include:
 
 1. **Technical Excellence**: You create perfectly valid, optimized SVG code that renders flawlessly across all browsers and devices
@@ -70,13 +95,15 @@ You respond ONLY with clean, valid SVG code - no explanations, no code blocks, j
 		},
 	}
 
+	usage.FromContext(ctx).SetModel(model)
+
 	body, err := json.Marshal(claudeReq)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
 	url := s.baseURL + "/chat/completions"
-	log.Printf("[CLAUDE] Sending request to %s", url)
+	s.logger.DebugContext(ctx, "sending upstream request", "url", url, "payload_bytes", len(body))
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
@@ -86,28 +113,29 @@ You respond ONLY with clean, valid SVG code - no explanations, no code blocks, j
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	utils.SetRequestIDHeader(httpReq, config.Get().Providers.Claude.RequestIDHeader)
 
 	resp, err := utils.HTTPClient.Do(httpReq)
 	if err != nil {
-		log.Printf("[CLAUDE] HTTP request failed: %v", err)
+		s.logger.ErrorContext(ctx, "upstream request failed", "error", err)
 		return nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[CLAUDE] Received response with status: %s", resp.Status)
+	s.logger.DebugContext(ctx, "received upstream response", "status", resp.StatusCode)
 
 	if resp.StatusCode >= 300 {
 		var errResp map[string]interface{}
 		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		log.Printf("[CLAUDE] Error response body: %+v", errResp)
-		return nil, fmt.Errorf("claude API error: %s", resp.Status)
+		s.logger.WarnContext(ctx, "upstream error response", "status", resp.StatusCode, "body", errResp)
+		return nil, newUpstreamStatusError("claude API error: ", resp)
 	}
 
-	// 读取原始响应内容进行调试
+	// 读取原始响应内容，仅在 debug 级别输出截断后的内容
 	bodyBytes := make([]byte, 0)
 	if body, err := io.ReadAll(resp.Body); err == nil {
 		bodyBytes = body
-		log.Printf("[CLAUDE] Raw response body: %s", string(body))
+		s.logger.DebugContext(ctx, "raw upstream response", "bytes", len(body), "body", truncateString(string(body), 500))
 	}
 
 	// 重新创建Reader用于JSON解码
@@ -115,29 +143,30 @@ You respond ONLY with clean, valid SVG code - no explanations, no code blocks, j
 
 	var claudeResp types.ClaudeGenerateResp
 	if err := json.NewDecoder(bodyReader).Decode(&claudeResp); err != nil {
-		log.Printf("[CLAUDE] Failed to decode response: %v", err)
+		s.logger.WarnContext(ctx, "failed to decode response, trying generic format", "error", err)
 
 		// 尝试解析为通用格式
 		bodyReader.Seek(0, 0)
-		return s.tryParseGenericFormat(bodyReader, &req)
+		return s.tryParseGenericFormat(ctx, bodyReader, &req)
 	}
 
 	// 添加调试信息
-	log.Printf("[CLAUDE] Response structure: ID=%s, Type=%s, Role=%s, Content length=%d",
-		claudeResp.ID, claudeResp.Type, claudeResp.Role, len(claudeResp.Content))
+	s.logger.DebugContext(ctx, "response structure",
+		"response_id", claudeResp.ID, "type", claudeResp.Type, "role", claudeResp.Role, "content_count", len(claudeResp.Content))
+	recordClaudeUsage(ctx, claudeResp.Usage.InputTokens, claudeResp.Usage.OutputTokens)
 
 	// 如果有内容，打印第一个内容的类型和前100个字符
 	if len(claudeResp.Content) > 0 {
 		firstContent := claudeResp.Content[0]
-		log.Printf("[CLAUDE] First content: Type=%s, Text prefix=%s",
-			firstContent.Type, truncateString(firstContent.Text, 100))
+		s.logger.DebugContext(ctx, "first content block",
+			"type", firstContent.Type, "text_prefix", truncateString(firstContent.Text, 100))
 	}
 
 	if len(claudeResp.Content) == 0 {
-		log.Printf("[CLAUDE] Content array is empty, response: %+v", claudeResp)
+		s.logger.DebugContext(ctx, "content array is empty, trying generic format", "response_id", claudeResp.ID)
 		// 重置Reader并尝试通用格式解析
 		bodyReader.Seek(0, 0)
-		return s.tryParseGenericFormat(bodyReader, &req)
+		return s.tryParseGenericFormat(ctx, bodyReader, &req)
 	}
 
 	// 提取SVG代码
@@ -145,7 +174,7 @@ You respond ONLY with clean, valid SVG code - no explanations, no code blocks, j
 	svgCode := s.extractSVGCode(svgContent)
 
 	if svgCode == "" {
-		log.Printf("[CLAUDE] No valid SVG found in response")
+		s.logger.WarnContext(ctx, "no valid SVG found in response")
 		return nil, fmt.Errorf("no valid SVG generated")
 	}
 
@@ -153,7 +182,7 @@ You respond ONLY with clean, valid SVG code - no explanations, no code blocks, j
 	imageID := generateClaudeImageID()
 	svgURL := s.createSVGDataURL(svgCode)
 
-	log.Printf("[CLAUDE] Successfully generated SVG - ID: %s", imageID)
+	s.logger.InfoContext(ctx, "generation succeeded", "image_id", imageID, "svg_bytes", len(svgCode))
 
 	return &types.ImageResponse{
 		ID:             imageID,
@@ -260,6 +289,13 @@ func encodeSVGToBase64(svgCode string) string {
 	return base64.StdEncoding.EncodeToString([]byte(svgCode))
 }
 
+// recordClaudeUsage 记录 Claude token 用量指标，并计入本次请求的用量
+func recordClaudeUsage(ctx context.Context, inputTokens, outputTokens int) {
+	metrics.ClaudeTokens.Add(float64(inputTokens), "input")
+	metrics.ClaudeTokens.Add(float64(outputTokens), "output")
+	usage.FromContext(ctx).AddTokens(inputTokens, outputTokens)
+}
+
 // truncateString 截断字符串用于日志
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -269,14 +305,21 @@ func truncateString(s string, maxLen int) string {
 }
 
 // tryParseGenericFormat 尝试解析通用格式响应
-func (s *ClaudeService) tryParseGenericFormat(bodyReader io.Reader, req *types.GenerateRequest) (*types.ImageResponse, error) {
+func (s *ClaudeService) tryParseGenericFormat(ctx context.Context, bodyReader io.Reader, req *types.GenerateRequest) (*types.ImageResponse, error) {
 	// 尝试解析为通用的map格式
 	var genericResp map[string]interface{}
 	if err := json.NewDecoder(bodyReader).Decode(&genericResp); err != nil {
 		return nil, fmt.Errorf("failed to decode generic response: %w", err)
 	}
 
-	log.Printf("[CLAUDE] Generic response structure: %+v", genericResp)
+	s.logger.DebugContext(ctx, "parsing generic response format", "fields", len(genericResp))
+
+	// OpenAI 兼容格式的 token 用量
+	if usage, ok := genericResp["usage"].(map[string]interface{}); ok {
+		promptTokens, _ := usage["prompt_tokens"].(float64)
+		completionTokens, _ := usage["completion_tokens"].(float64)
+		recordClaudeUsage(ctx, int(promptTokens), int(completionTokens))
+	}
 
 	// 尝试从不同的字段提取文本内容
 	var textContent string
@@ -305,7 +348,7 @@ func (s *ClaudeService) tryParseGenericFormat(bodyReader io.Reader, req *types.G
 		return nil, fmt.Errorf("no text content found in response")
 	}
 
-	log.Printf("[CLAUDE] Extracted text content: %s", truncateString(textContent, 200))
+	s.logger.DebugContext(ctx, "extracted text content", "text_prefix", truncateString(textContent, 200))
 
 	// 从文本中提取SVG代码
 	svgCode := s.extractSVGCode(textContent)
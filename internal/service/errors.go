@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// UpstreamStatusError 上游返回非成功状态码
+type UpstreamStatusError struct {
+	StatusCode int
+	message    string
+}
+
+// newUpstreamStatusError 根据响应创建状态码错误，prefix 为错误信息前缀
+func newUpstreamStatusError(prefix string, resp *http.Response) *UpstreamStatusError {
+	return &UpstreamStatusError{
+		StatusCode: resp.StatusCode,
+		message:    prefix + resp.Status,
+	}
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return e.message
+}
+
+// 上游错误分类，用于指标和熔断统计
+const (
+	ErrorClassTimeout         = "timeout"
+	ErrorClassCanceled        = "canceled"
+	ErrorClassNetwork         = "network"
+	ErrorClassAuth            = "auth"
+	ErrorClassRateLimited     = "rate_limited"
+	ErrorClassClientError     = "client_error"
+	ErrorClassServerError     = "server_error"
+	ErrorClassInvalidResponse = "invalid_response"
+	ErrorClassCircuitOpen     = "circuit_open"
+	ErrorClassNoKeyAvailable  = "no_key_available"
+)
+
+// ClassifyError 将上游调用错误归类
+func ClassifyError(err error) string {
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+	if errors.Is(err, ErrNoKeyAvailable) {
+		return ErrorClassNoKeyAvailable
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return ErrorClassAuth
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorClassRateLimited
+		case statusErr.StatusCode >= 500:
+			return ErrorClassServerError
+		default:
+			return ErrorClassClientError
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+
+	return ErrorClassInvalidResponse
+}
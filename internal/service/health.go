@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/types"
+	"svg-generator/pkg/utils"
+)
+
+// Provider 健康状态
+const (
+	HealthHealthy     = "healthy"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+	HealthDisabled    = "disabled"
+)
+
+// CredentialChecker 支持轻量凭据检查的 Provider，检查不应产生计费调用
+type CredentialChecker interface {
+	CheckCredentials(ctx context.Context) error
+}
+
+// providerHealth 单个 Provider 的调用统计、熔断器和凭据检查缓存
+type providerHealth struct {
+	breaker *circuitBreaker
+
+	mu          sync.Mutex
+	lastCall    *types.LastCallStatus
+	latencies   []time.Duration // 环形缓冲区，仅记录成功调用
+	next        int
+	credentials *types.CredentialStatus
+}
+
+func newProviderHealth(cfg config.HealthConfig) *providerHealth {
+	return &providerHealth{
+		breaker:   newCircuitBreaker(cfg.BreakerFailureThreshold, cfg.GetBreakerOpenDuration()),
+		latencies: make([]time.Duration, 0, cfg.GetLatencyWindow()),
+	}
+}
+
+// configure 应用新的健康检查配置，延迟窗口大小变化时清空已有样本
+func (h *providerHealth) configure(cfg config.HealthConfig) {
+	h.breaker.configure(cfg.BreakerFailureThreshold, cfg.GetBreakerOpenDuration())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if window := cfg.GetLatencyWindow(); window != cap(h.latencies) {
+		h.latencies = make([]time.Duration, 0, window)
+		h.next = 0
+	}
+}
+
+// recordCall 记录一次上游调用结果
+func (h *providerHealth) recordCall(duration time.Duration, err error) {
+	class := ""
+	if err != nil {
+		class = ClassifyError(err)
+	}
+
+	switch class {
+	case ErrorClassCanceled:
+		// 客户端取消不代表上游异常
+		h.breaker.Release()
+		return
+	case "", ErrorClassClientError:
+		h.breaker.Record(false)
+	default:
+		h.breaker.Record(true)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastCall = &types.LastCallStatus{
+		At:         time.Now(),
+		Success:    err == nil,
+		ErrorClass: class,
+		DurationMs: float64(duration) / float64(time.Millisecond),
+	}
+	if err == nil {
+		if len(h.latencies) < cap(h.latencies) {
+			h.latencies = append(h.latencies, duration)
+		} else {
+			h.latencies[h.next] = duration
+			h.next = (h.next + 1) % len(h.latencies)
+		}
+	}
+}
+
+// newCredentialStatus 根据凭据检查结果生成状态，错误信息中的凭据已脱敏
+func newCredentialStatus(err error) *types.CredentialStatus {
+	status := &types.CredentialStatus{CheckedAt: time.Now(), Valid: err == nil}
+	if err != nil {
+		status.ErrorClass = ClassifyError(err)
+		status.Error = logging.RedactSecrets(err.Error())
+	}
+	return status
+}
+
+// setCredentials 更新凭据检查结果
+func (h *providerHealth) setCredentials(err error) {
+	status := newCredentialStatus(err)
+
+	h.mu.Lock()
+	h.credentials = status
+	h.mu.Unlock()
+}
+
+// snapshot 生成健康报告
+func (h *providerHealth) snapshot() types.ProviderHealth {
+	report := types.ProviderHealth{Breaker: h.breaker.Status()}
+
+	h.mu.Lock()
+	if h.lastCall != nil {
+		lastCall := *h.lastCall
+		report.LastCall = &lastCall
+	}
+	if h.credentials != nil {
+		credentials := *h.credentials
+		report.Credentials = &credentials
+	}
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	h.mu.Unlock()
+
+	report.Samples = len(sorted)
+	if len(sorted) > 0 {
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		idx := (len(sorted)*95+99)/100 - 1
+		report.LatencyP95Ms = float64(sorted[idx]) / float64(time.Millisecond)
+	}
+
+	switch {
+	case report.Breaker.State == BreakerOpen:
+		report.Status = HealthUnavailable
+	case report.Credentials != nil && report.Credentials.ErrorClass == ErrorClassAuth:
+		report.Status = HealthUnavailable
+	case report.Breaker.State == BreakerHalfOpen,
+		report.Credentials != nil && !report.Credentials.Valid,
+		report.Breaker.ConsecutiveFailures > 0,
+		report.LastCall != nil && !report.LastCall.Success:
+		report.Status = HealthDegraded
+	default:
+		report.Status = HealthHealthy
+	}
+	return report
+}
+
+// allProviders 全部已知 Provider，按报告顺序排列
+var allProviders = []types.Provider{types.ProviderSVGIO, types.ProviderRecraft, types.ProviderClaude}
+
+// ProviderHealth 返回全部 Provider 的健康报告
+func (sm *ServiceManager) ProviderHealth() []types.ProviderHealth {
+	reports := make([]types.ProviderHealth, 0, len(allProviders))
+	for _, p := range allProviders {
+		report := types.ProviderHealth{Status: HealthDisabled, Breaker: types.BreakerStatus{State: BreakerClosed}}
+		if h, ok := sm.health[p]; ok && sm.GetProvider(p) != nil {
+			report = h.snapshot()
+		}
+		report.Provider = p
+		report.KeysTotal, report.KeysAvailable = sm.pools[p].counts()
+		report.Configured = report.KeysTotal > 0
+		report.Enabled = config.Get().IsProviderEnabled(string(p))
+		// 密钥池中的 Key 全部被隔离时无法处理请求
+		if report.Status != HealthDisabled && report.KeysAvailable == 0 {
+			report.Status = HealthUnavailable
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// Ready 至少有一个可用的 Provider 时返回 true
+func (sm *ServiceManager) Ready() bool {
+	for _, report := range sm.ProviderHealth() {
+		if report.Status == HealthHealthy || report.Status == HealthDegraded {
+			return true
+		}
+	}
+	return false
+}
+
+// StartHealthChecks 启动后台凭据检查，ctx 取消后停止
+func (sm *ServiceManager) StartHealthChecks(ctx context.Context) {
+	sm.healthMu.Lock()
+	sm.healthParent = ctx
+	sm.healthMu.Unlock()
+	sm.restartHealthChecks()
+}
+
+// restartHealthChecks 按当前配置的间隔重新启动凭据检查
+func (sm *ServiceManager) restartHealthChecks() {
+	sm.healthMu.Lock()
+	defer sm.healthMu.Unlock()
+
+	if sm.healthParent == nil {
+		return
+	}
+	if sm.stopHealth != nil {
+		sm.stopHealth()
+		sm.stopHealth = nil
+	}
+
+	interval := config.Get().Health.CredentialCheckInterval
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(sm.healthParent)
+	sm.stopHealth = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			sm.checkCredentials(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkCredentials 对支持凭据检查的 Provider 逐个 Key 执行一次检查，凭据无效的 Key 被隔离。
+// 任意一个 Key 有效即视为该 Provider 凭据有效
+func (sm *ServiceManager) checkCredentials(ctx context.Context) {
+	logger := logging.Component("health")
+	timeout := config.Get().Health.GetCredentialCheckTimeout()
+
+	for _, p := range allProviders {
+		h := sm.health[p]
+		if h == nil || sm.GetProvider(p) == nil {
+			continue
+		}
+
+		pool := sm.pools[p]
+		keys, providers := pool.all()
+		var firstErr error
+		checked, anyValid := false, false
+		for i, key := range keys {
+			checker, ok := providers[i].(CredentialChecker)
+			if !ok {
+				continue
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			err := checker.CheckCredentials(checkCtx)
+			cancel()
+			if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+				return
+			}
+
+			checked = true
+			status := newCredentialStatus(err)
+			pool.setCredentials(key, status)
+			if err != nil {
+				logger.Warn("credential check failed", "provider", string(p), "key_id", key.id, "error_class", status.ErrorClass, "error", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else {
+				logger.Debug("credential check passed", "provider", string(p), "key_id", key.id)
+				anyValid = true
+			}
+		}
+		if checked {
+			if anyValid {
+				firstErr = nil
+			}
+			h.setCredentials(firstErr)
+		}
+	}
+}
+
+// probeCredentials 以 GET 请求探测凭据：401/403 视为凭据无效，429/5xx 视为上游异常，
+// 其他状态码（包括 404）说明凭据已通过鉴权
+func probeCredentials(ctx context.Context, url, apiKey string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := utils.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return newUpstreamStatusError("credential check status: ", resp)
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/types"
+)
+
+// ErrNoKeyAvailable 密钥池中的 Key 全部处于隔离期
+var ErrNoKeyAvailable = errors.New("all api keys are quarantined")
+
+// ErrKeyExists 运行时添加的 Key 已在密钥池中
+var ErrKeyExists = errors.New("api key already in pool")
+
+// Key 选择策略
+const (
+	KeyStrategyRoundRobin = "round_robin"
+	KeyStrategyLeastUsed  = "least_used"
+)
+
+// Key 来源
+const (
+	KeySourceConfig  = "config"
+	KeySourceRuntime = "runtime"
+)
+
+// poolKey 密钥池中的单个 Key 及其使用统计
+type poolKey struct {
+	id       string
+	secret   string
+	source   string
+	provider Provider // 使用该 Key 创建的 Provider 实例
+
+	inFlight         int64
+	requests         int64
+	successes        int64
+	failures         int64
+	lastUsed         time.Time
+	lastErrorClass   string
+	quarantinedUntil time.Time
+	quarantineReason string
+	credentials      *types.CredentialStatus
+}
+
+// keyPool 单个 Provider 的 API Key 池，按策略选择 Key，返回 401/403/429 的 Key 隔离一段时间。
+// 运行时添加、移除的 Key 在重新加载配置后仍然生效，重启后失效
+type keyPool struct {
+	mu       sync.Mutex
+	keys     []*poolKey
+	next     int
+	strategy string
+	cooldown time.Duration
+	build    func(secret string) Provider
+
+	runtime      map[string]string // 运行时添加的 Key：id -> secret
+	runtimeOrder []string
+	removed      map[string]bool // 运行时移除的配置 Key
+}
+
+func newKeyPool() *keyPool {
+	return &keyPool{
+		strategy: KeyStrategyRoundRobin,
+		cooldown: config.KeyPoolConfig{}.GetCooldown(),
+		runtime:  make(map[string]string),
+		removed:  make(map[string]bool),
+	}
+}
+
+// keyID 计算 Key 的指纹，用于日志、指标和管理接口，不可还原出 Key
+func keyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:5])
+}
+
+// keyHint 返回 Key 末尾 4 位
+func keyHint(secret string) string {
+	if len(secret) <= 8 {
+		return "..."
+	}
+	return "..." + secret[len(secret)-4:]
+}
+
+// reconcile 按配置重建 Key 列表：保留仍然存在的 Key 的统计和隔离状态，
+// 每个 Key 的 Provider 实例按新配置重新创建
+func (p *keyPool) reconcile(secrets []string, cfg config.KeyPoolConfig, build func(secret string) Provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.build = build
+	p.strategy = cfg.Strategy
+	if p.strategy == "" {
+		p.strategy = KeyStrategyRoundRobin
+	}
+	p.cooldown = cfg.GetCooldown()
+
+	existing := make(map[string]*poolKey, len(p.keys))
+	for _, k := range p.keys {
+		existing[k.id] = k
+	}
+
+	var keys []*poolKey
+	seen := make(map[string]bool)
+	add := func(secret, source string) {
+		id := keyID(secret)
+		if seen[id] || (source == KeySourceConfig && p.removed[id]) {
+			return
+		}
+		seen[id] = true
+		k := existing[id]
+		if k == nil {
+			k = &poolKey{id: id, secret: secret}
+		}
+		k.source = source
+		k.provider = build(secret)
+		keys = append(keys, k)
+	}
+	for _, secret := range secrets {
+		add(secret, KeySourceConfig)
+	}
+	for _, id := range p.runtimeOrder {
+		add(p.runtime[id], KeySourceRuntime)
+	}
+
+	p.keys = keys
+	if p.next >= len(keys) {
+		p.next = 0
+	}
+}
+
+// replace 以单个 Provider 实例替换整个密钥池，用于注册自定义 Provider
+func (p *keyPool) replace(provider Provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = []*poolKey{{id: "custom", source: KeySourceRuntime, provider: provider}}
+	p.next = 0
+}
+
+// first 返回第一个 Key 的 Provider 实例，密钥池为空时返回 nil
+func (p *keyPool) first() Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) == 0 {
+		return nil
+	}
+	return p.keys[0].provider
+}
+
+// size 返回密钥池中的 Key 数量
+func (p *keyPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// acquire 按策略选择一个未被隔离的 Key 并计入进行中的请求，同时返回该 Key 当前的 Provider 实例；
+// 调用方必须随后调用 finish 或 abandon
+func (p *keyPool) acquire() (*poolKey, Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var chosen *poolKey
+	switch p.strategy {
+	case KeyStrategyLeastUsed:
+		for _, k := range p.keys {
+			if now.Before(k.quarantinedUntil) {
+				continue
+			}
+			if chosen == nil || k.inFlight < chosen.inFlight ||
+				(k.inFlight == chosen.inFlight && k.requests < chosen.requests) {
+				chosen = k
+			}
+		}
+	default:
+		for i := 0; i < len(p.keys); i++ {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if !now.Before(k.quarantinedUntil) {
+				chosen = k
+				p.next = (p.next + i + 1) % len(p.keys)
+				break
+			}
+		}
+	}
+
+	if chosen == nil {
+		return nil, nil, ErrNoKeyAvailable
+	}
+	chosen.inFlight++
+	chosen.lastUsed = now
+	return chosen, chosen.provider, nil
+}
+
+// finish 记录一次调用结果；鉴权失败和限流的 Key 进入隔离期，返回是否被隔离
+func (p *keyPool) finish(k *poolKey, class string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k.inFlight--
+	if class == ErrorClassCanceled {
+		return false
+	}
+	k.requests++
+	if class == "" {
+		k.successes++
+		return false
+	}
+	k.failures++
+	k.lastErrorClass = class
+	return p.quarantineLocked(k, class)
+}
+
+// abandon 释放未实际发出请求的 Key
+func (p *keyPool) abandon(k *poolKey) {
+	p.mu.Lock()
+	k.inFlight--
+	p.mu.Unlock()
+}
+
+// quarantineLocked 对鉴权失败或限流的 Key 开始隔离，调用方需持有 p.mu
+func (p *keyPool) quarantineLocked(k *poolKey, class string) bool {
+	if class != ErrorClassAuth && class != ErrorClassRateLimited {
+		return false
+	}
+	k.quarantinedUntil = time.Now().Add(p.cooldown)
+	k.quarantineReason = class
+	return true
+}
+
+// setCredentials 记录单个 Key 的凭据检查结果，凭据无效的 Key 进入隔离期
+func (p *keyPool) setCredentials(k *poolKey, status *types.CredentialStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.credentials = status
+	if !status.Valid {
+		p.quarantineLocked(k, status.ErrorClass)
+	}
+}
+
+// add 运行时添加 Key
+func (p *keyPool) add(secret string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := keyID(secret)
+	for _, k := range p.keys {
+		if k.id == id {
+			return id, ErrKeyExists
+		}
+	}
+	if p.build == nil {
+		return "", errors.New("provider is not initialized")
+	}
+
+	delete(p.removed, id)
+	if _, ok := p.runtime[id]; !ok {
+		p.runtime[id] = secret
+		p.runtimeOrder = append(p.runtimeOrder, id)
+	}
+	p.keys = append(p.keys, &poolKey{id: id, secret: secret, source: KeySourceRuntime, provider: p.build(secret)})
+	return id, nil
+}
+
+// remove 运行时移除 Key，进行中的请求不受影响；返回 Key 是否存在
+func (p *keyPool) remove(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, k := range p.keys {
+		if k.id != id {
+			continue
+		}
+		p.keys = append(p.keys[:i:i], p.keys[i+1:]...)
+		if p.next >= len(p.keys) {
+			p.next = 0
+		}
+		if _, ok := p.runtime[id]; ok {
+			delete(p.runtime, id)
+			for j, rid := range p.runtimeOrder {
+				if rid == id {
+					p.runtimeOrder = append(p.runtimeOrder[:j:j], p.runtimeOrder[j+1:]...)
+					break
+				}
+			}
+		} else {
+			p.removed[id] = true
+		}
+		return true
+	}
+	return false
+}
+
+// release 提前结束 Key 的隔离期，返回 Key 是否存在
+func (p *keyPool) release(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.id == id {
+			k.quarantinedUntil = time.Time{}
+			k.quarantineReason = ""
+			return true
+		}
+	}
+	return false
+}
+
+// all 返回当前全部 Key 及其 Provider 实例，用于凭据检查
+func (p *keyPool) all() ([]*poolKey, []Provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	providers := make([]Provider, len(p.keys))
+	for i, k := range p.keys {
+		providers[i] = k.provider
+	}
+	return append([]*poolKey(nil), p.keys...), providers
+}
+
+// status 返回各 Key 的使用情况
+func (p *keyPool) status() []types.APIKeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	out := make([]types.APIKeyStatus, 0, len(p.keys))
+	for _, k := range p.keys {
+		s := types.APIKeyStatus{
+			ID:             k.id,
+			Hint:           keyHint(k.secret),
+			Source:         k.source,
+			InFlight:       k.inFlight,
+			Requests:       k.requests,
+			Successes:      k.successes,
+			Failures:       k.failures,
+			LastErrorClass: k.lastErrorClass,
+		}
+		if !k.lastUsed.IsZero() {
+			lastUsed := k.lastUsed
+			s.LastUsed = &lastUsed
+		}
+		if now.Before(k.quarantinedUntil) {
+			until := k.quarantinedUntil
+			s.QuarantinedUntil = &until
+			s.QuarantineReason = k.quarantineReason
+		}
+		if k.credentials != nil {
+			credentials := *k.credentials
+			s.Credentials = &credentials
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// counts 返回 Key 总数和未被隔离的 Key 数
+func (p *keyPool) counts() (total, available int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, k := range p.keys {
+		if !now.Before(k.quarantinedUntil) {
+			available++
+		}
+	}
+	return len(p.keys), available
+}
+
+// nextRelease 返回最早结束隔离的时间，用于 Retry-After
+func (p *keyPool) nextRelease() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var earliest time.Time
+	for _, k := range p.keys {
+		if earliest.IsZero() || k.quarantinedUntil.Before(earliest) {
+			earliest = k.quarantinedUntil
+		}
+	}
+	return earliest
+}
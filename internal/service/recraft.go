@@ -6,41 +6,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/tracing"
 	"svg-generator/internal/types"
+	"svg-generator/internal/usage"
 	"svg-generator/pkg/utils"
 	"time"
 )
 
 // RecraftService 实现 Recraft API 调用
 type RecraftService struct {
-	apiKey  string
-	baseURL string
+	apiKey     string
+	baseURL    string
+	downloader *utils.SafeDownloader
+	logger     *slog.Logger
 }
 
 // NewRecraftService 创建 Recraft 服务实例
 func NewRecraftService(apiKey string) *RecraftService {
 	return &RecraftService{
-		apiKey:  apiKey,
-		baseURL: config.AppConfig.Providers.Recraft.BaseURL,
+		apiKey:     apiKey,
+		baseURL:    config.Get().Providers.Recraft.BaseURL,
+		downloader: NewProviderDownloader(config.Get().Providers.Recraft.AllowedDownloadHosts),
+		logger:     logging.Component("recraft"),
 	}
 }
 
+// CheckCredentials 通过当前用户接口校验 API Key
+func (s *RecraftService) CheckCredentials(ctx context.Context) error {
+	endpoint := config.Get().Providers.Recraft.Endpoints.User
+	if endpoint == "" {
+		endpoint = "/v1/users/me"
+	}
+	return probeCredentials(ctx, s.baseURL+endpoint, s.apiKey)
+}
+
 // GenerateImage 使用 Recraft API 生成图像
 func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRequest) (*types.ImageResponse, error) {
-	log.Printf("[RECRAFT] Starting generation request...")
+	s.logger.DebugContext(ctx, "starting generation request")
 
 	// 构建优化后的提示词（添加无背景要求）
 	enhancedPrompt, enhancedNegativePrompt := s.buildRecraftPrompt(req.Prompt, req.Style, req.NegativePrompt)
 
-	log.Printf("[RECRAFT] Original prompt: %s", req.Prompt)
-	log.Printf("[RECRAFT] Enhanced prompt: %s", enhancedPrompt)
-	log.Printf("[RECRAFT] Enhanced negative prompt: %s", enhancedNegativePrompt)
+	s.logger.DebugContext(ctx, "built recraft prompt",
+		"prompt", req.Prompt,
+		"enhanced_prompt", enhancedPrompt,
+		"negative_prompt", enhancedNegativePrompt)
 
 	// 构建 Recraft API 请求
 	recraftReq := types.RecraftGenerateReq{
@@ -55,6 +73,9 @@ func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRe
 	}
 
 	// 设置默认值
+	if recraftReq.Model == "" {
+		recraftReq.Model = config.Get().Providers.Recraft.DefaultModel
+	}
 	if recraftReq.Model == "" {
 		recraftReq.Model = "recraftv2"
 	}
@@ -68,13 +89,15 @@ func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRe
 		recraftReq.N = 1
 	}
 
+	usage.FromContext(ctx).SetModel(recraftReq.Model)
+
 	body, err := json.Marshal(recraftReq)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	url := s.baseURL + config.AppConfig.Providers.Recraft.Endpoints.Generate
-	log.Printf("[RECRAFT] Sending request to %s with payload size: %d bytes", url, len(body))
+	url := s.baseURL + config.Get().Providers.Recraft.Endpoints.Generate
+	s.logger.DebugContext(ctx, "sending upstream request", "url", url, "payload_bytes", len(body))
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
@@ -83,36 +106,39 @@ func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRe
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	utils.SetRequestIDHeader(httpReq, config.Get().Providers.Recraft.RequestIDHeader)
 
 	resp, err := utils.HTTPClient.Do(httpReq)
 	if err != nil {
-		log.Printf("[RECRAFT] HTTP request failed: %v", err)
+		s.logger.ErrorContext(ctx, "upstream request failed", "error", err)
 		return nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[RECRAFT] Received response with status: %s", resp.Status)
+	s.logger.DebugContext(ctx, "received upstream response", "status", resp.StatusCode)
 
 	if resp.StatusCode >= 300 {
 		var errResp map[string]interface{}
 		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		log.Printf("[RECRAFT] Error response body: %+v", errResp)
-		return nil, fmt.Errorf("recraft API error: %s", resp.Status)
+		s.logger.WarnContext(ctx, "upstream error response", "status", resp.StatusCode, "body", errResp)
+		return nil, newUpstreamStatusError("recraft API error: ", resp)
 	}
 
 	var recraftResp types.RecraftGenerateResp
 	if err := json.NewDecoder(resp.Body).Decode(&recraftResp); err != nil {
-		log.Printf("[RECRAFT] Failed to decode response: %v", err)
+		s.logger.ErrorContext(ctx, "failed to decode upstream response", "error", err)
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
 
 	if len(recraftResp.Data) == 0 {
-		log.Printf("[RECRAFT] No images in response")
+		s.logger.WarnContext(ctx, "no images in upstream response")
 		return nil, errors.New("no images generated")
 	}
 
+	usage.FromContext(ctx).AddImages(len(recraftResp.Data))
+
 	imageData := recraftResp.Data[0] // 取第一张图片
-	log.Printf("[RECRAFT] Successfully parsed response - URL: %s", imageData.URL)
+	s.logger.InfoContext(ctx, "generation succeeded", "image_url", imageData.URL)
 
 	// 解析图片尺寸
 	width, height := parseSizeFromString(recraftReq.Size)
@@ -128,7 +154,7 @@ func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRe
 	if req.Format == "svg" || strings.Contains(req.Style, "vector") {
 		vectorizedURL, err := s.vectorizeImage(ctx, imageData.URL)
 		if err != nil {
-			log.Printf("[RECRAFT] Vectorization failed: %v", err)
+			s.logger.WarnContext(ctx, "vectorization failed, using raster image", "error", err)
 			// 失败时继续使用原图
 		} else {
 			svgURL = vectorizedURL
@@ -150,41 +176,63 @@ func (s *RecraftService) GenerateImage(ctx context.Context, req types.GenerateRe
 }
 
 // vectorizeImage 使用 Recraft 的向量化 API 将图片转换为 SVG
-func (s *RecraftService) vectorizeImage(ctx context.Context, imageURL string) (string, error) {
-	log.Printf("[RECRAFT] Vectorizing image: %s", imageURL)
-
-	// 下载图片
-	imageBytes, err := utils.DownloadFile(ctx, imageURL)
+func (s *RecraftService) vectorizeImage(ctx context.Context, imageURL string) (svgURL string, err error) {
+	ctx, span := tracing.Start(ctx, "recraft.vectorizeImage", tracing.WithAttributes(
+		tracing.String("provider", string(types.ProviderRecraft)),
+	))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	s.logger.DebugContext(ctx, "vectorizing image", "image_url", imageURL)
+
+	// 下载图片，流式写入 multipart 请求体，避免整张图片驻留内存
+	downloadCtx, cancelDownload := context.WithCancel(ctx)
+	download, err := s.downloader.Open(downloadCtx, imageURL)
 	if err != nil {
+		cancelDownload()
 		return "", fmt.Errorf("download image: %w", err)
 	}
+	defer download.Body.Close()
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	// 上游提前返回时中止下载并关闭管道，等写入协程退出后才关闭下载的响应体
+	copied := make(chan struct{})
+	defer func() {
+		cancelDownload()
+		pr.Close()
+		<-copied
+	}()
+	go func() {
+		defer close(copied)
+		// 添加图片文件
+		part, err := writer.CreateFormFile("file", "image.png")
+		if err != nil {
+			pw.CloseWithError(fmt.Errorf("create form file: %w", err))
+			return
+		}
+		if _, err := io.Copy(part, download.Body); err != nil {
+			pw.CloseWithError(fmt.Errorf("write image data: %w", err))
+			return
+		}
 
-	// 创建 multipart form
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	// 添加图片文件
-	part, err := writer.CreateFormFile("file", "image.png")
-	if err != nil {
-		return "", fmt.Errorf("create form file: %w", err)
-	}
-	_, err = part.Write(imageBytes)
-	if err != nil {
-		return "", fmt.Errorf("write image data: %w", err)
-	}
-
-	// 添加 response_format 参数
-	writer.WriteField("response_format", "url")
-	writer.Close()
+		// 添加 response_format 参数
+		writer.WriteField("response_format", "url")
+		pw.CloseWithError(writer.Close())
+	}()
 
-	url := s.baseURL + config.AppConfig.Providers.Recraft.Endpoints.Vectorize
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	url := s.baseURL + config.Get().Providers.Recraft.Endpoints.Vectorize
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
 	if err != nil {
 		return "", fmt.Errorf("create vectorize request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
 	httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	utils.SetRequestIDHeader(httpReq, config.Get().Providers.Recraft.RequestIDHeader)
 
 	resp, err := utils.HTTPClient.Do(httpReq)
 	if err != nil {
@@ -193,15 +241,16 @@ func (s *RecraftService) vectorizeImage(ctx context.Context, imageURL string) (s
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("vectorize API error: %s", resp.Status)
+		return "", newUpstreamStatusError("vectorize API error: ", resp)
 	}
+	usage.FromContext(ctx).AddVectorizeCalls(1)
 
 	var vectorizeResp types.RecraftVectorizeResp
 	if err := json.NewDecoder(resp.Body).Decode(&vectorizeResp); err != nil {
 		return "", fmt.Errorf("decode vectorize response: %w", err)
 	}
 
-	log.Printf("[RECRAFT] Vectorization successful - SVG URL: %s", vectorizeResp.Image.URL)
+	s.logger.InfoContext(ctx, "vectorization succeeded", "svg_url", vectorizeResp.Image.URL)
 	return vectorizeResp.Image.URL, nil
 }
 
@@ -5,12 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
 	"svg-generator/internal/types"
+	"svg-generator/internal/usage"
 	"svg-generator/pkg/utils"
 )
 
@@ -18,16 +20,23 @@ import (
 type SVGIOService struct {
 	apiKey  string
 	baseURL string
+	logger  *slog.Logger
 }
 
 // NewSVGIOService 创建 SVG.IO 服务实例
 func NewSVGIOService(apiKey string) *SVGIOService {
 	return &SVGIOService{
 		apiKey:  apiKey,
-		baseURL: config.AppConfig.Providers.SVGIO.BaseURL,
+		baseURL: config.Get().Providers.SVGIO.BaseURL,
+		logger:  logging.Component("svgio"),
 	}
 }
 
+// CheckCredentials 查询一个不存在的图像ID校验 API Key，不产生生成费用
+func (s *SVGIOService) CheckCredentials(ctx context.Context) error {
+	return probeCredentials(ctx, s.baseURL+config.Get().Providers.SVGIO.Endpoints.GetImage+"health-check", s.apiKey)
+}
+
 // svgioGenerateReq SVG.IO API生成请求
 type svgioGenerateReq struct {
 	Prompt           string  `json:"prompt"`
@@ -39,23 +48,13 @@ type svgioGenerateReq struct {
 
 // svgioGenerateResp SVG.IO API生成响应
 type svgioGenerateResp struct {
-	Success bool `json:"success"`
-	Data    []struct {
-		ID             string `json:"id"`
-		Prompt         string `json:"prompt"`
-		NegativePrompt string `json:"negativePrompt"`
-		Style          string `json:"style"`
-		SVGURL         string `json:"svgUrl"`
-		PNGURL         string `json:"pngUrl"`
-		Width          int    `json:"width"`
-		Height         int    `json:"height"`
-		CreatedAt      string `json:"createdAt"`
-	} `json:"data"`
+	Success bool                      `json:"success"`
+	Data    []types.SVGIOGenerateItem `json:"data"`
 }
 
 // GenerateImage 使用 SVG.IO API 生成图像
 func (s *SVGIOService) GenerateImage(ctx context.Context, req types.GenerateRequest) (*types.ImageResponse, error) {
-	log.Printf("[SVGIO] Starting generation request...")
+	s.logger.DebugContext(ctx, "starting generation request")
 
 	upReq := svgioGenerateReq{
 		Prompt:           req.Prompt,
@@ -74,40 +73,43 @@ func (s *SVGIOService) GenerateImage(ctx context.Context, req types.GenerateRequ
 	}
 
 	body, _ := json.Marshal(upReq)
-	apiURL := s.baseURL + config.AppConfig.Providers.SVGIO.Endpoints.Generate
-	log.Printf("[SVGIO] Sending request to %s with payload size: %d bytes", apiURL, len(body))
+	apiURL := s.baseURL + config.Get().Providers.SVGIO.Endpoints.Generate
+	s.logger.DebugContext(ctx, "sending upstream request", "url", apiURL, "payload_bytes", len(body))
 
 	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(body))
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	utils.SetRequestIDHeader(httpReq, config.Get().Providers.SVGIO.RequestIDHeader)
 
 	resp, err := utils.HTTPClient.Do(httpReq)
 	if err != nil {
-		log.Printf("[SVGIO] HTTP request failed: %v", err)
+		s.logger.ErrorContext(ctx, "upstream request failed", "error", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[SVGIO] Received response with status: %s", resp.Status)
+	s.logger.DebugContext(ctx, "received upstream response", "status", resp.StatusCode)
 
 	if resp.StatusCode >= 300 {
 		var raw any
 		_ = json.NewDecoder(resp.Body).Decode(&raw)
-		log.Printf("[SVGIO] Error response body: %+v", raw)
-		return nil, errors.New("upstream status: " + resp.Status)
+		s.logger.WarnContext(ctx, "upstream error response", "status", resp.StatusCode, "body", raw)
+		return nil, newUpstreamStatusError("upstream status: ", resp)
 	}
 
 	var upResp svgioGenerateResp
 	if err := json.NewDecoder(resp.Body).Decode(&upResp); err != nil {
-		log.Printf("[SVGIO] Failed to decode response: %v", err)
+		s.logger.ErrorContext(ctx, "failed to decode upstream response", "error", err)
 		return nil, err
 	}
 	if !upResp.Success || len(upResp.Data) == 0 {
-		log.Printf("[SVGIO] Invalid response: success=%v, data_count=%d", upResp.Success, len(upResp.Data))
+		s.logger.WarnContext(ctx, "invalid upstream response", "success", upResp.Success, "data_count", len(upResp.Data))
 		return nil, errors.New("upstream no data")
 	}
 	it := upResp.Data[0]
-	log.Printf("[SVGIO] Successfully parsed response - ID: %s, SVG: %s, PNG: %s", it.ID, it.SVGURL, it.PNGURL)
+	usage.FromContext(ctx).AddImages(len(upResp.Data))
+	s.logger.InfoContext(ctx, "generation succeeded", "image_id", it.ID, "svg_url", it.SVGURL, "png_url", it.PNGURL,
+		"nsfw_text", it.NSFWTextDetected, "nsfw_content", it.NSFWContentDetected)
 
 	createdAt, _ := time.Parse(time.RFC3339, it.CreatedAt)
 	return &types.ImageResponse{
@@ -121,6 +123,9 @@ func (s *SVGIOService) GenerateImage(ctx context.Context, req types.GenerateRequ
 		Height:         it.Height,
 		CreatedAt:      createdAt,
 		Provider:       types.ProviderSVGIO,
+
+		NSFWTextDetected:    it.NSFWTextDetected,
+		NSFWContentDetected: it.NSFWContentDetected,
 	}, nil
 }
 
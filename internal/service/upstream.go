@@ -3,9 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/tracing"
 	"svg-generator/internal/types"
+	"svg-generator/pkg/utils"
 )
 
 // Provider 定义上游服务提供商接口
@@ -15,67 +22,235 @@ type Provider interface {
 
 // ServiceManager 管理多个上游服务
 type ServiceManager struct {
-	svgioService   Provider
-	recraftService Provider
-	claudeService  Provider
+	// pools 每个 Provider 的密钥池，每个 Key 对应一个 Provider 实例，配置重新加载时重建实例并保留统计
+	pools map[types.Provider]*keyPool
+
+	// mu 保护下载器，配置重新加载时整体替换；进行中的请求继续使用替换前取得的实例
+	mu          sync.RWMutex
+	downloaders map[types.Provider]*utils.SafeDownloader
+
+	health map[types.Provider]*providerHealth
+
+	healthMu     sync.Mutex
+	healthParent context.Context
+	stopHealth   context.CancelFunc
 }
 
-// NewServiceManager 创建服务管理器
-func NewServiceManager(svgioAPIKey, recraftAPIKey, claudeAPIKey, claudeBaseURL string) *ServiceManager {
-	var svgioService *SVGIOService
-	var recraftService *RecraftService
-	var claudeService *ClaudeService
+// NewServiceManager 按当前配置创建服务管理器
+func NewServiceManager() *ServiceManager {
+	healthCfg := config.Get().Health
+	sm := &ServiceManager{
+		pools: map[types.Provider]*keyPool{
+			types.ProviderSVGIO:   newKeyPool(),
+			types.ProviderRecraft: newKeyPool(),
+			types.ProviderClaude:  newKeyPool(),
+		},
+		health: map[types.Provider]*providerHealth{
+			types.ProviderSVGIO:   newProviderHealth(healthCfg),
+			types.ProviderRecraft: newProviderHealth(healthCfg),
+			types.ProviderClaude:  newProviderHealth(healthCfg),
+		},
+	}
+	sm.buildProviders(config.Get())
+	return sm
+}
 
-	if svgioAPIKey != "" && config.AppConfig.Providers.SVGIO.Enabled {
-		svgioService = NewSVGIOService(svgioAPIKey)
+// buildProviders 按配置为每个 API Key 创建 Provider 实例，并创建下载器。
+// 配置了 API Key 的 Provider 都会创建实例，是否启用在调用时按当前配置判断
+func (sm *ServiceManager) buildProviders(cfg *config.Config) {
+	providers := cfg.Providers
+	builders := map[types.Provider]func(secret string) Provider{
+		types.ProviderSVGIO:   func(secret string) Provider { return NewSVGIOService(secret) },
+		types.ProviderRecraft: func(secret string) Provider { return NewRecraftService(secret) },
+		types.ProviderClaude: func(secret string) Provider {
+			return NewClaudeService(secret, providers.Claude.BaseURL)
+		},
+	}
+	for p, build := range builders {
+		keys, poolCfg := cfg.GetProviderKeys(string(p))
+		sm.pools[p].reconcile(keys, poolCfg, build)
 	}
 
-	if recraftAPIKey != "" && config.AppConfig.Providers.Recraft.Enabled {
-		recraftService = NewRecraftService(recraftAPIKey)
+	downloaders := map[types.Provider]*utils.SafeDownloader{
+		types.ProviderSVGIO:   NewProviderDownloader(providers.SVGIO.AllowedDownloadHosts),
+		types.ProviderRecraft: NewProviderDownloader(providers.Recraft.AllowedDownloadHosts),
+		types.ProviderClaude:  NewProviderDownloader(nil),
 	}
 
-	if claudeAPIKey != "" && config.AppConfig.Providers.Claude.Enabled {
-		claudeService = NewClaudeService(claudeAPIKey, claudeBaseURL)
+	sm.mu.Lock()
+	sm.downloaders = downloaders
+	sm.mu.Unlock()
+}
+
+// Reload 应用新配置：按新的 API Key 和地址重建 Provider 实例和下载器，
+// 更新熔断参数并按新间隔重启凭据检查。熔断状态、延迟统计和各 Key 的使用统计在重新加载后保留
+func (sm *ServiceManager) Reload(cfg *config.Config) {
+	sm.buildProviders(cfg)
+	for _, h := range sm.health {
+		h.configure(cfg.Health)
 	}
+	sm.restartHealthChecks()
+}
+
+// NewProviderDownloader 根据配置创建限定主机白名单的下载器
+func NewProviderDownloader(allowedHosts []string) *utils.SafeDownloader {
+	download := config.Get().Security.Download
+	return utils.NewSafeDownloader(utils.DownloadPolicy{
+		AllowedHosts:         allowedHosts,
+		MaxBytes:             config.Get().GetDownloadMaxSize(),
+		MaxRedirects:         download.MaxRedirects,
+		AllowPrivateNetworks: download.AllowPrivateNetworks,
+	})
+}
 
-	return &ServiceManager{
-		svgioService:   svgioService,
-		recraftService: recraftService,
-		claudeService:  claudeService,
+// GetDownloader 获取指定Provider的资源下载器
+func (sm *ServiceManager) GetDownloader(providerType types.Provider) *utils.SafeDownloader {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if d, ok := sm.downloaders[providerType]; ok {
+		return d
 	}
+	return NewProviderDownloader(nil)
 }
 
-// RegisterProvider 注册新的Provider
+// RegisterProvider 注册新的Provider，替换该 Provider 的整个密钥池
 func (sm *ServiceManager) RegisterProvider(providerType types.Provider, provider Provider) {
-	switch providerType {
-	case types.ProviderSVGIO:
-		sm.svgioService = provider
-	case types.ProviderRecraft:
-		sm.recraftService = provider
-	case types.ProviderClaude:
-		sm.claudeService = provider
+	if pool, ok := sm.pools[providerType]; ok {
+		pool.replace(provider)
 	}
 }
 
-// GetProvider 获取指定的Provider
+// GetProvider 获取指定的Provider，未配置 API Key 或当前配置中未启用时返回 nil。
+// 返回的是密钥池中第一个 Key 的实例，生成请求应通过 GenerateImage 按策略选择 Key
 func (sm *ServiceManager) GetProvider(providerType types.Provider) Provider {
-	switch providerType {
-	case types.ProviderSVGIO:
-		return sm.svgioService
-	case types.ProviderRecraft:
-		return sm.recraftService
-	case types.ProviderClaude:
-		return sm.claudeService
-	default:
-		return sm.svgioService // 默认返回SVGIO
+	pool, ok := sm.pools[providerType]
+	if !ok {
+		// 默认返回SVGIO
+		pool, providerType = sm.pools[types.ProviderSVGIO], types.ProviderSVGIO
+	}
+	provider := pool.first()
+	if provider == nil || !config.Get().IsProviderEnabled(string(providerType)) {
+		return nil
 	}
+	return provider
 }
 
-// GenerateImage 根据提供商生成图像
+// GenerateImage 根据提供商生成图像。按密钥池策略选择 Key，Key 鉴权失败或被限流时隔离该 Key，
+// 并在请求仍有剩余时间时换用下一个 Key 重试
 func (sm *ServiceManager) GenerateImage(ctx context.Context, req types.GenerateRequest) (*types.ImageResponse, error) {
-	provider := sm.GetProvider(req.Provider)
-	if provider == nil {
+	if sm.GetProvider(req.Provider) == nil {
 		return nil, errors.New("provider not configured: " + string(req.Provider))
 	}
-	return provider.GenerateImage(ctx, req)
+
+	providerName := string(req.Provider)
+	pool := sm.pools[req.Provider]
+	key, provider, err := pool.acquire()
+	if err != nil {
+		metrics.UpstreamErrors.Inc(providerName, ClassifyError(err))
+		return nil, err
+	}
+
+	health := sm.health[req.Provider]
+	if health != nil && !health.breaker.Allow() {
+		pool.abandon(key)
+		metrics.UpstreamErrors.Inc(providerName, ErrorClassCircuitOpen)
+		return nil, ErrCircuitOpen
+	}
+
+	ctx, span := tracing.Start(ctx, providerName+".GenerateImage", tracing.WithAttributes(
+		tracing.String("provider", providerName),
+		tracing.String("model", req.Model),
+		tracing.Int("prompt.length", utf8.RuneCountInString(req.Prompt)),
+	))
+	defer span.End()
+
+	if timeout := config.Get().GetProviderTimeout(providerName); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	logger := logging.Component("service")
+	start := time.Now()
+	var img *types.ImageResponse
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(tracing.String("api_key.id", key.id), tracing.Int("api_key.attempt", attempt))
+		img, err = provider.GenerateImage(ctx, req)
+
+		class := ""
+		if err != nil {
+			class = ClassifyError(err)
+		}
+		outcome := class
+		if outcome == "" {
+			outcome = "success"
+		}
+		metrics.UpstreamKeyRequests.Inc(providerName, key.id, outcome)
+
+		if !pool.finish(key, class) {
+			break
+		}
+		logger.WarnContext(ctx, "api key quarantined", "key_id", key.id, "error_class", class)
+
+		// 换用下一个 Key 重试，每个 Key 最多尝试一次
+		if ctx.Err() != nil || attempt >= pool.size() {
+			break
+		}
+		next, nextProvider, acquireErr := pool.acquire()
+		if acquireErr != nil {
+			break
+		}
+		key, provider = next, nextProvider
+	}
+
+	if health != nil {
+		health.recordCall(time.Since(start), err)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(tracing.String("error.class", ClassifyError(err)))
+		metrics.UpstreamDuration.Observe(time.Since(start).Seconds(), providerName, "error")
+		metrics.UpstreamErrors.Inc(providerName, ClassifyError(err))
+		return nil, err
+	}
+	metrics.UpstreamDuration.Observe(time.Since(start).Seconds(), providerName, "success")
+	return img, nil
+}
+
+// ProviderKeys 返回指定 Provider 密钥池中各 Key 的使用情况
+func (sm *ServiceManager) ProviderKeys(providerType types.Provider) []types.APIKeyStatus {
+	if pool, ok := sm.pools[providerType]; ok {
+		return pool.status()
+	}
+	return nil
+}
+
+// AddProviderKey 运行时向密钥池添加 Key，返回 Key 的指纹
+func (sm *ServiceManager) AddProviderKey(providerType types.Provider, secret string) (string, error) {
+	pool, ok := sm.pools[providerType]
+	if !ok {
+		return "", errors.New("unknown provider: " + string(providerType))
+	}
+	return pool.add(secret)
+}
+
+// RemoveProviderKey 运行时从密钥池移除 Key，返回 Key 是否存在
+func (sm *ServiceManager) RemoveProviderKey(providerType types.Provider, id string) bool {
+	pool, ok := sm.pools[providerType]
+	return ok && pool.remove(id)
+}
+
+// ReleaseProviderKey 提前结束 Key 的隔离期，返回 Key 是否存在
+func (sm *ServiceManager) ReleaseProviderKey(providerType types.Provider, id string) bool {
+	pool, ok := sm.pools[providerType]
+	return ok && pool.release(id)
+}
+
+// KeyRetryAfter 返回密钥池中最早结束隔离的 Key 还需等待的时间
+func (sm *ServiceManager) KeyRetryAfter(providerType types.Provider) time.Duration {
+	pool, ok := sm.pools[providerType]
+	if !ok {
+		return 0
+	}
+	return time.Until(pool.nextRelease())
 }
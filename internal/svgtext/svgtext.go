@@ -0,0 +1,334 @@
+// Package svgtext 提取并替换 SVG 中 <text> 元素的文字。替换时只改写文字节点和需要调整的字号，
+// 其余字节（坐标、样式、注释、其他元素）原样保留
+package svgtext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrNotSVG 根元素不是 <svg>
+var ErrNotSVG = errors.New("root element is not <svg>")
+
+// growthThreshold 译文宽度超过原文的该倍数时才缩小字号
+const growthThreshold = 1.15
+
+// DefaultMinScale 字号最多缩小到原来的比例
+const DefaultMinScale = 0.5
+
+// segment 一个文字节点：text 为反转义并去掉首尾空白后的文字。start、end 不含源文件中首尾的空白字节，
+// 这些字节原样保留；lead、trail 为其余需要随译文重新写出的空白（CDATA 或实体形式的空白）
+type segment struct {
+	text        string
+	lead, trail string
+	start, end  int
+	element     int
+}
+
+// tag 一个开始标签的字节范围，fontSize 为标签自身的字号（属性或 style），没有时为空
+type tag struct {
+	start, end int
+	fontSize   string
+}
+
+// textElement 一个 <text> 元素：自身的开始标签和其中带字号的子元素标签
+type textElement struct {
+	tag           tag
+	sizedChildren []tag
+	textLength    bool
+}
+
+// Document 解析后的 SVG
+type Document struct {
+	src      []byte
+	segments []segment
+	elements []textElement
+}
+
+// Options 替换选项
+type Options struct {
+	// AdjustFontSize 译文明显变长时按宽度比例缩小字号
+	AdjustFontSize bool
+	// MinScale 字号最多缩小到的比例，0 时为 DefaultMinScale
+	MinScale float64
+}
+
+// Parse 解析 SVG，记录 <text> 元素（含 <tspan>、<textPath> 等子元素）中的文字节点
+func Parse(svg []byte) (*Document, error) {
+	doc := &Document{src: svg}
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	dec.Entity = xml.HTMLEntity
+
+	depth, textDepth := 0, 0
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse svg: %w", err)
+		}
+		end := int(dec.InputOffset())
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 && t.Name.Local != "svg" {
+				return nil, ErrNotSVG
+			}
+			depth++
+			current := tag{start: start, end: end, fontSize: fontSize(t.Attr)}
+			switch {
+			case t.Name.Local == "text" && textDepth == 0:
+				doc.elements = append(doc.elements, textElement{tag: current, textLength: hasAttr(t.Attr, "textLength")})
+				textDepth = 1
+			case textDepth > 0:
+				textDepth++
+				if current.fontSize != "" {
+					el := &doc.elements[len(doc.elements)-1]
+					el.sizedChildren = append(el.sizedChildren, current)
+				}
+				if hasAttr(t.Attr, "textLength") {
+					doc.elements[len(doc.elements)-1].textLength = true
+				}
+			}
+		case xml.EndElement:
+			depth--
+			if textDepth > 0 {
+				textDepth--
+			}
+		case xml.CharData:
+			if textDepth == 0 {
+				continue
+			}
+			raw := string(t)
+			trimmed := strings.TrimSpace(raw)
+			if trimmed == "" {
+				continue
+			}
+			lead := raw[:strings.Index(raw, trimmed)]
+			trail := raw[len(lead)+len(trimmed):]
+			if source := svg[start:end]; !bytes.HasPrefix(source, []byte("<![CDATA[")) {
+				rawLead := source[:len(source)-len(bytes.TrimLeft(source, xmlSpace))]
+				rawTrail := source[len(bytes.TrimRight(source, xmlSpace)):]
+				lead = strings.TrimPrefix(lead, normalizeNewlines(rawLead))
+				trail = strings.TrimSuffix(trail, normalizeNewlines(rawTrail))
+				start += len(rawLead)
+				end -= len(rawTrail)
+			}
+			doc.segments = append(doc.segments, segment{
+				text:    trimmed,
+				lead:    lead,
+				trail:   trail,
+				start:   start,
+				end:     end,
+				element: len(doc.elements) - 1,
+			})
+		}
+	}
+	if depth != 0 || len(svg) == 0 {
+		return nil, fmt.Errorf("parse svg: %w", io.ErrUnexpectedEOF)
+	}
+	return doc, nil
+}
+
+// Texts 返回全部文字节点的文字，顺序与 Render 的 translations 参数一致
+func (d *Document) Texts() []string {
+	texts := make([]string, len(d.segments))
+	for i, s := range d.segments {
+		texts[i] = s.text
+	}
+	return texts
+}
+
+// edit 一处字节替换
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Render 用 translations 替换文字节点，返回新的 SVG 和调整了字号的 <text> 元素数
+func (d *Document) Render(translations []string, opts Options) ([]byte, int, error) {
+	if len(translations) != len(d.segments) {
+		return nil, 0, fmt.Errorf("expected %d translations, got %d", len(d.segments), len(translations))
+	}
+	var edits []edit
+	for i, s := range d.segments {
+		edits = append(edits, edit{start: s.start, end: s.end, text: textEscaper.Replace(s.lead + translations[i] + s.trail)})
+	}
+
+	adjusted := 0
+	if opts.AdjustFontSize {
+		minScale := opts.MinScale
+		if minScale <= 0 || minScale > 1 {
+			minScale = DefaultMinScale
+		}
+		before := make([]int, len(d.elements))
+		after := make([]int, len(d.elements))
+		for i, s := range d.segments {
+			before[s.element] += displayWidth(s.text)
+			after[s.element] += displayWidth(translations[i])
+		}
+		for i, el := range d.elements {
+			if el.textLength || before[i] == 0 || float64(after[i]) <= float64(before[i])*growthThreshold {
+				continue
+			}
+			scale := max(minScale, float64(before[i])/float64(after[i]))
+			tagEdits, ok := d.scaleElement(el, scale)
+			if !ok {
+				continue
+			}
+			edits = append(edits, tagEdits...)
+			adjusted++
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		out.Write(d.src[pos:e.start])
+		out.WriteString(e.text)
+		pos = e.end
+	}
+	out.Write(d.src[pos:])
+	return out.Bytes(), adjusted, nil
+}
+
+// scaleElement 缩小 <text> 及其子元素上的字号。<text> 没有自己的字号时添加百分比字号，相对继承的字号缩小；
+// 子元素的相对字号（em、%）随父元素缩小，不再单独调整；字号无法解析（如 large）时不调整
+func (d *Document) scaleElement(el textElement, scale float64) ([]edit, bool) {
+	var edits []edit
+	for i, t := range append([]tag{el.tag}, el.sizedChildren...) {
+		raw := string(d.src[t.start:t.end])
+		var rewritten string
+		switch {
+		case t.fontSize == "" && i == 0:
+			rewritten = insertAttr(raw, "font-size", formatNumber(scale*100)+"%")
+		case i > 0 && relativeSize(t.fontSize):
+			continue
+		default:
+			size, ok := scaleSize(t.fontSize, scale)
+			if !ok {
+				return nil, false
+			}
+			rewritten = replaceFontSize(raw, size)
+		}
+		edits = append(edits, edit{start: t.start, end: t.end, text: rewritten})
+	}
+	return edits, true
+}
+
+// xmlSpace XML 中的空白字符
+const xmlSpace = " \t\r\n"
+
+// textEscaper 只转义文字节点中必须转义的字符，换行、引号等原样写出
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// normalizeNewlines 按 XML 解析器的规则把 \r\n 和单独的 \r 换成 \n
+func normalizeNewlines(b []byte) string {
+	return strings.ReplaceAll(strings.ReplaceAll(string(b), "\r\n", "\n"), "\r", "\n")
+}
+
+var (
+	fontSizeAttr  = regexp.MustCompile(`(\sfont-size\s*=\s*)("[^"]*"|'[^']*')`)
+	styleAttr     = regexp.MustCompile(`(\sstyle\s*=\s*)("[^"]*"|'[^']*')`)
+	fontSizeStyle = regexp.MustCompile(`(font-size\s*:\s*)([^;"']+)`)
+	sizeValue     = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z%]*)$`)
+)
+
+// fontSize 返回元素自身的字号，style 中的优先于 font-size 属性
+func fontSize(attrs []xml.Attr) string {
+	var size string
+	for _, a := range attrs {
+		switch a.Name.Local {
+		case "font-size":
+			if size == "" {
+				size = strings.TrimSpace(a.Value)
+			}
+		case "style":
+			if m := fontSizeStyle.FindStringSubmatch(a.Value); m != nil {
+				return strings.TrimSpace(m[2])
+			}
+		}
+	}
+	return size
+}
+
+func hasAttr(attrs []xml.Attr, name string) bool {
+	for _, a := range attrs {
+		if a.Name.Local == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleSize 按比例缩放字号的数值部分，保留单位
+func scaleSize(size string, scale float64) (string, bool) {
+	m := sizeValue.FindStringSubmatch(strings.TrimSpace(strings.TrimSuffix(size, "!important")))
+	if m == nil {
+		return "", false
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return "", false
+	}
+	return formatNumber(value*scale) + m[2], true
+}
+
+// relativeSize 字号是否相对父元素的字号
+func relativeSize(size string) bool {
+	m := sizeValue.FindStringSubmatch(strings.TrimSpace(strings.TrimSuffix(size, "!important")))
+	return m != nil && (m[2] == "em" || m[2] == "ex" || m[2] == "%")
+}
+
+// replaceFontSize 改写开始标签中的字号：style 中有 font-size 时改 style，否则改 font-size 属性
+func replaceFontSize(raw, size string) string {
+	if loc := styleAttr.FindStringSubmatchIndex(raw); loc != nil && fontSizeStyle.MatchString(raw[loc[4]:loc[5]]) {
+		style := fontSizeStyle.ReplaceAllString(raw[loc[4]:loc[5]], "${1}"+size)
+		return raw[:loc[4]] + style + raw[loc[5]:]
+	}
+	return fontSizeAttr.ReplaceAllStringFunc(raw, func(attr string) string {
+		m := fontSizeAttr.FindStringSubmatch(attr)
+		quote := m[2][:1]
+		return m[1] + quote + size + quote
+	})
+}
+
+// insertAttr 在开始标签末尾（> 或 /> 之前）添加属性
+func insertAttr(raw, name, value string) string {
+	end := len(raw) - 1
+	if strings.HasSuffix(raw, "/>") {
+		end--
+	}
+	return raw[:end] + " " + name + `="` + value + `"` + raw[end:]
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(float64(int(v*100+0.5))/100, 'f', -1, 64)
+}
+
+// displayWidth 估算文字的显示宽度：汉字、假名、谚文和全角字符按 2 计，组合附加符号不计宽度
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul),
+			r >= 0xFF00 && r <= 0xFFEF, r >= 0x3000 && r <= 0x303F:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
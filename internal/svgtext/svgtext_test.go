@@ -0,0 +1,287 @@
+package svgtext
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want []string
+	}{
+		{"plain", `<svg><text x="1">Hello</text></svg>`, []string{"Hello"}},
+		{"surrounding whitespace", "<svg><text>\n  Hello world \n</text></svg>", []string{"Hello world"}},
+		{"entities", `<svg><text>Tom &amp; Jerry &lt;3 &#169; &nbsp;</text></svg>`, []string{"Tom & Jerry <3 ©"}},
+		{"cdata", `<svg><text><![CDATA[a < b & c]]></text></svg>`, []string{"a < b & c"}},
+		{"text and cdata", `<svg><text>a &amp; <![CDATA[<b>]]></text></svg>`, []string{"a &", "<b>"}},
+		{"nested tspan", `<svg><text>Total: <tspan font-size="10">42 <tspan>kg</tspan></tspan></text></svg>`, []string{"Total:", "42", "kg"}},
+		{"text path", `<svg><text><textPath href="#p">Along</textPath></text></svg>`, []string{"Along"}},
+		{"self-closing tags", `<svg><rect width="1"/><text/><text>A<tspan dy="1"/>B</text></svg>`, []string{"A", "B"}},
+		{"outside text ignored", `<svg><title>Chart</title><desc>d</desc><style><![CDATA[text{fill:red}]]></style><text>Hi</text></svg>`, []string{"Hi"}},
+		{"no text", `<svg><rect width="1"/></svg>`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.svg))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := doc.Texts(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Texts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		svg     string
+		wantErr error
+	}{
+		{"not svg", `<html><text>Hi</text></html>`, ErrNotSVG},
+		{"empty", ``, nil},
+		{"truncated", `<svg><text>Hi</text>`, nil},
+		{"malformed", `<svg><text>Hi</tspan></svg>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.svg))
+			if err == nil {
+				t.Fatal("Parse() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name         string
+		svg          string
+		translations []string
+		opts         Options
+		want         string
+		wantAdjusted int
+	}{
+		{
+			name:         "escapes translation",
+			svg:          `<svg><text>Tom &amp; Jerry</text></svg>`,
+			translations: []string{"Tom & Jerry <3>"},
+			want:         `<svg><text>Tom &amp; Jerry &lt;3&gt;</text></svg>`,
+		},
+		{
+			name:         "keeps quotes and surrounding whitespace",
+			svg:          "<svg>\r\n<text x='1'>\r\n\t\"Hi\"\n</text>\n</svg>",
+			translations: []string{`"Hallo" 'du'`},
+			want:         "<svg>\r\n<text x='1'>\r\n\t\"Hallo\" 'du'\n</text>\n</svg>",
+		},
+		{
+			name:         "entity whitespace kept",
+			svg:          `<svg><text>&#32;Hi&#x20;</text></svg>`,
+			translations: []string{"Hallo"},
+			want:         `<svg><text> Hallo </text></svg>`,
+		},
+		{
+			name:         "cdata replaced by escaped text",
+			svg:          `<svg><text><![CDATA[ a < b ]]></text></svg>`,
+			translations: []string{"x < y"},
+			want:         `<svg><text> x &lt; y </text></svg>`,
+		},
+		{
+			name:         "self-closing tags kept",
+			svg:          `<svg><rect width="1"/><text x="0">ab<tspan dy="1"/>cd</text><text/></svg>`,
+			translations: []string{"abcd", "efgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><rect width="1"/><text x="0" font-size="50%">abcd<tspan dy="1"/>efgh</text><text/></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "self-closing tspan with font size",
+			svg:          `<svg><text font-size="20">abcd<tspan font-size="8"/></text></svg>`,
+			translations: []string{"abcdefgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="10">abcdefgh<tspan font-size="4"/></text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "tspan font size attribute",
+			svg:          `<svg><text x="0" font-size="20"><tspan font-size='10'>ab</tspan> cd</text></svg>`,
+			translations: []string{"abcd", "efgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text x="0" font-size="10"><tspan font-size='5'>abcd</tspan> efgh</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "tspan font size in style",
+			svg:          `<svg><text style="fill:red;font-size:24px" font-size="99"><tspan style="font-weight:bold; font-size: 12px">ab</tspan>cd</text></svg>`,
+			translations: []string{"abcd", "efgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text style="fill:red;font-size:12px" font-size="99"><tspan style="font-weight:bold; font-size: 6px">abcd</tspan>efgh</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "relative tspan font size follows parent",
+			svg:          `<svg><text font-size="20">ab<tspan font-size="1.5em">cd</tspan><tspan style="font-size:80%">ef</tspan></text></svg>`,
+			translations: []string{"abcd", "efgh", "ijkl"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="10">abcd<tspan font-size="1.5em">efgh</tspan><tspan style="font-size:80%">ijkl</tspan></text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "unparsable font size",
+			svg:          `<svg><text font-size="large">ab</text></svg>`,
+			translations: []string{"abcdefgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="large">abcdefgh</text></svg>`,
+		},
+		{
+			name:         "text length on text",
+			svg:          `<svg><text font-size="20" textLength="100">ab</text></svg>`,
+			translations: []string{"abcdefgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="20" textLength="100">abcdefgh</text></svg>`,
+		},
+		{
+			name:         "text length on tspan",
+			svg:          `<svg><text font-size="20"><tspan textLength="50">ab</tspan></text></svg>`,
+			translations: []string{"abcdefgh"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="20"><tspan textLength="50">abcdefgh</tspan></text></svg>`,
+		},
+		{
+			name:         "each text element scaled separately",
+			svg:          `<svg><text font-size="20">ab</text><text font-size="20">cd</text></svg>`,
+			translations: []string{"ab", "cdef"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="20">ab</text><text font-size="10">cdef</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "within growth threshold",
+			svg:          `<svg><text font-size="20">abcdefghij</text></svg>`,
+			translations: []string{"abcdefghijk"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="20">abcdefghijk</text></svg>`,
+		},
+		{
+			name:         "adjustment disabled",
+			svg:          `<svg><text font-size="20">ab</text></svg>`,
+			translations: []string{"abcdefgh"},
+			want:         `<svg><text font-size="20">abcdefgh</text></svg>`,
+		},
+		{
+			name:         "wide characters",
+			svg:          `<svg><text font-size="20">猫</text></svg>`,
+			translations: []string{"cats"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="10">cats</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "min scale floor",
+			svg:          `<svg><text font-size="20">ab</text></svg>`,
+			translations: []string{"abcdefghij"},
+			opts:         Options{AdjustFontSize: true, MinScale: 0.75},
+			want:         `<svg><text font-size="15">abcdefghij</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "min scale below ratio",
+			svg:          `<svg><text font-size="20">ab</text></svg>`,
+			translations: []string{"abcdefghij"},
+			opts:         Options{AdjustFontSize: true, MinScale: 0.1},
+			want:         `<svg><text font-size="4">abcdefghij</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "default min scale",
+			svg:          `<svg><text font-size="20">ab</text></svg>`,
+			translations: []string{"abcdefghij"},
+			opts:         Options{AdjustFontSize: true},
+			want:         `<svg><text font-size="10">abcdefghij</text></svg>`,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "invalid min scale uses default",
+			svg:          `<svg><text font-size="20">ab</text></svg>`,
+			translations: []string{"abcdefghij"},
+			opts:         Options{AdjustFontSize: true, MinScale: 2},
+			want:         `<svg><text font-size="10">abcdefghij</text></svg>`,
+			wantAdjusted: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.svg))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, adjusted, err := doc.Render(tt.translations, tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if string(got) != tt.want || adjusted != tt.wantAdjusted {
+				t.Errorf("Render() = %q, %d, want %q, %d", got, adjusted, tt.want, tt.wantAdjusted)
+			}
+		})
+	}
+}
+
+// TestRenderKeepsOtherBytes 文字节点之外的字节（声明、注释、属性写法、其他元素）原样保留
+func TestRenderKeepsOtherBytes(t *testing.T) {
+	svg := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\r\n" +
+		"<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n" +
+		"<!-- chart <text>not a node</text> -->\n" +
+		"<svg xmlns=\"http://www.w3.org/2000/svg\"   xmlns:xlink='http://www.w3.org/1999/xlink' viewBox=\"0 0 10 10\">\n" +
+		"  <style><![CDATA[ text { font: 12px sans-serif } ]]></style>\n" +
+		"  <title>Sales &amp; costs</title>\n" +
+		"  <g transform = 'translate(1,2)'><rect x=\"0\" y=\"0\"/>\n" +
+		"    <text  x = \"1\" y='2'  font-size=\"12\" >\n      Sales\n    </text>\n" +
+		"    <svg:text xmlns:svg=\"http://www.w3.org/2000/svg\">Costs<svg:tspan font-size=\"8\"> (k)</svg:tspan></svg:text>\n" +
+		"  </g>\n" +
+		"</svg>\n"
+
+	doc, err := Parse([]byte(svg))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := []string{"Sales", "Costs", "(k)"}; !reflect.DeepEqual(doc.Texts(), want) {
+		t.Fatalf("Texts() = %q, want %q", doc.Texts(), want)
+	}
+
+	got, _, err := doc.Render(doc.Texts(), Options{AdjustFontSize: true})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if string(got) != svg {
+		t.Errorf("Render(Texts()) changed the document:\n%s\nwant\n%s", got, svg)
+	}
+
+	got, _, err = doc.Render([]string{"Ventes", "Coûts", "(k€)"}, Options{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := strings.Replace(svg, "\n      Sales\n", "\n      Ventes\n", 1)
+	want = strings.Replace(want, ">Costs<", ">Coûts<", 1)
+	want = strings.Replace(want, "> (k)<", "> (k€)<", 1)
+	if string(got) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderTranslationCount(t *testing.T) {
+	doc, err := Parse([]byte(`<svg><text>a</text><text>b</text></svg>`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, _, err := doc.Render([]string{"a"}, Options{}); err == nil {
+		t.Error("Render() with too few translations error = nil, want error")
+	}
+}
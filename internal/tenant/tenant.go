@@ -0,0 +1,44 @@
+// Package tenant 调用方租户标识的校验与上下文传递
+package tenant
+
+import (
+	"context"
+
+	"svg-generator/internal/requestid"
+)
+
+// Default 未携带租户请求头时使用的租户
+const Default = "default"
+
+type contextKey struct{}
+
+type authenticatedKey struct{}
+
+// NewContext 返回携带租户标识的上下文
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// NewAuthenticatedContext 返回携带经过认证（租户 API Key 或管理令牌）的租户标识的上下文
+func NewAuthenticatedContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(NewContext(ctx, id), authenticatedKey{}, true)
+}
+
+// IsAuthenticated 上下文中的租户是否经过认证；未认证的请求归入 Default 租户
+func IsAuthenticated(ctx context.Context) bool {
+	authenticated, _ := ctx.Value(authenticatedKey{}).(bool)
+	return authenticated
+}
+
+// FromContext 获取上下文中的租户标识，不存在时返回 Default
+func FromContext(ctx context.Context) string {
+	if id, _ := ctx.Value(contextKey{}).(string); id != "" {
+		return id
+	}
+	return Default
+}
+
+// IsValid 校验租户标识，规则与请求ID相同：非空、长度受限，且只包含字母数字和 -_.:
+func IsValid(id string) bool {
+	return requestid.IsValid(id)
+}
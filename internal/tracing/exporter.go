@@ -0,0 +1,347 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter 将已结束的Span发送到后端
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// ========== OTLP/HTTP JSON 导出器 ==========
+
+// OTLPExporter 以 OTLP/HTTP JSON 编码发送到 Collector（默认 /v1/traces）
+type OTLPExporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter 创建 OTLP 导出器
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string, timeout time.Duration) *OTLPExporter {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		// 导出请求不经过追踪 Transport，避免产生递归Span
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// ExportSpans 发送一批Span
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*SpanData) error {
+	body, err := json.Marshal(buildOTLPRequest(e.serviceName, spans))
+	if err != nil {
+		return fmt.Errorf("marshal otlp request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create otlp request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export status: %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown 关闭导出器
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// ========== stdout / 文件导出器 ==========
+
+// WriterExporter 以 OTLP JSON 格式逐批写入 io.Writer，便于本地调试
+type WriterExporter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	closer      io.Closer
+	serviceName string
+}
+
+// NewWriterExporter 创建写入导出器，closer 可为 nil
+func NewWriterExporter(w io.Writer, closer io.Closer, serviceName string) *WriterExporter {
+	return &WriterExporter{w: w, closer: closer, serviceName: serviceName}
+}
+
+// ExportSpans 写入一行 JSON
+func (e *WriterExporter) ExportSpans(ctx context.Context, spans []*SpanData) error {
+	line, err := json.Marshal(buildOTLPRequest(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+// Shutdown 关闭底层文件
+func (e *WriterExporter) Shutdown(ctx context.Context) error {
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
+
+// ========== 批处理器 ==========
+
+// batchProcessor 异步批量导出，队列满时丢弃新Span
+type batchProcessor struct {
+	exporter     Exporter
+	queue        chan *SpanData
+	batchSize    int
+	interval     time.Duration
+	flushRequest chan chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+func newBatchProcessor(exporter Exporter, queueSize, batchSize int, interval time.Duration) *batchProcessor {
+	p := &batchProcessor{
+		exporter:     exporter,
+		queue:        make(chan *SpanData, queueSize),
+		batchSize:    batchSize,
+		interval:     interval,
+		flushRequest: make(chan chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) onEnd(span *SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		slog.Warn("span queue full, dropping span", "component", "tracing", "span", span.Name)
+	}
+}
+
+func (p *batchProcessor) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, p.batchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := p.exporter.ExportSpans(ctx, batch); err != nil {
+			slog.Warn("span export failed", "component", "tracing", "spans", len(batch), "error", err)
+		}
+		cancel()
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-p.queue:
+				batch = append(batch, span)
+				if len(batch) >= p.batchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= p.batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-p.flushRequest:
+			drain()
+			close(ack)
+		case <-p.done:
+			drain()
+			return
+		}
+	}
+}
+
+// forceFlush 导出队列中的全部Span
+func (p *batchProcessor) forceFlush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case p.flushRequest <- ack:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	var err error
+	p.stopOnce.Do(func() {
+		if err = p.forceFlush(ctx); err != nil {
+			return
+		}
+		close(p.done)
+		err = p.exporter.Shutdown(ctx)
+	})
+	return err
+}
+
+// ========== OTLP JSON 编码 ==========
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func buildOTLPRequest(serviceName string, spans []*SpanData) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        toOTLPAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if s.ParentSpanID.IsValid() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		for _, ev := range s.Events {
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: strconv.FormatInt(ev.Time.UnixNano(), 10),
+				Name:         ev.Name,
+				Attributes:   toOTLPAttributes(ev.Attributes),
+			})
+		}
+		out = append(out, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: toOTLPAttributes([]Attribute{String("service.name", serviceName)})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "svg-generator"},
+			Spans: out,
+		}},
+	}}}
+}
+
+func toOTLPAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpAnyValue
+		switch val := a.Value.(type) {
+		case string:
+			v.StringValue = &val
+		case int64:
+			s := strconv.FormatInt(val, 10)
+			v.IntValue = &s
+		case bool:
+			v.BoolValue = &val
+		case float64:
+			v.DoubleValue = &val
+		default:
+			s := fmt.Sprint(val)
+			v.StringValue = &s
+		}
+		out = append(out, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return out
+}
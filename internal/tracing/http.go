@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"net/http"
+)
+
+// Transport 为出站请求创建客户端Span并注入 traceparent
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport 包装基础 RoundTripper，base 为 nil 时使用 http.DefaultTransport
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 未启用追踪或不在Trace中时直接透传
+	if !Enabled() || SpanFromContext(req.Context()) == nil {
+		return t.Base.RoundTrip(req)
+	}
+
+	ctx, span := Start(req.Context(), "HTTP "+req.Method,
+		WithSpanKind(SpanKindClient),
+		WithAttributes(
+			String("http.request.method", req.Method),
+			String("server.address", req.URL.Hostname()),
+			String("url.path", req.URL.Path),
+		))
+	defer span.End()
+
+	// RoundTripper 不应修改原请求
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(StatusError, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"svg-generator/internal/config"
+)
+
+// 批处理参数
+const (
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 256
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Init 根据配置创建Tracer并设置为全局Tracer
+func Init(cfg config.TracingConfig) (*Tracer, error) {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "svg-generator"
+	}
+
+	var exporter Exporter
+	switch cfg.Exporter {
+	case "", "otlp":
+		exporter = NewOTLPExporter(cfg.Endpoint, serviceName, cfg.Headers, cfg.Timeout)
+	case "stdout":
+		exporter = NewWriterExporter(os.Stdout, nil, serviceName)
+	case "file":
+		if dir := filepath.Dir(cfg.FilePath); dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("create trace directory: %w", err)
+			}
+		}
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter = NewWriterExporter(f, f, serviceName)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %q", cfg.Exporter)
+	}
+
+	// 未配置采样率时全部采样
+	sampleRatio := cfg.SampleRatio
+	if sampleRatio == 0 {
+		sampleRatio = 1
+	}
+
+	t := &Tracer{
+		serviceName: serviceName,
+		sampleRatio: sampleRatio,
+		processor:   newBatchProcessor(exporter, defaultQueueSize, defaultBatchSize, defaultFlushInterval),
+	}
+	SetTracer(t)
+	return t, nil
+}
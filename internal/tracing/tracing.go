@@ -0,0 +1,362 @@
+// Package tracing 提供兼容 OpenTelemetry 数据模型的轻量链路追踪：
+// W3C traceparent 传播、批量导出到 OTLP/HTTP (JSON) 或本地 stdout/文件
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID 16字节追踪ID
+type TraceID [16]byte
+
+// SpanID 8字节Span ID
+type SpanID [8]byte
+
+// String 十六进制表示
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid 是否为非零ID
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// String 十六进制表示
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid 是否为非零ID
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext 可跨进程传播的Span标识
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	Remote  bool
+}
+
+// IsValid 是否包含有效的 TraceID 和 SpanID
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind Span类型，取值与 OTLP 一致
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode Span状态，取值与 OTLP 一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute Span属性
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字符串属性
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int 整数属性
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Int64 整数属性
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool 布尔属性
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Float64 浮点属性
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Event Span事件
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Span 一次操作的追踪记录；nil Span 上的方法均为空操作
+type Span struct {
+	mu sync.Mutex
+
+	tracer       *Tracer
+	spanContext  SpanContext
+	parentSpanID SpanID
+	name         string
+	kind         SpanKind
+	start        time.Time
+	end          time.Time
+	attributes   []Attribute
+	events       []Event
+	status       StatusCode
+	statusMsg    string
+	ended        bool
+}
+
+// SpanContext 返回Span标识
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// SetAttributes 设置属性
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.attributes = append(s.attributes, attrs...)
+}
+
+// AddEvent 添加事件
+func (s *Span) AddEvent(name string, attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, Event{Name: name, Time: time.Now(), Attributes: attrs})
+}
+
+// RecordError 记录错误并将状态置为 Error
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, Event{
+		Name:       "exception",
+		Time:       time.Now(),
+		Attributes: []Attribute{String("exception.message", err.Error())},
+	})
+	s.status = StatusError
+	s.statusMsg = err.Error()
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.status = code
+	s.statusMsg = msg
+}
+
+// End 结束Span并提交导出，重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := s.snapshot()
+	s.mu.Unlock()
+
+	if s.spanContext.Sampled && s.tracer != nil {
+		s.tracer.processor.onEnd(data)
+	}
+}
+
+// SpanData 已结束Span的只读快照，供导出器使用
+type SpanData struct {
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Name          string
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Events        []Event
+	Status        StatusCode
+	StatusMessage string
+}
+
+func (s *Span) snapshot() *SpanData {
+	return &SpanData{
+		TraceID:       s.spanContext.TraceID,
+		SpanID:        s.spanContext.SpanID,
+		ParentSpanID:  s.parentSpanID,
+		Name:          s.name,
+		Kind:          s.kind,
+		Start:         s.start,
+		End:           s.end,
+		Attributes:    append([]Attribute(nil), s.attributes...),
+		Events:        append([]Event(nil), s.events...),
+		Status:        s.status,
+		StatusMessage: s.statusMsg,
+	}
+}
+
+// ========== Tracer ==========
+
+// Tracer 创建Span并交给批处理器导出
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+// globalTracer 全局Tracer，为nil时追踪关闭
+var (
+	globalMu     sync.RWMutex
+	globalTracer *Tracer
+)
+
+// SetTracer 设置全局Tracer
+func SetTracer(t *Tracer) {
+	globalMu.Lock()
+	globalTracer = t
+	globalMu.Unlock()
+}
+
+func getTracer() *Tracer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return globalTracer
+}
+
+// Enabled 追踪是否已启用
+func Enabled() bool {
+	return getTracer() != nil
+}
+
+type spanContextKey struct{}
+
+// SpanFromContext 从上下文获取当前Span
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan 将Span放入上下文
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+type remoteContextKey struct{}
+
+// ContextWithRemoteSpanContext 放入从上游请求中提取的远端Span标识
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteContextKey{}, sc)
+}
+
+// SpanOption 创建Span的选项
+type SpanOption func(*Span)
+
+// WithSpanKind 指定Span类型
+func WithSpanKind(kind SpanKind) SpanOption {
+	return func(s *Span) { s.kind = kind }
+}
+
+// WithAttributes 创建时附加属性
+func WithAttributes(attrs ...Attribute) SpanOption {
+	return func(s *Span) { s.attributes = append(s.attributes, attrs...) }
+}
+
+// Start 创建子Span；追踪未启用时返回 nil Span，调用方无需判空
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	t := getTracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   SpanKindInternal,
+		start:  time.Now(),
+	}
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.spanContext.TraceID = parent.spanContext.TraceID
+		span.spanContext.Sampled = parent.spanContext.Sampled
+		span.parentSpanID = parent.spanContext.SpanID
+	} else if remote, ok := ctx.Value(remoteContextKey{}).(SpanContext); ok && remote.IsValid() {
+		span.spanContext.TraceID = remote.TraceID
+		span.spanContext.Sampled = remote.Sampled
+		span.parentSpanID = remote.SpanID
+	} else {
+		span.spanContext.TraceID = newTraceID()
+		span.spanContext.Sampled = t.shouldSample(span.spanContext.TraceID)
+	}
+	span.spanContext.SpanID = newSpanID()
+
+	for _, opt := range opts {
+		opt(span)
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// shouldSample 根据 TraceID 低8字节按比例采样，保证同一Trace的决策一致
+func (t *Tracer) shouldSample(id TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	if t.sampleRatio <= 0 {
+		return false
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// Shutdown 导出队列中剩余的Span并关闭导出器
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.processor.shutdown(ctx)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -24,6 +24,9 @@ type GenerateRequest struct {
 	// 新增：是否跳过翻译（当用户确定输入的是英文时）
 	SkipTranslate bool `json:"skip_translate,omitempty"`
 
+	// 提示词增强级别：off | light | detailed，为空时使用 enhancement.default_level
+	Enhance string `json:"enhance,omitempty"`
+
 	// Recraft 特有参数
 	Model     string `json:"model,omitempty"`    // recraftv3 或 recraftv2
 	Size      string `json:"size,omitempty"`     // 图像尺寸，如 "1024x1024"
@@ -46,12 +49,127 @@ type ImageResponse struct {
 	OriginalPrompt   string `json:"original_prompt,omitempty"`   // 原始提示词
 	TranslatedPrompt string `json:"translated_prompt,omitempty"` // 翻译后的提示词
 	WasTranslated    bool   `json:"was_translated"`              // 是否进行了翻译
+	RequestID        string `json:"request_id,omitempty"`        // 请求ID，用于问题排查
+	// 检测到的提示词语言（ISO 639-1）
+	DetectedLanguage string `json:"detected_language,omitempty"`
+	// 产生译文的翻译器名称，命中缓存时为 cache
+	Translator string `json:"translator,omitempty"`
+	// 翻译结果来自缓存
+	TranslationCached bool `json:"translation_cached,omitempty"`
+	// 翻译时命中术语的术语表版本
+	GlossaryVersion string `json:"glossary_version,omitempty"`
+	// 增强后实际发给上游的提示词和增强级别，未增强时省略
+	EnhancedPrompt   string `json:"enhanced_prompt,omitempty"`
+	EnhancementLevel string `json:"enhancement_level,omitempty"`
+	// 内容审核结果，未开启审核时省略
+	Moderation *ModerationResult `json:"moderation,omitempty"`
+	// 发往上游前被掩码的个人信息类型
+	PIIRedacted []string `json:"pii_redacted,omitempty"`
+	// 上游返回的 NSFW 标记（目前只有 SVG.IO 提供），供生成后审核使用，不直接返回给客户端
+	NSFWTextDetected    bool `json:"-"`
+	NSFWContentDetected bool `json:"-"`
+}
+
+// ModerationResult 内容审核结果
+type ModerationResult struct {
+	Level      string   `json:"level"`           // 生效的审核级别
+	Action     string   `json:"action"`          // allow | flag | block
+	Stage      string   `json:"stage,omitempty"` // 触发 flag/block 的阶段：input | output
+	Categories []string `json:"categories,omitempty"`
+	Reasons    []string `json:"reasons,omitempty"`
 }
 
 type ErrorResp struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Details interface{} `json:"details,omitempty"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// FieldError 单个字段的校验错误，作为 ErrorResp.Details 的元素返回
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SVGTranslateRequest 翻译 SVG 中文字的请求
+type SVGTranslateRequest struct {
+	SVG string `json:"svg"`
+	// TargetLanguage 目标语言（ISO 639-1），默认 en
+	TargetLanguage string `json:"target_language,omitempty"`
+	// AdjustFontSize 译文明显变长时缩小字号
+	AdjustFontSize bool `json:"adjust_font_size,omitempty"`
+	// MinFontScale 字号最多缩小到的比例（0.1-1），默认 0.5
+	MinFontScale float64 `json:"min_font_scale,omitempty"`
+}
+
+// SVGTranslateResponse 翻译后的 SVG
+type SVGTranslateResponse struct {
+	SVG            string `json:"svg"`
+	TargetLanguage string `json:"target_language"`
+	// Segments 翻译的文字节点数
+	Segments int `json:"segments"`
+	// FontAdjusted 调整了字号的 <text> 元素数
+	FontAdjusted      int    `json:"font_adjusted,omitempty"`
+	Translator        string `json:"translator,omitempty"`
+	TranslationCached bool   `json:"translation_cached,omitempty"`
+	// PIIRedacted 发往翻译服务前被掩码的个人信息类型，未检测到时省略
+	PIIRedacted []string `json:"pii_redacted,omitempty"`
+	RequestID   string   `json:"request_id,omitempty"`
+}
+
+// ProviderHealth 单个 Provider 的健康状况，由 /health/providers 返回
+type ProviderHealth struct {
+	Provider      Provider          `json:"provider"`
+	Configured    bool              `json:"configured"` // 是否配置了 API Key
+	Enabled       bool              `json:"enabled"`    // 配置文件中是否启用
+	Status        string            `json:"status"`     // healthy, degraded, unavailable, disabled
+	LastCall      *LastCallStatus   `json:"last_call,omitempty"`
+	Credentials   *CredentialStatus `json:"credentials,omitempty"`
+	Breaker       BreakerStatus     `json:"breaker"`
+	LatencyP95Ms  float64           `json:"latency_p95_ms"`
+	Samples       int               `json:"latency_samples"`
+	KeysTotal     int               `json:"keys_total"`     // 密钥池中的 Key 数量
+	KeysAvailable int               `json:"keys_available"` // 未被隔离的 Key 数量
+}
+
+// APIKeyStatus 密钥池中单个 API Key 的使用情况，不包含 Key 本身
+type APIKeyStatus struct {
+	ID               string            `json:"id"`     // Key 的指纹
+	Hint             string            `json:"hint"`   // Key 末尾几位，便于辨认
+	Source           string            `json:"source"` // config, runtime
+	InFlight         int64             `json:"in_flight"`
+	Requests         int64             `json:"requests"`
+	Successes        int64             `json:"successes"`
+	Failures         int64             `json:"failures"`
+	LastUsed         *time.Time        `json:"last_used,omitempty"`
+	LastErrorClass   string            `json:"last_error_class,omitempty"`
+	QuarantinedUntil *time.Time        `json:"quarantined_until,omitempty"`
+	QuarantineReason string            `json:"quarantine_reason,omitempty"`
+	Credentials      *CredentialStatus `json:"credentials,omitempty"`
+}
+
+// LastCallStatus 最近一次上游调用结果
+type LastCallStatus struct {
+	At         time.Time `json:"at"`
+	Success    bool      `json:"success"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+}
+
+// CredentialStatus 缓存的凭据检查结果
+type CredentialStatus struct {
+	CheckedAt  time.Time `json:"checked_at"`
+	Valid      bool      `json:"valid"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// BreakerStatus 熔断器状态
+type BreakerStatus struct {
+	State               string     `json:"state"` // closed, open, half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
 }
 
 // SVG.IO 上游 API 相关类型
@@ -130,7 +248,7 @@ type ClaudeGenerateReq struct {
 	Model       string          `json:"model"`
 	Messages    []ClaudeMessage `json:"messages"`
 	MaxTokens   int             `json:"max_tokens"`
-	Temperature float64         `json:"temperature,omitempty"`
+	Temperature float64         `json:"temperature"` // 0 表示确定性输出，不能省略
 	System      string          `json:"system,omitempty"`
 }
 
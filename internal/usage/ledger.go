@@ -0,0 +1,212 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+)
+
+// Entry 用量台账中的一条记录，对应一次到达上游调用阶段的生成请求
+type Entry struct {
+	Time           time.Time `json:"time"`
+	RequestID      string    `json:"request_id,omitempty"`
+	Tenant         string    `json:"tenant"`
+	Provider       string    `json:"provider"`
+	Model          string    `json:"model,omitempty"`
+	Route          string    `json:"route"`
+	Outcome        string    `json:"outcome"` // success 或错误分类
+	InputTokens    int       `json:"input_tokens"`
+	OutputTokens   int       `json:"output_tokens"`
+	Images         int       `json:"images"`
+	VectorizeCalls int       `json:"vectorize_calls"`
+	Cost           float64   `json:"cost"`
+	Currency       string    `json:"currency"`
+	DurationMs     int64     `json:"duration_ms"`
+}
+
+// OutcomeSuccess 成功请求的 Outcome
+const OutcomeSuccess = "success"
+
+// Ledger 用量台账：配置了 usage.ledger_path 时追加写入 JSON Lines 文件，
+// 否则在内存中保留最近的 usage.max_memory_entries 条记录，重启后丢失
+type Ledger struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	memory     []Entry
+	maxEntries int
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLedger *Ledger
+	subscribers   []func(Entry)
+)
+
+// Open 按配置打开台账，ledger_path 的父目录不存在时自动创建
+func Open(cfg config.UsageConfig) (*Ledger, error) {
+	l := &Ledger{path: cfg.LedgerPath, maxEntries: cfg.GetMaxMemoryEntries()}
+	if l.path == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return nil, fmt.Errorf("create ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Init 打开台账并设为 Record 和 Default 使用的全局台账
+func Init(cfg config.UsageConfig) (*Ledger, error) {
+	l, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defaultMu.Lock()
+	defaultLedger = l
+	defaultMu.Unlock()
+	return l, nil
+}
+
+// Default 返回全局台账，未初始化时返回 nil
+func Default() *Ledger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLedger
+}
+
+// OnRecord 注册写入台账后的回调，回调在请求 goroutine 中同步执行，应尽快返回。
+// 需在开始处理请求前注册
+func OnRecord(fn func(Entry)) {
+	defaultMu.Lock()
+	subscribers = append(subscribers, fn)
+	defaultMu.Unlock()
+}
+
+// Record 按当前配置的价格计算成本并写入全局台账；未开启用量核算或台账未初始化时忽略
+func Record(e Entry, m *Meter) {
+	cfg := config.Get().Usage
+	l := Default()
+	if !cfg.Enabled || l == nil {
+		return
+	}
+	m.fill(&e)
+	e.Currency = cfg.GetCurrency()
+	e.Cost = Cost(e, cfg.Pricing.GetPrice(e.Provider))
+	if err := l.Append(e); err != nil {
+		logging.Component("usage").Error("failed to write usage ledger", "request_id", e.RequestID, "error", err)
+	}
+	if e.Cost > 0 {
+		metrics.UsageCost.Add(e.Cost, e.Provider, e.Currency)
+	}
+
+	defaultMu.RLock()
+	fns := subscribers
+	defaultMu.RUnlock()
+	for _, fn := range fns {
+		fn(e)
+	}
+}
+
+// Cost 按价格计算一条记录的成本，保留 6 位小数
+func Cost(e Entry, price config.PriceConfig) float64 {
+	cost := float64(e.InputTokens)/1000*price.InputPer1KTokens +
+		float64(e.OutputTokens)/1000*price.OutputPer1KTokens +
+		float64(e.Images)*price.PerImage +
+		float64(e.VectorizeCalls)*price.PerVectorize
+	return math.Round(cost*1e6) / 1e6
+}
+
+// Append 写入一条记录
+func (l *Ledger) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		if len(l.memory) >= l.maxEntries {
+			// 丢弃最旧的记录，整体前移避免底层数组无限增长
+			n := copy(l.memory, l.memory[len(l.memory)-l.maxEntries+1:])
+			l.memory = l.memory[:n]
+		}
+		l.memory = append(l.memory, e)
+		return nil
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+// Query 返回 [from, to) 时间范围内满足 match 的记录，按写入顺序排列；match 为 nil 时不过滤
+func (l *Ledger) Query(from, to time.Time, match func(Entry) bool) ([]Entry, error) {
+	keep := func(e Entry) bool {
+		return !e.Time.Before(from) && e.Time.Before(to) && (match == nil || match(e))
+	}
+
+	l.mu.Lock()
+	if l.file == nil {
+		var out []Entry
+		for _, e := range l.memory {
+			if keep(e) {
+				out = append(out, e)
+			}
+		}
+		l.mu.Unlock()
+		return out, nil
+	}
+	l.mu.Unlock()
+
+	// 文件模式：另开只读句柄扫描，写入不受影响；最后一行可能正在写入，解析失败的行跳过
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var out []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ledger: %w", err)
+	}
+	return out, nil
+}
+
+// Close 关闭台账文件
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Sync()
+	err = errors.Join(err, l.file.Close())
+	l.file = nil
+	return err
+}
@@ -0,0 +1,99 @@
+// Package usage 按租户和 Provider 记录每次生成请求的用量与成本
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+// Meter 单次请求的用量计数，由各 Provider 服务在调用上游成功后累加。
+// nil Meter 的方法均为空操作，未开启用量核算时服务代码无需判断
+type Meter struct {
+	mu             sync.Mutex
+	model          string
+	inputTokens    int
+	outputTokens   int
+	images         int
+	vectorizeCalls int
+}
+
+type contextKey struct{}
+
+// NewContext 返回携带新 Meter 的上下文
+func NewContext(ctx context.Context) (context.Context, *Meter) {
+	m := &Meter{}
+	return context.WithValue(ctx, contextKey{}, m), m
+}
+
+// FromContext 获取上下文中的 Meter，不存在时返回 nil
+func FromContext(ctx context.Context) *Meter {
+	m, _ := ctx.Value(contextKey{}).(*Meter)
+	return m
+}
+
+// SetModel 记录实际使用的模型
+func (m *Meter) SetModel(model string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.model = model
+	m.mu.Unlock()
+}
+
+// AddTokens 累加输入、输出 token 数
+func (m *Meter) AddTokens(input, output int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.inputTokens += input
+	m.outputTokens += output
+	m.mu.Unlock()
+}
+
+// AddImages 累加生成的图片数
+func (m *Meter) AddImages(n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.images += n
+	m.mu.Unlock()
+}
+
+// AddVectorizeCalls 累加成功的向量化调用次数
+func (m *Meter) AddVectorizeCalls(n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.vectorizeCalls += n
+	m.mu.Unlock()
+}
+
+// Model 返回服务记录的实际模型，未记录时返回空字符串
+func (m *Meter) Model() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.model
+}
+
+// fill 将计数写入台账记录
+func (m *Meter) fill(e *Entry) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.model != "" {
+		e.Model = m.model
+	}
+	e.InputTokens = m.inputTokens
+	e.OutputTokens = m.outputTokens
+	e.Images = m.images
+	e.VectorizeCalls = m.vectorizeCalls
+}
@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"math"
+	"sort"
+)
+
+// 汇总维度
+const (
+	GroupByTenant   = "tenant"
+	GroupByProvider = "provider"
+	GroupByModel    = "model"
+)
+
+// Summary 一组记录的汇总
+type Summary struct {
+	Key            string  `json:"key,omitempty"`
+	Requests       int     `json:"requests"`
+	Successes      int     `json:"successes"`
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	Images         int     `json:"images"`
+	VectorizeCalls int     `json:"vectorize_calls"`
+	Cost           float64 `json:"cost"`
+}
+
+func (s *Summary) add(e Entry) {
+	s.Requests++
+	if e.Outcome == OutcomeSuccess {
+		s.Successes++
+	}
+	s.InputTokens += e.InputTokens
+	s.OutputTokens += e.OutputTokens
+	s.Images += e.Images
+	s.VectorizeCalls += e.VectorizeCalls
+	s.Cost += e.Cost
+}
+
+// IsGroupBy 判断是否为支持的汇总维度
+func IsGroupBy(groupBy string) bool {
+	switch groupBy {
+	case GroupByTenant, GroupByProvider, GroupByModel:
+		return true
+	}
+	return false
+}
+
+// Summarize 按维度汇总记录，分组按成本从高到低排列；groupBy 为空时只返回总计
+func Summarize(entries []Entry, groupBy string) (groups []Summary, total Summary) {
+	index := make(map[string]int)
+	for _, e := range entries {
+		total.add(e)
+		if groupBy == "" {
+			continue
+		}
+		key := groupKey(e, groupBy)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Summary{Key: key})
+		}
+		groups[i].add(e)
+	}
+
+	total.Cost = roundCost(total.Cost)
+	for i := range groups {
+		groups[i].Cost = roundCost(groups[i].Cost)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Cost != groups[j].Cost {
+			return groups[i].Cost > groups[j].Cost
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, total
+}
+
+func groupKey(e Entry, groupBy string) string {
+	switch groupBy {
+	case GroupByTenant:
+		return e.Tenant
+	case GroupByProvider:
+		return e.Provider
+	default:
+		if e.Model == "" {
+			return "unknown"
+		}
+		return e.Model
+	}
+}
+
+func roundCost(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -2,19 +2,46 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os" // 创建服务管理器
+	"os/signal"
+	"svg-generator/internal/audit"
+	"svg-generator/internal/budget"
+	"svg-generator/internal/cache"
 	"svg-generator/internal/config"
+	"svg-generator/internal/glossary"
 	"svg-generator/internal/handlers"
+	"svg-generator/internal/lifecycle"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
 	"svg-generator/internal/service"
+	"svg-generator/internal/tracing"
+	"svg-generator/internal/usage"
 	"svg-generator/pkg/utils"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 func main() {
-	log.Printf("Starting multi-provider SVG image generation service...")
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		_ = godotenv.Load(".env")
+		os.Exit(config.RunCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		_ = godotenv.Load(".env")
+		os.Exit(audit.RunCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	printConfig := flag.Bool("print-config", false, "print the merged configuration (secrets redacted) and exit")
+	flag.Parse()
 
 	// 加载环境变量
 	_ = godotenv.Load(".env")
@@ -26,75 +53,211 @@ func main() {
 	}
 
 	if err := config.InitConfig(configPath); err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", "error", err)
 	}
 
-	log.Printf("Configuration loaded successfully from: %s", configPath)
+	if *printConfig {
+		out, err := yaml.Marshal(config.Get().Redacted())
+		if err != nil {
+			fatal("Failed to encode configuration", "error", err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
 
-	// 加载 API 密钥
-	svgioAPIKey := os.Getenv("SVGIO_API_KEY")
-	recraftAPIKey := os.Getenv("RECRAFT_API_KEY")
-	claudeAPIKey := os.Getenv("CLAUDE_API_KEY")
-	claudeBaseURL := os.Getenv("CLAUDE_BASE_URL")
+	slog.Info("Starting multi-provider SVG image generation service...")
 
-	// 验证至少有一个Provider可用
-	enabledProviders := 0
-	if svgioAPIKey != "" && config.AppConfig.IsProviderEnabled("svgio") {
-		log.Printf("SVG.IO API key loaded successfully (length: %d)", len(svgioAPIKey))
-		enabledProviders++
+	// 初始化结构化日志
+	_, logCloser, err := logging.Init(config.Get().Logging)
+	if err != nil {
+		fatal("Failed to initialize logging", "error", err)
 	}
-	if recraftAPIKey != "" && config.AppConfig.IsProviderEnabled("recraft") {
-		log.Printf("Recraft API key loaded successfully (length: %d)", len(recraftAPIKey))
-		enabledProviders++
+	// 日志最先注册、最后关闭，保证其他钩子的日志能够输出
+	lifecycle.OnShutdown("logging", func(ctx context.Context) error {
+		return logCloser.Close()
+	})
+
+	slog.Info("Configuration loaded successfully", "files", config.ConfigFiles(configPath), "app_env", os.Getenv("APP_ENV"))
+
+	// 初始化链路追踪
+	if config.Get().Features.EnableTracing {
+		tracer, err := tracing.Init(config.Get().Tracing)
+		if err != nil {
+			fatal("Failed to initialize tracing", "error", err)
+		}
+		lifecycle.OnShutdown("tracing", tracer.Shutdown)
+		slog.Info("Tracing enabled", "exporter", config.Get().Tracing.Exporter)
 	}
-	if claudeAPIKey != "" && config.AppConfig.IsProviderEnabled("claude") {
-		log.Printf("Claude API key loaded successfully (length: %d)", len(claudeAPIKey))
-		enabledProviders++
+
+	// 初始化用量台账；未开启用量核算时也打开，以便通过重新加载配置开启
+	ledger, err := usage.Init(config.Get().Usage)
+	if err != nil {
+		fatal("Failed to open usage ledger", "error", err)
+	}
+	lifecycle.OnShutdown("usage-ledger", func(context.Context) error {
+		return ledger.Close()
+	})
+	budget.Init(ledger)
+
+	// 初始化审计日志
+	if auditCfg := config.Get().Audit; auditCfg.Enabled {
+		auditLog, err := audit.Init(auditCfg)
+		if err != nil {
+			fatal("Failed to open audit log", "error", err)
+		}
+		lifecycle.OnShutdown("audit-log", func(context.Context) error {
+			return auditLog.Close()
+		})
+		slog.Info("Audit log enabled", "path", auditLog.Path())
+	}
+
+	// 打开术语表存储；未开启术语表时也打开，以便先通过管理接口维护再开启
+	glossaryCfg := config.Get().Translation.Glossary
+	if glossaries, err := glossary.Init(glossaryCfg.GetDir()); err != nil {
+		if glossaryCfg.Enabled {
+			fatal("Failed to open glossary store", "error", err)
+		}
+		slog.Warn("Failed to open glossary store", "dir", glossaryCfg.GetDir(), "error", err)
+	} else if glossaryCfg.Enabled {
+		slog.Info("Glossary enabled", "dir", glossaryCfg.GetDir(), "tenants", len(glossaries.Tenants()))
+	}
+
+	// 验证至少有一个Provider可用（API Key 来自配置、SVGGEN_* 或旧版环境变量、密钥文件）
+	enabledProviders := 0
+	available := make(map[string]bool)
+	for _, name := range []string{"svgio", "recraft", "claude"} {
+		keys, _ := config.Get().GetProviderKeys(name)
+		if len(keys) > 0 && config.Get().IsProviderEnabled(name) {
+			slog.Info("API keys loaded successfully", "provider", name, "keys", len(keys))
+			available[name] = true
+			enabledProviders++
+		}
 	}
 
 	if enabledProviders == 0 {
-		log.Fatal("No providers available: either API keys are missing or all providers are disabled in config")
+		fatal("No providers available: either API keys are missing or all providers are disabled in config")
 	}
 
 	// 初始化服务管理器
-	serviceManager := service.NewServiceManager(svgioAPIKey, recraftAPIKey, claudeAPIKey, claudeBaseURL)
-	log.Printf("Service manager initialized with available providers")
-
-	// 初始化翻译服务
-	var translateService utils.TranslateService
-	translateAPIKey := os.Getenv("OPENAI_API_KEY")
-	if translateAPIKey != "" && config.AppConfig.Translation.Enabled {
-		translateService = utils.NewOpenAITranslateService(translateAPIKey)
-		log.Printf("Translation service initialized with OpenAI")
+	serviceManager := service.NewServiceManager()
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	serviceManager.StartHealthChecks(healthCtx)
+	lifecycle.OnShutdown("health-checks", func(ctx context.Context) error {
+		stopHealthChecks()
+		return nil
+	})
+
+	// 配置热更新：重新加载成功后依次应用到日志和服务管理器
+	config.OnReload(func(old, new *config.Config) {
+		if err := logging.Apply(new.Logging); err != nil {
+			slog.Warn("Failed to apply logging config", "error", err)
+		}
+		serviceManager.Reload(new)
+	})
+	watchReload(configPath)
+	slog.Info("Service manager initialized with available providers")
+
+	// 初始化翻译服务：翻译器链始终创建，每次调用时按当前配置构建翻译器，是否翻译由处理器按
+	// translation.enabled 判断，因此重新加载配置或管理接口可以在运行时开启翻译、更换 API Key
+	var translators []string
+	for _, tc := range config.Get().Translation.GetTranslators() {
+		if tc.APIKey != "" || tc.Type == config.TranslatorDictionary {
+			translators = append(translators, tc.Name)
+		}
+	}
+	var translateService utils.TranslateService = utils.NewChainTranslateService()
+	if len(translators) > 0 && config.Get().Translation.Enabled {
+		slog.Info("Translation service initialized", "translators", translators)
 	} else {
-		log.Printf("Warning: Translation service disabled or OPENAI_API_KEY not found")
+		slog.Warn("Translation service disabled or no translator has an api_key")
+	}
+	if cacheCfg := config.Get().Translation.Cache; cacheCfg.Enabled {
+		var store cache.Store
+		if cacheCfg.GetBackend() == "redis" {
+			redisStore, err := cache.NewRedis(cacheCfg.Redis)
+			if err != nil {
+				fatal("Failed to configure translation cache", "error", err)
+			}
+			lifecycle.OnShutdown("translation-cache", func(context.Context) error {
+				return redisStore.Close()
+			})
+			store = redisStore
+		} else {
+			store = cache.NewLRU(cacheCfg.GetMaxEntries())
+		}
+		cached := utils.NewCachedTranslateService(translateService, store)
+		cache.Register("translation", cached)
+		if cacheCfg.WarmFile != "" {
+			n, err := cached.Warm(context.Background(), cacheCfg.WarmFile)
+			if err != nil {
+				slog.Warn("Failed to warm translation cache", "file", cacheCfg.WarmFile, "loaded", n, "error", err)
+			} else {
+				slog.Info("Translation cache warmed", "file", cacheCfg.WarmFile, "entries", n)
+			}
+		}
+		translateService = cached
+		slog.Info("Translation cache enabled", "backend", cacheCfg.GetBackend(), "ttl", cacheCfg.GetTTL().String())
+	}
+	// 术语表在缓存之外处理，缓存键使用替换术语后的文本
+	translateService = utils.NewGlossaryTranslateService(translateService)
+
+	// 提示词增强在请求时读取当前配置，可以通过重新加载配置开启或调整
+	if enhancement := config.Get().GetEnhancement(); enhancement.Enabled {
+		slog.Info("Prompt enhancement enabled", "model", enhancement.Model, "default_level", enhancement.DefaultLevel)
 	}
 
 	mux := http.NewServeMux()
 
+	// 所有 Provider 都注册路由，是否可用在请求时按当前配置判断，
+	// 以便通过重新加载配置启用、禁用 Provider 或更换 API Key
 	// 注册路由处理器 - SVG.IO 提供商
-	if svgioAPIKey != "" && config.AppConfig.IsProviderEnabled("svgio") {
-		mux.HandleFunc("/v1/images/svgio/svg", handlers.SVGHandler(serviceManager, translateService))
-		mux.HandleFunc("/v1/images/svgio", handlers.ImageHandler(serviceManager, translateService))
-		log.Printf("SVG.IO routes registered")
-	}
+	mux.HandleFunc("/v1/images/svgio/svg", instrument("/v1/images/svgio/svg", "svgio", handlers.SVGHandler(serviceManager, translateService)))
+	mux.HandleFunc("/v1/images/svgio", instrument("/v1/images/svgio", "svgio", handlers.ImageHandler(serviceManager, translateService)))
 
 	// 注册路由处理器 - Recraft 提供商
-	if recraftAPIKey != "" && config.AppConfig.IsProviderEnabled("recraft") {
-		mux.HandleFunc("/v1/images/recraft/svg", handlers.RecraftSVGHandler(serviceManager, translateService))
-		mux.HandleFunc("/v1/images/recraft", handlers.RecraftImageHandler(serviceManager, translateService))
-		log.Printf("Recraft routes registered")
-	}
+	mux.HandleFunc("/v1/images/recraft/svg", instrument("/v1/images/recraft/svg", "recraft", handlers.RecraftSVGHandler(serviceManager, translateService)))
+	mux.HandleFunc("/v1/images/recraft", instrument("/v1/images/recraft", "recraft", handlers.RecraftImageHandler(serviceManager, translateService)))
 
 	// 注册路由处理器 - Claude 提供商
-	if claudeAPIKey != "" && config.AppConfig.IsProviderEnabled("claude") {
-		mux.HandleFunc("/v1/images/claude/svg", handlers.ClaudeSVGHandler(serviceManager, translateService))
-		mux.HandleFunc("/v1/images/claude", handlers.ClaudeImageHandler(serviceManager, translateService))
-		log.Printf("Claude routes registered")
-	}
+	mux.HandleFunc("/v1/images/claude/svg", instrument("/v1/images/claude/svg", "claude", handlers.ClaudeSVGHandler(serviceManager, translateService)))
+	mux.HandleFunc("/v1/images/claude", instrument("/v1/images/claude", "claude", handlers.ClaudeImageHandler(serviceManager, translateService)))
+
+	// 翻译 SVG 中的文字
+	mux.HandleFunc("/v1/svg/translate", instrument("/v1/svg/translate", "", handlers.SVGTranslateHandler(translateService)))
 
 	// 通用路由
 	mux.HandleFunc("/health", handlers.HealthHandler())
+	mux.HandleFunc("/healthz", handlers.LivenessHandler())
+	mux.HandleFunc("/readyz", handlers.ReadinessHandler(serviceManager))
+	mux.HandleFunc("/health/providers", handlers.ProviderHealthHandler(serviceManager))
+	if config.Get().Features.EnableMetrics {
+		mux.HandleFunc("/metrics", metrics.Handler())
+		slog.Info("Metrics endpoint registered")
+	}
+
+	// 管理接口始终注册，admin.enabled 为 false 时返回 404，可通过重新加载配置开启
+	// 用量与成本查询
+	mux.HandleFunc("/v1/usage", handlers.UsageHandler())
+
+	mux.HandleFunc("/admin/providers", handlers.AdminAuth(handlers.AdminProvidersHandler(serviceManager)))
+	mux.HandleFunc("/admin/providers/{name}", handlers.AdminAuth(handlers.AdminProviderHandler(serviceManager)))
+	mux.HandleFunc("/admin/providers/{name}/{action}", handlers.AdminAuth(handlers.AdminProviderHandler(serviceManager)))
+	mux.HandleFunc("/admin/providers/{name}/keys", handlers.AdminAuth(handlers.AdminProviderKeysHandler(serviceManager)))
+	mux.HandleFunc("/admin/providers/{name}/keys/{id}", handlers.AdminAuth(handlers.AdminProviderKeysHandler(serviceManager)))
+	mux.HandleFunc("/admin/providers/{name}/keys/{id}/{action}", handlers.AdminAuth(handlers.AdminProviderKeysHandler(serviceManager)))
+	mux.HandleFunc("/admin/settings", handlers.AdminAuth(handlers.AdminSettingsHandler()))
+	mux.HandleFunc("/admin/flags", handlers.AdminAuth(handlers.AdminFlagsHandler()))
+	mux.HandleFunc("/admin/caches", handlers.AdminAuth(handlers.AdminCachesHandler()))
+	mux.HandleFunc("/admin/caches/flush", handlers.AdminAuth(handlers.AdminCachesHandler()))
+	mux.HandleFunc("/admin/budgets", handlers.AdminAuth(handlers.AdminBudgetsHandler()))
+	mux.HandleFunc("/admin/audit", handlers.AdminAuth(handlers.AdminAuditHandler()))
+	mux.HandleFunc("/admin/audit/verify", handlers.AdminAuth(handlers.AdminAuditHandler()))
+	mux.HandleFunc("/admin/jobs", handlers.AdminAuth(handlers.AdminJobsHandler()))
+	mux.HandleFunc("/admin/glossaries", handlers.AdminAuth(handlers.AdminGlossariesHandler()))
+	mux.HandleFunc("/admin/glossaries/{tenant}", handlers.AdminAuth(handlers.AdminGlossariesHandler()))
+	mux.HandleFunc("/admin/glossaries/{tenant}/{action}", handlers.AdminAuth(handlers.AdminGlossariesHandler()))
+	mux.HandleFunc("/admin/glossaries/{tenant}/{action}/{version}", handlers.AdminAuth(handlers.AdminGlossariesHandler()))
+	mux.HandleFunc("/admin/jobs/{id}", handlers.AdminAuth(handlers.AdminJobsHandler()))
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodOptions {
 			handlers.CORSPreflight()(w, r)
@@ -103,24 +266,145 @@ func main() {
 		http.NotFound(w, r)
 	})
 
-	addr := config.AppConfig.GetServerAddr()
-	log.Printf("listening on %s", addr)
-	log.Printf("Available endpoints:")
-	if svgioAPIKey != "" && config.AppConfig.IsProviderEnabled("svgio") {
-		log.Printf("  - POST /v1/images/svgio/svg   (SVG.IO - direct SVG download)")
-		log.Printf("  - POST /v1/images/svgio       (SVG.IO - JSON metadata)")
+	addr := config.Get().GetServerAddr()
+	slog.Info("listening", "addr", addr)
+	slog.Info("Available endpoints:")
+	if available["svgio"] {
+		slog.Info("  - POST /v1/images/svgio/svg   (SVG.IO - direct SVG download)")
+		slog.Info("  - POST /v1/images/svgio       (SVG.IO - JSON metadata)")
+	}
+	if available["recraft"] {
+		slog.Info("  - POST /v1/images/recraft/svg (Recraft - direct SVG download)")
+		slog.Info("  - POST /v1/images/recraft     (Recraft - JSON metadata)")
 	}
-	if recraftAPIKey != "" && config.AppConfig.IsProviderEnabled("recraft") {
-		log.Printf("  - POST /v1/images/recraft/svg (Recraft - direct SVG download)")
-		log.Printf("  - POST /v1/images/recraft     (Recraft - JSON metadata)")
+	if available["claude"] {
+		slog.Info("  - POST /v1/images/claude/svg  (Claude - direct SVG download)")
+		slog.Info("  - POST /v1/images/claude      (Claude - JSON metadata)")
 	}
-	if claudeAPIKey != "" && config.AppConfig.IsProviderEnabled("claude") {
-		log.Printf("  - POST /v1/images/claude/svg  (Claude - direct SVG download)")
-		log.Printf("  - POST /v1/images/claude      (Claude - JSON metadata)")
+	slog.Info("  - POST /v1/svg/translate       (Translate text inside an SVG)")
+	slog.Info("  - GET  /health                 (Health check)")
+	slog.Info("  - GET  /healthz                (Liveness probe)")
+	slog.Info("  - GET  /readyz                 (Readiness probe)")
+	slog.Info("  - GET  /health/providers       (Provider health details)")
+	if config.Get().Features.EnableMetrics {
+		slog.Info("  - GET  /metrics                (Prometheus metrics)")
 	}
-	log.Printf("  - GET  /health                 (Health check)")
+	if config.Get().Usage.Enabled {
+		slog.Info("  - GET  /v1/usage               (Usage and cost report)")
+	}
+	if config.Get().Admin.Enabled {
+		slog.Info("  - *    /admin/...              (Admin API, requires admin.token)")
+	}
+
+	// 公共头在最外层：WithTenant 拒绝请求时的错误响应同样带有 CORS 和安全头，并记录请求日志
+	var handler http.Handler = utils.WithCommonHeaders(utils.WithTenant(mux))
+	if config.Get().Security.EnableRequestID {
+		handler = utils.WithRequestID(handler)
+	}
+
+	serverCfg := config.Get().Server
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       serverCfg.ReadTimeout,
+		ReadHeaderTimeout: serverCfg.ReadTimeout,
+		WriteTimeout:      serverCfg.WriteTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
+	}
+
+	// 监听 SIGINT/SIGTERM
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	lifecycle.SetReady(true)
+
+	select {
+	case err := <-serverErr:
+		fatal("Server stopped", "error", err)
+	case <-signalCtx.Done():
+	}
+	stop()
+
+	shutdown(srv)
+}
+
+// watchReload 监听配置文件变化和 SIGHUP，触发配置重新加载
+func watchReload(configPath string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.OnShutdown("config-watch", func(context.Context) error {
+		cancel()
+		return nil
+	})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				signal.Stop(hup)
+				return
+			case <-hup:
+				slog.Info("SIGHUP received, reloading configuration", "path", configPath)
+				config.ReloadAndLog(configPath)
+			}
+		}
+	}()
+
+	if reloadCfg := config.Get().Reload; reloadCfg.Watch {
+		go config.Watch(ctx, configPath, reloadCfg.Interval)
+		slog.Info("Watching configuration file for changes", "path", configPath)
+	}
+}
+
+// shutdown 优雅退出：先置为未就绪并停止接收新任务，等待负载均衡摘除后排空连接，最后执行清理钩子
+func shutdown(srv *http.Server) {
+	serverCfg := config.Get().Server
+	slog.Info("Shutdown signal received, draining",
+		"shutdown_delay", serverCfg.ShutdownDelay.String(),
+		"shutdown_timeout", serverCfg.GetShutdownTimeout().String())
+
+	lifecycle.BeginDrain()
+	if serverCfg.ShutdownDelay > 0 {
+		time.Sleep(serverCfg.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverCfg.GetShutdownTimeout())
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Grace period expired, closing remaining connections", "error", err)
+		_ = srv.Close()
+	} else {
+		slog.Info("All connections drained")
+	}
+
+	// 清理钩子使用独立的超时，避免排空耗尽时间后无法刷新日志和追踪数据
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer hookCancel()
+	slog.Info("Flushing pending telemetry and closing resources")
+	if err := lifecycle.RunShutdownHooks(hookCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "shutdown completed with errors: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
 
-	if err := http.ListenAndServe(addr, utils.WithCommonHeaders(mux)); err != nil {
-		log.Fatal(err)
+// instrument 启用指标采集时为路由记录请求数和耗时
+func instrument(route, provider string, h http.HandlerFunc) http.HandlerFunc {
+	if !config.Get().Features.EnableMetrics {
+		return h
 	}
+	return metrics.InstrumentHandler(route, provider, h)
 }
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ========== Chat Completions 客户端 ==========
+
+// ChatMessage 一条对话消息
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest 一次 chat completions 请求
+type ChatRequest struct {
+	Messages    []ChatMessage
+	MaxTokens   int
+	Temperature float64
+	// RequestIDHeader 非空时把当前请求 ID 写入该请求头
+	RequestIDHeader string
+}
+
+type chatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error,omitempty"`
+}
+
+// ChatClient OpenAI 兼容 chat completions 接口的客户端，翻译、提示词增强和审核分类器共用。
+// 只负责请求和响应，输出的清理和校验由调用方按各自的格式处理
+type ChatClient struct {
+	serviceURL string
+	apiKey     string
+	model      string
+}
+
+// NewChatClient 创建 chat completions 客户端
+func NewChatClient(serviceURL, apiKey, model string) *ChatClient {
+	return &ChatClient{serviceURL: serviceURL, apiKey: apiKey, model: model}
+}
+
+// Model 返回请求使用的模型
+func (c *ChatClient) Model() string {
+	return c.model
+}
+
+// Complete 发送一次请求，返回第一个候选的内容和 finish_reason
+func (c *ChatClient) Complete(ctx context.Context, r ChatRequest) (content, finishReason string, err error) {
+	jsonData, err := json.Marshal(chatCompletionRequest{
+		Model:       c.model,
+		Messages:    r.Messages,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serviceURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	SetRequestIDHeader(req, r.RequestIDHeader)
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out chatCompletionResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&out)
+	switch {
+	case decodeErr == nil && out.Error != nil:
+		return "", "", fmt.Errorf("chat completion API error: %s", out.Error.Message)
+	case resp.StatusCode >= 300:
+		return "", "", fmt.Errorf("chat completion API returned status %d", resp.StatusCode)
+	case decodeErr != nil:
+		return "", "", fmt.Errorf("decode response: %w", decodeErr)
+	case len(out.Choices) == 0:
+		return "", "", errors.New("no chat completion choices returned")
+	}
+	choice := out.Choices[0]
+	return choice.Message.Content, choice.FinishReason, nil
+}
@@ -1,20 +1,29 @@
 package utils	
 import (
-	"log"
+	"log/slog"
 	"net/http"
+
+	"svg-generator/internal/config"
 )
 
 // ========== 中间件和 CORS ==========
 // WithCommonHeaders CORS middleware and common headers
 func WithCommonHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[MIDDLEWARE] %s %s from %s - User-Agent: %s", r.Method, r.URL.Path, r.RemoteAddr, r.Header.Get("User-Agent"))
+		if config.Get().Logging.EnableRequestLogging {
+			slog.InfoContext(r.Context(), "request received",
+				"component", "middleware",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.Header.Get("User-Agent"))
+		}
 
 		// CORS
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		w.Header().Set("Access-Control-Expose-Headers", "X-Image-Id, X-Image-Width, X-Image-Height, Content-Disposition")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Requested-With, X-Request-Id, "+config.Get().Security.GetTenantHeader())
+		w.Header().Set("Access-Control-Expose-Headers", "X-Image-Id, X-Image-Width, X-Image-Height, X-Request-Id, X-Budget-Downgraded-From, X-Moderation, X-PII-Redacted, X-Was-Translated, X-Translator, X-Translation-Cached, X-Glossary-Version, X-Enhancement-Level, Content-Disposition")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// 其他安全/缓存
@@ -23,7 +32,7 @@ func WithCommonHeaders(next http.Handler) http.Handler {
 
 		// 预检请求直接返回
 		if r.Method == http.MethodOptions {
-			log.Printf("[MIDDLEWARE] CORS preflight request handled")
+			slog.DebugContext(r.Context(), "CORS preflight request handled", "component", "middleware")
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
@@ -36,8 +45,8 @@ func WithCommonHeaders(next http.Handler) http.Handler {
 func SetCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-	w.Header().Set("Access-Control-Expose-Headers", "X-Image-Id, X-Image-Width, X-Image-Height, Content-Disposition")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Requested-With, X-Request-Id, "+config.Get().Security.GetTenantHeader())
+	w.Header().Set("Access-Control-Expose-Headers", "X-Image-Id, X-Image-Width, X-Image-Height, X-Request-Id, X-Budget-Downgraded-From, X-Moderation, X-PII-Redacted, X-Was-Translated, X-Translator, X-Translation-Cached, X-Glossary-Version, X-Enhancement-Level, Content-Disposition")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.Header().Set("Cache-Control", "no-store")
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"svg-generator/internal/langdetect"
+)
+
+// minDictionaryCoverage 词典至少要覆盖的中文字符比例，低于该比例视为翻译失败
+const minDictionaryCoverage = 0.5
+
+// ErrDictionaryCoverage 词典覆盖的中文字符过少
+var ErrDictionaryCoverage = errors.New("dictionary does not cover enough of the text")
+
+// DictionaryTranslateService 离线词典翻译：按最长匹配逐词替换，作为远程翻译全部不可用时的兜底
+type DictionaryTranslateService struct {
+	file string
+}
+
+// NewDictionaryTranslateService 创建词典翻译器，file 为补充词条文件，可为空
+func NewDictionaryTranslateService(file string) *DictionaryTranslateService {
+	return &DictionaryTranslateService{file: file}
+}
+
+// Translate 翻译文本；已经是英文时原样返回，词典只收录中文词条，其他语言返回错误
+func (s *DictionaryTranslateService) Translate(ctx context.Context, text string) (string, error) {
+	if target := TargetLanguage(ctx); target != DefaultTargetLanguage {
+		return "", fmt.Errorf("dictionary does not support target language %q", target)
+	}
+	switch lang := langdetect.Detect(text).Lang; lang {
+	case "en", langdetect.Undetermined:
+		// 英文中夹杂的中文（如招牌文字）按词典翻译，其他文字无法处理
+		switch foreign := langdetect.ForeignScripts(text, "en", "zh"); {
+		case len(foreign) > 0:
+			return "", fmt.Errorf("dictionary does not support script %q", foreign[0])
+		case len(langdetect.ForeignScripts(text, "en")) == 0:
+			return text, nil
+		}
+	case "zh":
+	default:
+		return "", fmt.Errorf("dictionary does not support language %q", lang)
+	}
+	dict, err := loadDictionary(s.file)
+	if err != nil {
+		return "", err
+	}
+	return dict.translate(text)
+}
+
+// dictionary 词条表，maxLen 为最长词条的字符数
+type dictionary struct {
+	entries map[string]string
+	maxLen  int
+}
+
+func newDictionary(entries map[string]string) *dictionary {
+	d := &dictionary{entries: entries}
+	for term := range entries {
+		if n := utf8.RuneCountInString(term); n > d.maxLen {
+			d.maxLen = n
+		}
+	}
+	return d
+}
+
+// translate 从左到右按最长匹配替换中文词条，非中文片段原样保留，未收录的中文字符被丢弃
+func (d *dictionary) translate(text string) (string, error) {
+	runes := []rune(text)
+	var words []string
+	var latin strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(latin.String()); s != "" {
+			words = append(words, s)
+		}
+		latin.Reset()
+	}
+
+	chinese, covered := 0, 0
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if sep, ok := chinesePunctuation[r]; ok {
+			flush()
+			if len(words) > 0 {
+				words[len(words)-1] += sep
+			}
+			i++
+			continue
+		}
+		if !isChineseRune(r) {
+			latin.WriteRune(r)
+			i++
+			continue
+		}
+		flush()
+
+		matched := 0
+		for n := min(d.maxLen, len(runes)-i); n > 0; n-- {
+			if english, ok := d.entries[string(runes[i:i+n])]; ok {
+				if english != "" {
+					words = append(words, english)
+				}
+				matched = n
+				break
+			}
+		}
+		if matched == 0 {
+			chinese++
+			i++
+			continue
+		}
+		chinese += matched
+		covered += matched
+		i += matched
+	}
+	flush()
+
+	if chinese > 0 && float64(covered)/float64(chinese) < minDictionaryCoverage {
+		return "", fmt.Errorf("%w (%d of %d characters)", ErrDictionaryCoverage, covered, chinese)
+	}
+	return strings.TrimRight(strings.Join(words, " "), ",;:. "), nil
+}
+
+// isChineseRune 与 ContainsChinese 使用相同的范围
+func isChineseRune(r rune) bool {
+	return r >= 0x4e00 && r <= 0x9fff
+}
+
+// chinesePunctuation 中文标点转换为英文分隔符
+var chinesePunctuation = map[rune]string{
+	'，': ",", '、': ",", '。': ".", '；': ";", '：': ":", '！': ".", '？': ".",
+}
+
+// 补充词条文件按路径和修改时间缓存，文件更新后自动重新读取
+var (
+	dictionaryMu     sync.Mutex
+	dictionaryCache  = map[string]*loadedDictionary{}
+	builtinDictOnce  sync.Once
+	builtinDictValue *dictionary
+)
+
+type loadedDictionary struct {
+	modTime time.Time
+	dict    *dictionary
+}
+
+// loadDictionary 返回内置词条与补充词条合并后的词典，补充词条优先
+func loadDictionary(file string) (*dictionary, error) {
+	builtinDictOnce.Do(func() {
+		builtinDictValue = newDictionary(builtinDictionary)
+	})
+	if file == "" {
+		return builtinDictValue, nil
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("dictionary file: %w", err)
+	}
+	dictionaryMu.Lock()
+	defer dictionaryMu.Unlock()
+	if cached, ok := dictionaryCache[file]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.dict, nil
+	}
+
+	extra, err := readDictionaryFile(file)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]string, len(builtinDictionary)+len(extra))
+	for term, english := range builtinDictionary {
+		entries[term] = english
+	}
+	for term, english := range extra {
+		entries[term] = english
+	}
+	dict := newDictionary(entries)
+	dictionaryCache[file] = &loadedDictionary{modTime: info.ModTime(), dict: dict}
+	return dict, nil
+}
+
+// readDictionaryFile 读取每行 "中文<TAB>English" 的词条文件，忽略空行和 # 开头的注释行；
+// 译文为空表示删除该词
+func readDictionaryFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("dictionary file: %w", err)
+	}
+	defer f.Close()
+
+	entries := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimRightFunc(scanner.Text(), unicode.IsSpace)
+		if strings.TrimSpace(raw) == "" || strings.HasPrefix(strings.TrimSpace(raw), "#") {
+			continue
+		}
+		term, english, ok := strings.Cut(raw, "\t")
+		if !ok || strings.TrimSpace(term) == "" {
+			return nil, fmt.Errorf("%s:%d: expected \"term<TAB>translation\"", file, line)
+		}
+		entries[strings.TrimSpace(term)] = strings.TrimSpace(english)
+	}
+	return entries, scanner.Err()
+}
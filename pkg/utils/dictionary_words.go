@@ -0,0 +1,77 @@
+package utils
+
+// builtinDictionary 离线翻译的内置词条，覆盖图像生成提示词中常见的主体、属性、风格和场景。
+// 译文为空的词条（如结构助词）在翻译时被删除
+var builtinDictionary = map[string]string{
+	// 数量词和虚词
+	"一只": "a", "一个": "a", "一条": "a", "一朵": "a", "一棵": "a", "一座": "a", "一辆": "a", "一位": "a",
+	"一群": "a group of", "一对": "a pair of", "一些": "some", "两只": "two", "三只": "three", "很多": "many",
+	"的": "", "地": "", "得": "", "了": "", "着": "", "和": "and", "与": "and", "及": "and", "在": "in",
+	"上": "on", "里": "in", "中": "in", "下": "under", "旁边": "beside", "前面": "in front of", "后面": "behind",
+	"正在": "", "有": "with", "带着": "with", "拿着": "holding", "戴着": "wearing", "穿着": "wearing",
+	"非常": "very", "很": "very", "超级": "super",
+
+	// 动物
+	"猫": "cat", "小猫": "kitten", "猫咪": "cat", "狗": "dog", "小狗": "puppy", "狐狸": "fox", "兔子": "rabbit",
+	"熊猫": "panda", "熊": "bear", "老虎": "tiger", "狮子": "lion", "大象": "elephant", "长颈鹿": "giraffe",
+	"猴子": "monkey", "马": "horse", "牛": "cow", "羊": "sheep", "猪": "pig", "鸡": "chicken", "鸭子": "duck",
+	"鸟": "bird", "小鸟": "little bird", "鹰": "eagle", "猫头鹰": "owl", "企鹅": "penguin", "鱼": "fish",
+	"鲸鱼": "whale", "海豚": "dolphin", "蝴蝶": "butterfly", "蜜蜂": "bee", "龙": "dragon", "独角兽": "unicorn",
+	"恐龙": "dinosaur", "松鼠": "squirrel", "老鼠": "mouse", "乌龟": "turtle", "青蛙": "frog", "蛇": "snake",
+
+	// 人物
+	"人": "person", "男人": "man", "女人": "woman", "男孩": "boy", "女孩": "girl", "孩子": "child",
+	"小孩": "child", "婴儿": "baby", "老人": "old man", "宇航员": "astronaut", "机器人": "robot",
+	"医生": "doctor", "厨师": "chef", "科学家": "scientist", "公主": "princess", "骑士": "knight",
+	"超人": "superhero", "忍者": "ninja", "海盗": "pirate", "巫师": "wizard",
+
+	// 植物和食物
+	"花": "flower", "花朵": "flower", "玫瑰": "rose", "向日葵": "sunflower", "樱花": "cherry blossom",
+	"树": "tree", "大树": "big tree", "树叶": "leaves", "叶子": "leaf", "草": "grass", "草地": "meadow",
+	"竹子": "bamboo", "仙人掌": "cactus", "蘑菇": "mushroom", "苹果": "apple", "香蕉": "banana",
+	"西瓜": "watermelon", "草莓": "strawberry", "蛋糕": "cake", "咖啡": "coffee", "茶": "tea",
+	"面包": "bread", "披萨": "pizza", "汉堡": "burger", "冰淇淋": "ice cream",
+
+	// 物品
+	"房子": "house", "城堡": "castle", "塔": "tower", "桥": "bridge", "汽车": "car", "车": "car",
+	"自行车": "bicycle", "飞机": "airplane", "火箭": "rocket", "船": "boat", "火车": "train",
+	"书": "book", "杯子": "cup", "帽子": "hat", "眼镜": "glasses", "雨伞": "umbrella", "灯": "lamp",
+	"电脑": "computer", "手机": "phone", "相机": "camera", "吉他": "guitar", "钢琴": "piano",
+	"气球": "balloon", "礼物": "gift", "星星": "star", "爱心": "heart", "心": "heart", "皇冠": "crown",
+	"剑": "sword", "钥匙": "key", "时钟": "clock", "图标": "icon", "标志": "logo", "徽章": "badge",
+	"按钮": "button", "箭头": "arrow", "地图": "map", "旗帜": "flag",
+
+	// 自然和场景
+	"太阳": "sun", "月亮": "moon", "天空": "sky", "云": "cloud", "云朵": "clouds", "雨": "rain",
+	"雪": "snow", "雪花": "snowflake", "彩虹": "rainbow", "闪电": "lightning", "风": "wind", "火": "fire",
+	"水": "water", "山": "mountain", "山脉": "mountains", "河": "river", "湖": "lake", "海": "sea",
+	"大海": "ocean", "海滩": "beach", "岛": "island", "森林": "forest", "沙漠": "desert", "花园": "garden",
+	"城市": "city", "街道": "street", "乡村": "countryside", "宇宙": "universe", "太空": "outer space",
+	"星空": "starry sky", "地球": "earth", "星球": "planet", "夜晚": "night", "夜空": "night sky",
+	"日落": "sunset", "日出": "sunrise", "春天": "spring", "夏天": "summer", "秋天": "autumn", "冬天": "winter",
+	"背景": "background", "风景": "landscape", "场景": "scene",
+
+	// 颜色
+	"红色": "red", "橙色": "orange", "黄色": "yellow", "绿色": "green", "蓝色": "blue", "紫色": "purple",
+	"粉色": "pink", "粉红色": "pink", "黑色": "black", "白色": "white", "灰色": "gray", "棕色": "brown",
+	"金色": "golden", "银色": "silver", "红": "red", "黄": "yellow", "绿": "green", "蓝": "blue",
+	"紫": "purple", "黑": "black", "白": "white", "彩色": "colorful", "五颜六色": "colorful",
+	"黑白": "black and white", "渐变": "gradient",
+
+	// 形容词
+	"可爱": "cute", "可爱的": "cute", "漂亮": "beautiful", "美丽": "beautiful", "小": "small", "大": "big",
+	"小小": "tiny", "巨大": "giant", "快乐": "happy", "开心": "happy", "微笑": "smiling", "悲伤": "sad",
+	"生气": "angry", "睡觉": "sleeping", "奔跑": "running", "飞翔": "flying", "跳舞": "dancing",
+	"游泳": "swimming", "坐": "sitting", "站": "standing", "温馨": "cozy", "神秘": "mysterious",
+	"梦幻": "dreamy", "未来": "futuristic", "古老": "ancient", "现代": "modern", "复古": "retro",
+	"明亮": "bright", "黑暗": "dark", "简单": "simple", "简约": "minimalist", "精致": "detailed",
+	"圆形": "round", "方形": "square", "透明": "transparent", "发光": "glowing", "毛茸茸": "fluffy",
+
+	// 风格
+	"卡通": "cartoon", "动漫": "anime", "插画": "illustration", "插图": "illustration", "扁平": "flat",
+	"扁平化": "flat design", "矢量": "vector", "矢量图": "vector graphic", "线条": "line art",
+	"线稿": "line art", "水彩": "watercolor", "油画": "oil painting", "素描": "sketch", "像素": "pixel art",
+	"手绘": "hand-drawn", "写实": "realistic", "极简": "minimalist", "几何": "geometric", "抽象": "abstract",
+	"赛博朋克": "cyberpunk", "蒸汽朋克": "steampunk", "中国风": "Chinese style", "水墨": "ink wash",
+	"剪纸": "paper cut", "风格": "style", "图案": "pattern", "海报": "poster", "贴纸": "sticker",
+}
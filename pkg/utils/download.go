@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"svg-generator/internal/tracing"
+)
+
+// ========== 安全下载 ==========
+
+// 下载相关错误
+var (
+	ErrDownloadHostNotAllowed   = errors.New("download host not allowed")
+	ErrDownloadAddressBlocked   = errors.New("download address resolves to a blocked network")
+	ErrDownloadTooLarge         = errors.New("download exceeds maximum size")
+	ErrDownloadTooManyRedirects = errors.New("download exceeded maximum redirects")
+	ErrDownloadContentType      = errors.New("download content type not allowed")
+)
+
+// 允许下载的资源类型
+const (
+	ContentTypeSVG = "image/svg+xml"
+	ContentTypePNG = "image/png"
+)
+
+// 下载策略默认值
+const (
+	defaultDownloadMaxBytes     int64 = 20 << 20
+	defaultDownloadMaxRedirects       = 3
+)
+
+// DownloadPolicy 下载策略
+type DownloadPolicy struct {
+	// AllowedHosts 允许访问的主机，支持 "*.example.com" 通配；为空表示允许任意公网主机
+	AllowedHosts []string
+	// MaxBytes 响应体大小上限
+	MaxBytes int64
+	// MaxRedirects 最多跟随的重定向次数；0 使用默认值，负数表示不跟随
+	MaxRedirects int
+	// AllowedContentTypes 允许的内容类型；为空时默认 SVG 和 PNG
+	AllowedContentTypes []string
+	// AllowPrivateNetworks 允许访问内网/回环地址（仅用于本地开发）
+	AllowPrivateNetworks bool
+}
+
+// SafeDownloader 带主机白名单、内网地址拦截、大小和类型限制的下载器
+type SafeDownloader struct {
+	policy DownloadPolicy
+	client *http.Client
+}
+
+// DownloadResult 下载结果，调用方负责关闭 Body
+type DownloadResult struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+}
+
+// NewSafeDownloader 创建安全下载器
+func NewSafeDownloader(policy DownloadPolicy) *SafeDownloader {
+	if policy.MaxBytes <= 0 {
+		policy.MaxBytes = defaultDownloadMaxBytes
+	}
+	if policy.MaxRedirects < 0 {
+		policy.MaxRedirects = 0
+	} else if policy.MaxRedirects == 0 {
+		policy.MaxRedirects = defaultDownloadMaxRedirects
+	}
+	if len(policy.AllowedContentTypes) == 0 {
+		policy.AllowedContentTypes = []string{ContentTypeSVG, ContentTypePNG}
+	}
+
+	d := &SafeDownloader{policy: policy}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		// DNS 解析完成后、建立连接前校验目标IP，防止 DNS rebinding
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if policy.AllowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isBlockedIP(ip) {
+				return fmt.Errorf("%w: %s", ErrDownloadAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		// 不走环境代理，否则IP校验只会作用在代理地址上
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+
+	d.client = &http.Client{
+		Transport: tracing.NewTransport(transport),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > policy.MaxRedirects {
+				return ErrDownloadTooManyRedirects
+			}
+			return d.checkURL(req.URL)
+		},
+	}
+	return d
+}
+
+// Open 发起下载请求并校验响应，返回限制大小的响应体用于流式读取
+func (d *SafeDownloader) Open(ctx context.Context, fileURL string) (result *DownloadResult, err error) {
+	ctx, span := tracing.Start(ctx, "DownloadFile")
+	defer func() {
+		// 成功时Span在响应体关闭后结束，覆盖流式传输耗时
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+		}
+	}()
+
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download url: %w", err)
+	}
+	span.SetAttributes(tracing.String("server.address", u.Hostname()))
+	if err := d.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(d.policy.AllowedContentTypes, ", "))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, errors.New("fetch status: " + resp.Status)
+	}
+
+	if resp.ContentLength > d.policy.MaxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrDownloadTooLarge, resp.ContentLength, d.policy.MaxBytes)
+	}
+
+	// 读取前512字节用于内容嗅探，不影响后续流式读取
+	br := bufio.NewReaderSize(resp.Body, 512)
+	head, _ := br.Peek(512)
+
+	contentType := d.resolveContentType(resp.Header.Get("Content-Type"), head)
+	if contentType == "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %q", ErrDownloadContentType, resp.Header.Get("Content-Type"))
+	}
+
+	span.SetAttributes(
+		tracing.String("download.content_type", contentType),
+		tracing.Int64("download.content_length", resp.ContentLength),
+	)
+
+	return &DownloadResult{
+		Body: &limitedBody{
+			reader: br,
+			closer: resp.Body,
+			remain: d.policy.MaxBytes,
+			span:   span,
+			limit:  d.policy.MaxBytes,
+		},
+		ContentType:   contentType,
+		ContentLength: resp.ContentLength,
+	}, nil
+}
+
+// Download 下载完整内容到内存，适用于小文件
+func (d *SafeDownloader) Download(ctx context.Context, fileURL string) ([]byte, string, error) {
+	result, err := d.Open(ctx, fileURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer result.Body.Close()
+
+	b, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, result.ContentType, nil
+}
+
+// checkURL 校验协议和主机白名单
+func (d *SafeDownloader) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported download scheme: %q", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("download url must not contain credentials")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return errors.New("download url has no host")
+	}
+
+	// IP字面量直接校验，无需等待拨号
+	if ip := net.ParseIP(host); ip != nil && !d.policy.AllowPrivateNetworks && isBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrDownloadAddressBlocked, host)
+	}
+
+	if len(d.policy.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, pattern := range d.policy.AllowedHosts {
+		if matchHost(strings.ToLower(pattern), host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDownloadHostNotAllowed, host)
+}
+
+// resolveContentType 根据响应头和内容嗅探确定资源类型，不允许时返回空字符串
+func (d *SafeDownloader) resolveContentType(header string, head []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(header)
+	mediaType = strings.ToLower(mediaType)
+
+	for _, allowed := range d.policy.AllowedContentTypes {
+		if mediaType == allowed {
+			return allowed
+		}
+	}
+
+	// 部分CDN返回通用类型，此时根据内容判断
+	switch mediaType {
+	case "", "application/octet-stream", "binary/octet-stream", "text/plain", "text/xml", "application/xml":
+		sniffed := sniffImageType(head)
+		for _, allowed := range d.policy.AllowedContentTypes {
+			if sniffed == allowed {
+				return allowed
+			}
+		}
+	}
+	return ""
+}
+
+// sniffImageType 通过文件头识别 PNG 或 SVG
+func sniffImageType(head []byte) string {
+	if len(head) >= 8 && string(head[:8]) == "\x89PNG\r\n\x1a\n" {
+		return ContentTypePNG
+	}
+	text := strings.ToLower(strings.TrimSpace(string(head)))
+	if strings.HasPrefix(text, "<svg") || (strings.HasPrefix(text, "<?xml") && strings.Contains(text, "<svg")) {
+		return ContentTypeSVG
+	}
+	return ""
+}
+
+// matchHost 匹配主机名，"*.example.com" 匹配 example.com 的任意子域名
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return pattern == host
+}
+
+// blockedNetworks net.IP 的分类方法未覆盖、同样不允许访问的地址段
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络，部分系统把 0.x.x.x 路由到本机
+	"100.64.0.0/10", // 运营商级NAT
+	"192.0.0.0/24",  // IETF 协议分配
+	"198.18.0.0/15", // 基准测试
+	"240.0.0.0/4",   // 保留地址和广播地址
+	"64:ff9b::/96",  // NAT64，可以借此访问内网 IPv4 地址
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// isBlockedIP 判断IP是否属于内网、回环、链路本地等不允许访问的地址
+func isBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		inBlockedNetwork(ip)
+}
+
+func inBlockedNetwork(ip net.IP) bool {
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBody 超过大小上限时返回 ErrDownloadTooLarge 的响应体
+type limitedBody struct {
+	reader io.Reader
+	closer io.Closer
+	remain int64
+	limit  int64
+	span   *tracing.Span
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remain <= 0 {
+		// 再读一个字节判断是否恰好读完
+		var one [1]byte
+		n, err := b.reader.Read(one[:])
+		if n > 0 {
+			b.span.RecordError(ErrDownloadTooLarge)
+			return 0, ErrDownloadTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remain {
+		p = p[:b.remain]
+	}
+	n, err := b.reader.Read(p)
+	b.remain -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	b.span.SetAttributes(tracing.Int64("download.bytes", b.limit-b.remain))
+	b.span.End()
+	return b.closer.Close()
+}
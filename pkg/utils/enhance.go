@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/langdetect"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/tracing"
+	"svg-generator/internal/types"
+)
+
+// ========== 提示词增强 ==========
+
+// 增强结果未通过校验的原因
+var (
+	ErrEnhancementEmpty         = errors.New("enhanced prompt is empty")
+	ErrEnhancementTruncated     = errors.New("enhanced prompt was truncated by max_tokens")
+	ErrEnhancementTooLong       = errors.New("enhanced prompt exceeds max_output_length")
+	ErrEnhancementWrongLanguage = errors.New("enhanced prompt is not in the language of the prompt")
+)
+
+// enhanceClient 按配置快照共享的增强模型客户端
+var enhanceClient = config.NewDerived(func(cfg *config.Config) *ChatClient {
+	e := cfg.GetEnhancement()
+	return NewChatClient(e.ServiceURL, e.APIKey, e.Model)
+})
+
+var (
+	// enhancementPreamble 单独成行的引导语，如 "Here is the enhanced prompt:"、"好的，扩写后的提示词如下："
+	enhancementPreamble = regexp.MustCompile(`(?i)^(sure|okay|ok|certainly|here(?:'s| is)|below is|以下是|好的|扩写后)[^\n]{0,80}[:：]$`)
+	// enhancementNote 扩写结果之后单独成行的说明
+	enhancementNote = regexp.MustCompile(`(?i)^[(（]?\s*(note|notes|explanation|注|注意|说明)\s*[:：]`)
+)
+
+// cleanEnhancement 去掉思考过程、代码块、单独成行的引导语、结尾的说明和包裹结果的引号，
+// 并把换行和连续空白合并为一个空格。与译文不同，扩写结果中的 "English:"、"Prompt:" 等前缀可能是提示词本身的内容，不去掉
+func cleanEnhancement(content string) string {
+	var lines []string
+	for i, line := range strings.Split(stripReasoning(content), "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 && enhancementPreamble.MatchString(line) {
+			continue
+		}
+		if len(lines) > 0 && enhancementNote.MatchString(line) {
+			break
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(strings.Fields(trimQuotes(strings.Join(lines, "\n"))), " ")
+}
+
+// enhanceProviderHints 针对各 Provider 的扩写要点
+var enhanceProviderHints = map[types.Provider]string{
+	types.ProviderSVGIO: "目标模型生成扁平风格的矢量插画。描述主体的外观、姿态和表情，简洁的构图和配色，" +
+		"避免照片写实、复杂背景和画面中的文字。",
+	types.ProviderRecraft: "目标模型生成矢量图形。描述主体的外观和姿态、构图与视角、配色方案以及线条和填充的质感，" +
+		"强调单一主体、干净的矢量造型和透明背景。",
+	types.ProviderClaude: "目标模型是直接编写 SVG 代码的大语言模型。把主体拆解为可以用路径绘制的几何形状，" +
+		"说明各部分的形状、相对位置、层次和颜色（可以给出十六进制色值），避免难以用路径表达的纹理、光影和照片细节。",
+}
+
+// enhanceLevelHints 各增强级别的篇幅要求
+var enhanceLevelHints = map[string]string{
+	config.EnhanceLight:    "只补充最关键的视觉细节（外观、颜色、姿态）和简单的构图，保持简短：英文不超过 30 个单词，中文不超过 60 个字。",
+	config.EnhanceDetailed: "写成完整的画面描述：主体细节、姿态与表情、构图与视角、配色、风格和线条质感，英文不超过 120 个单词，中文不超过 200 个字。",
+}
+
+// EnhancePrompt 按 enhancement 配置调用大模型，把提示词扩写为针对 provider 的画面描述，
+// 使用与原提示词相同的语言。level 为 off 或未知时原样返回；扩写结果未通过校验时返回错误，由调用方使用原提示词
+func EnhancePrompt(ctx context.Context, prompt string, provider types.Provider, level string) (enhanced string, err error) {
+	levelHint, ok := enhanceLevelHints[level]
+	if !ok {
+		return prompt, nil
+	}
+	snapshot := config.Get()
+	cfg := snapshot.GetEnhancement()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "PromptEnhancer.Enhance", tracing.WithAttributes(
+		tracing.String("enhancement.model", cfg.Model),
+		tracing.String("enhancement.level", level),
+		tracing.Int("prompt.length", utf8.RuneCountInString(prompt)),
+	))
+	start := time.Now()
+	defer func() {
+		outcome := enhancementOutcome(err)
+		if err == nil && enhanced == prompt {
+			outcome = "unchanged"
+		}
+		metrics.PromptEnhancements.Inc(level, outcome)
+		metrics.PromptEnhancementDuration.Observe(time.Since(start).Seconds(), level)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	logger := logging.Component("enhance")
+	instruction := fmt.Sprintf(`请把以下图像生成提示词扩写为更详细的画面描述。%s%s
+保留原提示词的全部内容和意图，不要更换主体，不要添加原文没有的文字、标志或品牌。使用与原提示词相同的语言，只返回扩写后的提示词，不要其他解释：
+
+%s`, enhanceProviderHints[provider], levelHint, prompt)
+
+	content, finishReason, err := enhanceClient.Get(snapshot).Complete(ctx, ChatRequest{
+		Messages:    []ChatMessage{{Role: "user", Content: instruction}},
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	enhanced = cleanEnhancement(content)
+	if finishReason == "length" {
+		err = ErrEnhancementTruncated
+	} else {
+		err = checkEnhancement(prompt, enhanced, cfg.MaxOutputLength, snapshot.Translation.GetMinConfidence())
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "enhanced prompt rejected", "model", cfg.Model, "level", level,
+			"reason", err.Error(), "finish_reason", finishReason, "model_output", content)
+		return "", err
+	}
+	logger.DebugContext(ctx, "prompt enhanced", "provider", provider, "level", level, "prompt", prompt, "enhanced_prompt", enhanced)
+	return enhanced, nil
+}
+
+// checkEnhancement 校验扩写结果：非空、不超过 maxLength 个字符，且与原提示词语言一致。
+// 原提示词是英文时非拉丁文字的结果一律拒绝；其余情况只在检测到其他语言且置信度不低于 minConfidence 时拒绝
+func checkEnhancement(prompt, enhanced string, maxLength int, minConfidence float64) error {
+	if enhanced == "" {
+		return ErrEnhancementEmpty
+	}
+	if utf8.RuneCountInString(enhanced) > maxLength {
+		return ErrEnhancementTooLong
+	}
+	source := langdetect.Detect(prompt).Lang
+	detected := langdetect.Detect(enhanced)
+	switch {
+	case source == langdetect.Undetermined || detected.Lang == source || detected.Lang == langdetect.Undetermined:
+		return nil
+	case source == DefaultTargetLanguage && detected.Script != "Latin":
+		return ErrEnhancementWrongLanguage
+	case detected.Confidence >= minConfidence:
+		return ErrEnhancementWrongLanguage
+	}
+	return nil
+}
+
+// enhancementOutcome 返回增强结果对应的指标标签
+func enhancementOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "enhanced"
+	case errors.Is(err, ErrEnhancementEmpty):
+		return "empty"
+	case errors.Is(err, ErrEnhancementTruncated):
+		return "truncated"
+	case errors.Is(err, ErrEnhancementTooLong):
+		return "too_long"
+	case errors.Is(err, ErrEnhancementWrongLanguage):
+		return "wrong_language"
+	default:
+		return "failed"
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestCleanEnhancement(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "a fluffy orange cat, flat style", "a fluffy orange cat, flat style"},
+		{"think block", "<think>\nthe user wants more detail\n</think>\n\na fluffy orange cat", "a fluffy orange cat"},
+		{"unterminated think", "<think>\nlet me think", ""},
+		{"preamble", "Here is the enhanced prompt:\n\"a round orange cat made of simple circles\"", "a round orange cat made of simple circles"},
+		{"chinese preamble", "好的，扩写后的提示词如下：\n一只橘色的胖猫，扁平风格", "一只橘色的胖猫，扁平风格"},
+		{"label kept", "English: the word on the sign, bold letters", "English: the word on the sign, bold letters"},
+		{"prompt label kept", "Prompt: a poster titled Prompt", "Prompt: a poster titled Prompt"},
+		{"lines joined", "a fluffy orange cat,\n  sitting on a sofa,\n\nflat style", "a fluffy orange cat, sitting on a sofa, flat style"},
+		{"trailing note", "a fluffy orange cat\n\nNote: I added the sofa for context.", "a fluffy orange cat"},
+		{"code fence", "```\na fluffy orange cat\n```", "a fluffy orange cat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanEnhancement(tt.content); got != tt.want {
+				t.Errorf("cleanEnhancement(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"log/slog"
+	"net/http"
+
+	"svg-generator/internal/logging"
+	"svg-generator/internal/requestid"
+)
+
+// WithRequestID 接受或生成 X-Request-Id，写入上下文、日志字段和响应头
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.Generate()
+		}
+
+		ctx := requestid.NewContext(r.Context(), id)
+		ctx = logging.With(ctx, slog.String("request_id", id))
+		w.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetRequestIDHeader 将上下文中的请求ID转发给上游，header 为空时不转发
+func SetRequestIDHeader(req *http.Request, header string) {
+	if header == "" {
+		return
+	}
+	if id := requestid.FromContext(req.Context()); id != "" {
+		req.Header.Set(header, id)
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/tenant"
+)
+
+// APIKeyHeader 携带租户 API Key 的请求头，也可以使用 Authorization: Bearer <key>
+const APIKeyHeader = "X-API-Key"
+
+// WithTenant 按请求携带的凭据确定租户，写入上下文和日志字段：
+//   - 管理令牌：可以用 security.tenant_header 指定代表的租户，未指定时为 default
+//   - security.tenant_keys 中的 API Key：对应的租户
+//   - 未携带凭据：default 租户，不视为已认证；开启 security.enable_api_key_validation 时 /v1 接口返回 401
+//
+// 租户请求头本身不能证明身份，只对管理令牌生效
+func WithTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Get().Security
+		// 只有 /v1 接口按租户计费和限制；管理接口的令牌由 AdminAuth 校验并审计
+		protected := strings.HasPrefix(r.URL.Path, "/v1/") && r.Method != http.MethodOptions
+
+		id, authenticated := tenant.Default, false
+		key := RequestCredential(r)
+		switch {
+		case key == "":
+		case IsAdminToken(key):
+			authenticated = true
+			header := cfg.GetTenantHeader()
+			if h := r.Header.Get(header); h != "" {
+				if !tenant.IsValid(h) {
+					WriteError(w, http.StatusBadRequest, "invalid_tenant", "invalid tenant identifier",
+						header+" must be 1-128 characters of letters, digits and -_.:")
+					return
+				}
+				id = h
+			}
+		default:
+			if owner, ok := lookupTenantKey(cfg.TenantKeys, key); ok {
+				id, authenticated = owner, true
+			} else if protected {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				WriteError(w, http.StatusUnauthorized, "invalid_api_key", "invalid API key", nil)
+				return
+			}
+		}
+		if !authenticated && protected && cfg.EnableAPIKeyValidation {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			WriteError(w, http.StatusUnauthorized, "unauthorized", "missing API key",
+				"send the tenant API key as "+APIKeyHeader+" or Authorization: Bearer")
+			return
+		}
+
+		ctx := tenant.NewContext(r.Context(), id)
+		if authenticated {
+			ctx = tenant.NewAuthenticatedContext(r.Context(), id)
+		}
+		ctx = logging.With(ctx, slog.String("tenant", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestCredential 返回请求携带的 API Key：优先 X-API-Key，其次 Authorization: Bearer，都没有时返回空字符串。
+// 租户认证和审计记录使用同一规则
+func RequestCredential(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	return BearerToken(r)
+}
+
+// BearerToken 返回 Authorization: Bearer 中的令牌，没有时返回空字符串
+func BearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// IsAdminToken 判断令牌是否为有效的管理令牌，管理接口未开启时始终返回 false
+func IsAdminToken(token string) bool {
+	adminCfg := config.Get().Admin
+	if !adminCfg.Enabled || adminCfg.Token == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminCfg.Token)) == 1
+}
+
+// lookupTenantKey 查找 API Key 所属的租户，比较所有 Key 以免泄露匹配位置
+func lookupTenantKey(tenants []config.TenantKeyConfig, key string) (string, bool) {
+	owner, found := "", false
+	for _, tk := range tenants {
+		for _, k := range tk.Keys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 && !found {
+				owner, found = tk.Tenant, true
+			}
+		}
+	}
+	return owner, found
+}
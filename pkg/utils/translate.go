@@ -1,127 +1,147 @@
 package utils
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
-	
-	"svg-generator/internal/config")
+	"unicode/utf8"
 
-// ========== 翻译服务 ==========
-
-// OpenAI API 请求结构
-type openaiTranslateRequest struct {
-	Model       string                   `json:"model"`
-	Messages    []openaiTranslateMessage `json:"messages"`
-	MaxTokens   int                      `json:"max_tokens"`
-	Temperature float64                  `json:"temperature"`
-}
+	"svg-generator/internal/config"
+	"svg-generator/internal/langdetect"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+	"svg-generator/internal/tracing"
+)
 
-type openaiTranslateMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// OpenAI API 响应结构
-type openaiTranslateResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-	} `json:"error,omitempty"`
-}
+// ========== 翻译服务 ==========
 
 // TranslateService 翻译服务接口
 type TranslateService interface {
 	Translate(ctx context.Context, text string) (string, error)
 }
 
-// OpenAITranslateService OpenAI 翻译服务实现
+// OpenAITranslateService 使用 OpenAI 兼容 chat completions 接口的翻译服务
 type OpenAITranslateService struct {
-	apiKey string
-	model  string
+	client    *ChatClient
+	maxTokens int
 }
 
 // NewOpenAITranslateService 创建OpenAI翻译服务实例
-func NewOpenAITranslateService(apiKey string) *OpenAITranslateService {
+func NewOpenAITranslateService(serviceURL, apiKey, model string, maxTokens int) *OpenAITranslateService {
 	return &OpenAITranslateService{
-		apiKey: apiKey,
-		model:  config.AppConfig.Translation.DefaultModel,
+		client:    NewChatClient(serviceURL, apiKey, model),
+		maxTokens: maxTokens,
 	}
 }
 
-// Translate 翻译文本
-func (s *OpenAITranslateService) Translate(ctx context.Context, text string) (string, error) {
-	// 检测是否包含中文字符
-	if !ContainsChinese(text) {
-		log.Printf("[TRANSLATE] Text appears to be English already, skipping translation: %q", text)
+// Translate 翻译文本。模型输出经过清理（去掉 <think> 思考过程、引导语和引号）和校验
+// （未被截断、不为空、是英文），未通过时按 translation.max_retries 重试，仍失败时返回错误，
+// 由翻译器链尝试下一个翻译器或使用原文
+func (s *OpenAITranslateService) Translate(ctx context.Context, text string) (translated string, err error) {
+	cfg := config.Get().Translation
+	ctx, span := tracing.Start(ctx, "TranslateService.Translate", tracing.WithAttributes(
+		tracing.String("translation.model", s.client.Model()),
+		tracing.Int("prompt.length", utf8.RuneCountInString(text)),
+	))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	logger := logging.Component("translate")
+
+	// 已经是目标语言（或没有文字）时不翻译
+	target := TargetLanguage(ctx)
+	lang := langdetect.Detect(text).Lang
+	if isTargetLanguage(text, lang, target) {
+		logger.DebugContext(ctx, "text is already in the target language, skipping translation", "text", text, "target", target)
 		return text, nil
 	}
 
-	log.Printf("[TRANSLATE] Translating text: %q", text)
-
-	prompt := fmt.Sprintf(`请将以下文本翻译成英文，保持原意，适合用作AI图像生成的提示词。只返回翻译结果，不要其他解释：
-
-%s`, text)
+	logger.DebugContext(ctx, "translating text", "text", text, "target", target)
 
-	reqBody := openaiTranslateRequest{
-		Model: s.model,
-		Messages: []openaiTranslateMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		MaxTokens:   150,
-		Temperature: 0.3,
+	terms := glossaryTermsFrom(ctx)
+	var constraint string
+	if len(terms) > 0 {
+		constraint = fmt.Sprintf("以下术语是固定译法，必须原样保留在译文中，不要翻译或改写：%s\n", strings.Join(terms, "、"))
 	}
+	var prompt string
+	switch {
+	case isSegmented(ctx):
+		prompt = fmt.Sprintf(`请将以下带编号的文本片段翻译成%s。每个片段以 [[编号]] 开头、各占一行，译文必须保留编号和顺序，每行只写对应片段的译文。%s只返回翻译结果，不要其他解释：
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
-	}
+%s`, languageName(target), constraint, text)
+	case target == DefaultTargetLanguage:
+		prompt = fmt.Sprintf(`请将以下文本翻译成英文，保持原意，适合用作AI图像生成的提示词。%s只返回翻译结果，不要其他解释：
+
+%s`, constraint, text)
+	default:
+		prompt = fmt.Sprintf(`请将以下文本翻译成%s，保持原意。%s只返回翻译结果，不要其他解释：
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.AppConfig.Translation.ServiceURL, bytes.NewReader(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+%s`, languageName(target), constraint, text)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	for attempt := 0; ; attempt++ {
+		content, finishReason, err := s.client.Complete(ctx, ChatRequest{
+			Messages:        []ChatMessage{{Role: "user", Content: prompt}},
+			MaxTokens:       s.maxTokens,
+			Temperature:     0.3,
+			RequestIDHeader: cfg.RequestIDHeader,
+		})
+		if err != nil {
+			return "", err
+		}
+		translated, checkErr := acceptTranslation(text, content, finishReason, lang, target, terms, cfg.GetMinConfidence())
+		if checkErr == nil {
+			logger.InfoContext(ctx, "translation completed", "text", text, "translated", translated)
+			return translated, nil
+		}
 
-	resp, err := HTTPClient.Do(req)
-	if err != nil {
-		log.Printf("[TRANSLATE] HTTP request failed: %v", err)
-		return "", fmt.Errorf("http request: %w", err)
+		metrics.TranslationRejected.Inc(rejectionReason(checkErr))
+		logger.WarnContext(ctx, "translation rejected", "model", s.client.Model(), "attempt", attempt+1,
+			"reason", checkErr.Error(), "finish_reason", finishReason, "model_output", content)
+		if attempt >= cfg.MaxRetries {
+			return "", fmt.Errorf("%w (after %d attempts)", checkErr, attempt+1)
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	var translateResp openaiTranslateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&translateResp); err != nil {
-		return "", fmt.Errorf("decode response: %w", err)
-	}
+// isTargetLanguage 判断 lang 语言的文本是否无需翻译：没有可识别的文字，
+// 或已经是目标语言且不夹杂目标语言不使用的文字
+func isTargetLanguage(text, lang, target string) bool {
+	return lang == langdetect.Undetermined || (lang == target && len(langdetect.ForeignScripts(text, target)) == 0)
+}
 
-	if translateResp.Error != nil {
-		log.Printf("[TRANSLATE] API error: %s", translateResp.Error.Message)
-		return "", fmt.Errorf("translate API error: %s", translateResp.Error.Message)
-	}
+type targetLanguageKey struct{}
+
+// WithTargetLanguage 指定翻译的目标语言（ISO 639-1），未指定时为 DefaultTargetLanguage
+func WithTargetLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, targetLanguageKey{}, lang)
+}
 
-	if len(translateResp.Choices) == 0 {
-		return "", errors.New("no translation choices returned")
+// TargetLanguage 返回上下文中的目标语言
+func TargetLanguage(ctx context.Context) string {
+	if lang, _ := ctx.Value(targetLanguageKey{}).(string); lang != "" {
+		return lang
 	}
+	return DefaultTargetLanguage
+}
 
-	translated := strings.TrimSpace(translateResp.Choices[0].Message.Content)
-	log.Printf("[TRANSLATE] Translation result: %q -> %q", text, translated)
+// languageNames 翻译提示词中使用的语言名称，未收录的语言直接使用语言代码
+var languageNames = map[string]string{
+	"en": "英文", "zh": "简体中文", "ja": "日文", "ko": "韩文", "fr": "法文", "de": "德文", "es": "西班牙文",
+	"it": "意大利文", "pt": "葡萄牙文", "ru": "俄文", "ar": "阿拉伯文", "nl": "荷兰文", "pl": "波兰文",
+	"tr": "土耳其文", "vi": "越南文", "id": "印尼文", "th": "泰文", "uk": "乌克兰文", "he": "希伯来文",
+	"el": "希腊文", "hi": "印地文", "fa": "波斯文",
+}
 
-	return translated, nil
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return "语言代码为 " + code + " 的语言"
 }
 
 // ContainsChinese 检测文本是否包含中文字符
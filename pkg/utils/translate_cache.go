@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"svg-generator/internal/cache"
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+)
+
+// DefaultTargetLanguage 提示词翻译的目标语言
+const DefaultTargetLanguage = "en"
+
+// TranslateInfo 一次翻译的附加信息，由翻译服务填写，供处理器写入响应
+type TranslateInfo struct {
+	// Translator 产生译文的翻译器名称，命中缓存时为 cache
+	Translator string
+	// Cached 结果来自翻译缓存
+	Cached bool
+	// Offline 结果来自离线词典，质量较低，不写入缓存
+	Offline bool
+	// GlossaryVersion 命中术语时生效的术语表版本，如 "default@2,acme@5"
+	GlossaryVersion string
+}
+
+type translateInfoKey struct{}
+
+// WithTranslateInfo 在上下文中附加 TranslateInfo，翻译完成后读取
+func WithTranslateInfo(ctx context.Context) (context.Context, *TranslateInfo) {
+	info := &TranslateInfo{}
+	return context.WithValue(ctx, translateInfoKey{}, info), info
+}
+
+// translateInfoFrom 获取上下文中的 TranslateInfo，未附加时返回 nil
+func translateInfoFrom(ctx context.Context) *TranslateInfo {
+	info, _ := ctx.Value(translateInfoKey{}).(*TranslateInfo)
+	return info
+}
+
+// TranslationCacheKey 计算缓存键：规范化空白后的原文、产生译文的翻译器（见 TranslatorCacheID）和目标语言的 SHA-256
+func TranslationCacheKey(text, translator, target string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	sum := sha256.Sum256([]byte(translator + "\x00" + target + "\x00" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// TranslatorCacheID 缓存键中标识翻译器的部分：翻译器名称和模型，更换任一项后不再使用旧译文
+func TranslatorCacheID(name, model string) string {
+	return name + "/" + model
+}
+
+// cacheableTranslators 返回结果可以缓存的翻译器（离线词典除外），按翻译器链的顺序
+func cacheableTranslators(cfg config.TranslationConfig) []config.TranslatorConfig {
+	var out []config.TranslatorConfig
+	for _, tc := range cfg.GetTranslators() {
+		if tc.Type != config.TranslatorDictionary {
+			out = append(out, tc)
+		}
+	}
+	return out
+}
+
+// CachedTranslateService 带结果缓存的翻译服务，缓存故障时直接调用下层服务
+type CachedTranslateService struct {
+	next  TranslateService
+	store cache.Store
+}
+
+// NewCachedTranslateService 为 next 添加结果缓存
+func NewCachedTranslateService(next TranslateService, store cache.Store) *CachedTranslateService {
+	return &CachedTranslateService{next: next, store: store}
+}
+
+// Translate 先查缓存，未命中时翻译并写入缓存；原文无需翻译或译文来自离线词典时不缓存
+func (s *CachedTranslateService) Translate(ctx context.Context, text string) (string, error) {
+	cfg := config.Get().Translation
+	logger := logging.Component("translate")
+	info := translateInfoFrom(ctx)
+	if info == nil {
+		ctx, info = WithTranslateInfo(ctx)
+	}
+	// 键中包含产生译文的翻译器；命中术语时还包含术语表版本，术语表修改后不再使用旧译文
+	target := TargetLanguage(ctx)
+	keyFor := func(id string) string {
+		if info.GlossaryVersion != "" {
+			id += "+glossary:" + info.GlossaryVersion
+		}
+		return TranslationCacheKey(text, id, target)
+	}
+
+	// 按翻译器链的顺序查找，任一翻译器的译文都可以使用
+	translators := cacheableTranslators(cfg)
+	outcome := "miss"
+	for _, tc := range translators {
+		cached, ok, err := s.store.Get(ctx, keyFor(TranslatorCacheID(tc.Name, tc.Model)))
+		if err != nil {
+			outcome = "error"
+			logger.WarnContext(ctx, "translation cache lookup failed", "error", err)
+			break
+		}
+		if ok {
+			metrics.TranslationCache.Inc("hit")
+			info.Cached = true
+			info.Translator = "cache"
+			logger.DebugContext(ctx, "translation cache hit", "text", text, "translator", tc.Name)
+			return cached, nil
+		}
+	}
+	metrics.TranslationCache.Inc(outcome)
+
+	translated, err := s.next.Translate(ctx, text)
+	if err != nil || translated == text || info.Offline {
+		return translated, err
+	}
+	tc, ok := translatorByName(translators, info.Translator)
+	if !ok {
+		return translated, nil
+	}
+	if err := s.store.Set(ctx, keyFor(TranslatorCacheID(tc.Name, tc.Model)), translated, cfg.Cache.GetTTL()); err != nil {
+		logger.WarnContext(ctx, "translation cache store failed", "error", err)
+	}
+	return translated, nil
+}
+
+// Flush 清空翻译缓存
+func (s *CachedTranslateService) Flush() int {
+	return s.store.Flush()
+}
+
+// translationWarmEntry 预热文件中的一行
+type translationWarmEntry struct {
+	Text        string `json:"text"`
+	Translation string `json:"translation"`
+	Translator  string `json:"translator"`
+	Model       string `json:"model"`
+	Target      string `json:"target"`
+}
+
+// Warm 从 JSONL 文件预热缓存；translator 省略时为翻译器链中第一个可缓存的翻译器，
+// model 省略时为该翻译器的模型，target 省略时为英文。返回写入的条目数
+func (s *CachedTranslateService) Warm(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	cfg := config.Get().Translation
+	translators := cacheableTranslators(cfg)
+	loaded := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		var entry translationWarmEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return loaded, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if entry.Text == "" || entry.Translation == "" {
+			return loaded, fmt.Errorf("%s:%d: text and translation are required", path, line)
+		}
+		if entry.Translator == "" && len(translators) > 0 {
+			entry.Translator = translators[0].Name
+		}
+		if entry.Model == "" {
+			tc, ok := translatorByName(translators, entry.Translator)
+			if !ok {
+				return loaded, fmt.Errorf("%s:%d: unknown or uncacheable translator %q", path, line, entry.Translator)
+			}
+			entry.Model = tc.Model
+		}
+		if entry.Target == "" {
+			entry.Target = DefaultTargetLanguage
+		}
+		key := TranslationCacheKey(entry.Text, TranslatorCacheID(entry.Translator, entry.Model), entry.Target)
+		if err := s.store.Set(ctx, key, entry.Translation, cfg.Cache.GetTTL()); err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+	return loaded, scanner.Err()
+}
+
+// translatorByName 按名称查找翻译器配置
+func translatorByName(translators []config.TranslatorConfig, name string) (config.TranslatorConfig, bool) {
+	for _, tc := range translators {
+		if tc.Name == name {
+			return tc, true
+		}
+	}
+	return config.TranslatorConfig{}, false
+}
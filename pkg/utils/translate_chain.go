@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/metrics"
+)
+
+// ChainTranslateService 按 translation.translators 的顺序尝试翻译器，返回第一个成功的结果。
+// 翻译器链在每次调用时按当前配置构建，重新加载配置后立即生效
+type ChainTranslateService struct{}
+
+// NewChainTranslateService 创建翻译器链
+func NewChainTranslateService() *ChainTranslateService {
+	return &ChainTranslateService{}
+}
+
+// Translate 依次调用翻译器，每个翻译器受自身 timeout 约束；全部失败时返回各翻译器的错误
+func (s *ChainTranslateService) Translate(ctx context.Context, text string) (string, error) {
+	logger := logging.Component("translate")
+
+	var errs []error
+	for _, tc := range config.Get().Translation.GetTranslators() {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		translator, err := newTranslator(tc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", tc.Name, err))
+			continue
+		}
+
+		translateCtx, cancel := context.WithTimeout(ctx, tc.Timeout)
+		translated, err := translator.Translate(translateCtx, text)
+		cancel()
+		if err != nil {
+			metrics.TranslatorAttempts.Inc(tc.Name, "error")
+			logger.WarnContext(ctx, "translator failed, trying next", "translator", tc.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", tc.Name, err))
+			continue
+		}
+
+		metrics.TranslatorAttempts.Inc(tc.Name, "success")
+		if info := translateInfoFrom(ctx); info != nil {
+			info.Translator = tc.Name
+			info.Offline = tc.Type == config.TranslatorDictionary
+		}
+		return translated, nil
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no translators configured")
+	}
+	return "", errors.Join(errs...)
+}
+
+// newTranslator 按配置创建单个翻译器
+func newTranslator(tc config.TranslatorConfig) (TranslateService, error) {
+	switch tc.Type {
+	case config.TranslatorDictionary:
+		return NewDictionaryTranslateService(tc.DictionaryFile), nil
+	case config.TranslatorOpenAI:
+		if tc.APIKey == "" {
+			return nil, errors.New("api_key not set")
+		}
+		return NewOpenAITranslateService(tc.ServiceURL, tc.APIKey, tc.Model, tc.MaxTokens), nil
+	default:
+		return nil, fmt.Errorf("unknown translator type %q", tc.Type)
+	}
+}
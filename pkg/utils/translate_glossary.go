@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"context"
+	"strings"
+
+	"svg-generator/internal/config"
+	"svg-generator/internal/glossary"
+	"svg-generator/internal/logging"
+	"svg-generator/internal/tenant"
+)
+
+type glossaryTermsKey struct{}
+
+// withGlossaryTerms 附加翻译时必须原样保留的术语，由 OpenAITranslateService 写入提示词
+func withGlossaryTerms(ctx context.Context, terms []string) context.Context {
+	if len(terms) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, glossaryTermsKey{}, terms)
+}
+
+func glossaryTermsFrom(ctx context.Context) []string {
+	terms, _ := ctx.Value(glossaryTermsKey{}).([]string)
+	return terms
+}
+
+// GlossaryTranslateService 按租户术语表翻译：先把命中的术语替换为固定译法（不翻译的术语保留原文），
+// 再把这些术语作为约束交给下层翻译服务。术语表按当前配置和存储读取，修改后立即生效
+type GlossaryTranslateService struct {
+	next TranslateService
+}
+
+// NewGlossaryTranslateService 为 next 添加术语表处理，应位于缓存之外，使缓存键包含替换后的文本
+func NewGlossaryTranslateService(next TranslateService) *GlossaryTranslateService {
+	return &GlossaryTranslateService{next: next}
+}
+
+// Translate 替换术语后翻译；未开启术语表、目标语言不是英文或没有命中术语时直接调用下层服务
+func (s *GlossaryTranslateService) Translate(ctx context.Context, text string) (string, error) {
+	store := glossary.Default()
+	if !config.Get().Translation.Glossary.Enabled || store == nil || TargetLanguage(ctx) != DefaultTargetLanguage {
+		return s.next.Translate(ctx, text)
+	}
+	g := store.For(tenant.FromContext(ctx))
+	substituted, terms := g.Apply(text)
+	if len(terms) == 0 {
+		return s.next.Translate(ctx, text)
+	}
+
+	info := translateInfoFrom(ctx)
+	if info == nil {
+		ctx, info = WithTranslateInfo(ctx)
+	}
+	info.GlossaryVersion = g.Version
+	logger := logging.Component("translate")
+	logger.DebugContext(ctx, "glossary applied", "glossary_version", g.Version, "terms", terms, "text", substituted)
+
+	translated, err := s.next.Translate(withGlossaryTerms(ctx, terms), substituted)
+	if err != nil {
+		return "", err
+	}
+	for _, term := range terms {
+		if !strings.Contains(strings.ToLower(translated), strings.ToLower(term)) {
+			logger.WarnContext(ctx, "glossary term missing from translation", "term", term, "glossary_version", g.Version)
+		}
+	}
+	return translated, nil
+}
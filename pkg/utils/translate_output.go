@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"svg-generator/internal/langdetect"
+)
+
+// 译文未通过校验的原因，作为 svggen_translation_rejected_total 的 reason 标签
+var (
+	ErrTranslationEmpty         = errors.New("translation is empty")
+	ErrTranslationTruncated     = errors.New("translation was truncated by max_tokens")
+	ErrTranslationNotEnglish    = errors.New("translation is not English")
+	ErrTranslationWrongLanguage = errors.New("translation is not in the target language")
+	ErrTranslationUntranslated  = errors.New("translation repeats the source text")
+)
+
+// rejectionReason 返回校验错误对应的指标标签
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, ErrTranslationEmpty):
+		return "empty"
+	case errors.Is(err, ErrTranslationTruncated):
+		return "truncated"
+	case errors.Is(err, ErrTranslationNotEnglish):
+		return "not_english"
+	case errors.Is(err, ErrTranslationWrongLanguage):
+		return "wrong_language"
+	case errors.Is(err, ErrTranslationUntranslated):
+		return "untranslated"
+	default:
+		return "other"
+	}
+}
+
+var (
+	// reasoningBlock 推理模型输出的思考过程
+	reasoningBlock = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+	// reasoningClose 部分服务端会去掉开头的 <think>，只留下结束标签
+	reasoningClose = regexp.MustCompile(`(?is)^.*</(think|thinking|reasoning)>`)
+	// translationLabel 译文前的标签，如 "Translation:"、"**English:**"、"译文："
+	translationLabel = regexp.MustCompile(`(?i)^\**\s*(english translation|translation|translated (text|prompt)|english|prompt|here(?:'s| is)[^:：\n]{0,60}|译文|翻译结果|翻译|英文翻译|英文)\s*\**\s*[:：]\s*\**\s*`)
+	// translationPreamble 单独成行的引导语，如 "Here is the translation:"、"Sure! The English prompt is:"
+	translationPreamble = regexp.MustCompile(`(?i)^(sure|okay|ok|certainly|here|the|以下|好的|翻译)[^\n]{0,80}[:：]$`)
+	// translationNote 译文之后的说明行
+	translationNote = regexp.MustCompile(`(?i)^[(（]?\s*(note|notes|explanation|注|注意|说明|解释)\s*[:：]`)
+)
+
+// quotePairs 包裹整段译文时需要去掉的引号
+var quotePairs = [][2]string{
+	{`"`, `"`}, {`'`, `'`}, {"`", "`"}, {"“", "”"}, {"‘", "’"}, {"「", "」"}, {"『", "』"}, {"«", "»"},
+}
+
+// cleanTranslation 去掉推理模型的思考过程、引导语、标签、说明和包裹译文的引号、代码块
+func cleanTranslation(content string) string {
+	text := stripReasoning(content)
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			if len(lines) > 0 {
+				lines = append(lines, line)
+			}
+			continue
+		case len(lines) == 0 && translationPreamble.MatchString(line):
+			continue
+		case len(lines) > 0 && translationNote.MatchString(line):
+			// 说明之后的内容都不是译文
+			return trimQuotes(strings.TrimSpace(strings.Join(lines, "\n")))
+		}
+		if len(lines) == 0 {
+			line = translationLabel.ReplaceAllString(line, "")
+		}
+		lines = append(lines, line)
+	}
+	return trimQuotes(strings.TrimSpace(strings.Join(lines, "\n")))
+}
+
+// stripReasoning 去掉推理模型的思考过程和包裹输出的代码块；思考过程未结束就被截断时返回空串
+func stripReasoning(content string) string {
+	text := reasoningBlock.ReplaceAllString(content, "")
+	text = reasoningClose.ReplaceAllString(text, "")
+	if i := strings.Index(strings.ToLower(text), "<think>"); i >= 0 {
+		// 思考过程未结束就被截断，没有输出结果
+		text = text[:i]
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 && !strings.ContainsAny(text[:nl], " \t") {
+			text = text[nl+1:] // 语言标记，如 ```text
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	return text
+}
+
+// trimQuotes 去掉包裹整段文本的成对引号，可以嵌套
+func trimQuotes(text string) string {
+	for {
+		trimmed := false
+		for _, q := range quotePairs {
+			if len(text) > len(q[0])+len(q[1]) && strings.HasPrefix(text, q[0]) && strings.HasSuffix(text, q[1]) {
+				inner := text[len(q[0]) : len(text)-len(q[1])]
+				if q[0] == q[1] && strings.Contains(inner, q[0]) {
+					// "a" and "b" 这类内部还有同样引号的文本不是整体被引用
+					continue
+				}
+				text = strings.TrimSpace(inner)
+				trimmed = true
+			}
+		}
+		if !trimmed {
+			return text
+		}
+	}
+}
+
+// acceptTranslation 清理并校验一次模型输出；finish_reason 为 length 时输出被 max_tokens 截断，不再检查内容
+func acceptTranslation(source, content, finishReason, sourceLang, target string, protected []string, minConfidence float64) (string, error) {
+	translated := cleanTranslation(content)
+	if finishReason == "length" {
+		return translated, ErrTranslationTruncated
+	}
+	return translated, checkTranslation(source, translated, sourceLang, target, protected, minConfidence)
+}
+
+// checkTranslation 校验清理后的译文：非空、不是原文照抄、去掉保留术语后是目标语言。
+// 目标为英文时非拉丁文字的译文一律拒绝；其余情况只在译文检测为原文语言且置信度不低于 minConfidence 时拒绝，
+// 避免把含有专有名词的译文误判为其他语言
+func checkTranslation(source, translated, sourceLang, target string, protected []string, minConfidence float64) error {
+	if translated == "" {
+		return ErrTranslationEmpty
+	}
+	if strings.Join(strings.Fields(translated), " ") == strings.Join(strings.Fields(source), " ") {
+		return ErrTranslationUntranslated
+	}
+	rest := translated
+	for _, term := range protected {
+		rest = strings.ReplaceAll(rest, term, " ")
+	}
+	detected := langdetect.Detect(rest)
+	wrong := ErrTranslationWrongLanguage
+	if target == DefaultTargetLanguage {
+		wrong = ErrTranslationNotEnglish
+	}
+	switch {
+	case detected.Lang == target || detected.Lang == langdetect.Undetermined:
+		return nil
+	case target == DefaultTargetLanguage && detected.Script != "Latin":
+		return wrong
+	case detected.Lang == sourceLang && detected.Confidence >= minConfidence:
+		return wrong
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanTranslation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "a cute cat", "a cute cat"},
+		{"think block", "<think>\n用户要求翻译“一只猫”。\n</think>\n\na cute cat", "a cute cat"},
+		{"thinking block", "<THINKING>translate it</THINKING>a cute cat", "a cute cat"},
+		{"several blocks", "<think>a</think><reasoning>b</reasoning>\na cute cat", "a cute cat"},
+		{"lone closing tag", "好的，用户要翻译一只猫。\n</think>\n\na cute cat", "a cute cat"},
+		{"unterminated think", "<think>\n用户要求翻译，我先分析一下", ""},
+		{"unterminated think after text", "a cute cat\n<think>wait", "a cute cat"},
+		{"preamble line", "Here is the translation:\na cute cat", "a cute cat"},
+		{"preamble with blank line", "Sure! The English prompt is:\n\na cute cat", "a cute cat"},
+		{"chinese preamble", "以下是翻译结果：\na cute cat", "a cute cat"},
+		{"inline label", "Here's the translation: a cute cat", "a cute cat"},
+		{"bold label", "**Translation:** a cute cat", "a cute cat"},
+		{"english label", "English: a cute cat", "a cute cat"},
+		{"chinese label", "译文：a cute cat", "a cute cat"},
+		{"label only on first line", "a cat\nEnglish: text on a sign", "a cat\nEnglish: text on a sign"},
+		{"trailing note", "a cute cat\n\nNote: \"可爱\" means cute.", "a cute cat"},
+		{"chinese note", "a cute cat\n（注：保留了原意）", "a cute cat"},
+		{"code fence", "```text\na cute cat\n```", "a cute cat"},
+		{"code fence without language", "```\na cute cat\n```", "a cute cat"},
+		{"double quotes", `"a cute cat"`, "a cute cat"},
+		{"curly quotes", "“a cute cat”", "a cute cat"},
+		{"nested quotes", "\"“a cute cat”\"", "a cute cat"},
+		{"label and quotes", "Translation: 「a cute cat」", "a cute cat"},
+		{"inner quotes kept", "“a sign that says \"welcome\"”", `a sign that says "welcome"`},
+		{"separately quoted parts", `"a cat" and "a dog"`, `"a cat" and "a dog"`},
+		{"single quote inside", "'a cat's toy'", "'a cat's toy'"},
+		{"multiline", "a cute cat\nsitting on a sofa", "a cute cat\nsitting on a sofa"},
+		{"segments", "[[1]] Title\n[[2]] Total", "[[1]] Title\n[[2]] Total"},
+		{"everything", "<think>hmm</think>\nSure, here it is:\n\n**English:** \"a cute cat\"\n\nNote: literal translation", "a cute cat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanTranslation(tt.content); got != tt.want {
+				t.Errorf("cleanTranslation(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTranslation(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		translated string
+		sourceLang string
+		target     string
+		protected  []string
+		want       error
+	}{
+		{"english", "一只可爱的猫", "a cute cat", "zh", "en", nil, nil},
+		{"empty", "一只可爱的猫", "", "zh", "en", nil, ErrTranslationEmpty},
+		{"copied source", "一只可爱的猫", "一只可爱的猫", "zh", "en", nil, ErrTranslationUntranslated},
+		{"copied with other spacing", "un chat  mignon", "un chat mignon\n", "fr", "en", nil, ErrTranslationUntranslated},
+		{"still chinese", "一只可爱的猫", "一只可爱的小猫咪", "zh", "en", nil, ErrTranslationNotEnglish},
+		{"cyrillic for english target", "милый кот", "милая кошка", "ru", "en", nil, ErrTranslationNotEnglish},
+		{"protected term", "小米咖啡店的标志", "a logo for 小米 coffee shop", "zh", "en", []string{"小米"}, nil},
+		{"only protected term", "小米", "小米 ", "zh", "en", []string{"小米"}, ErrTranslationUntranslated},
+		{"no letters", "三只猫", "3 🐱", "zh", "en", nil, nil},
+		{"japanese target", "一只可爱的猫", "かわいい猫", "zh", "ja", nil, nil},
+		{"source language for japanese target", "a cute cat sitting on the sofa", "a cute cat sitting on a sofa", "en", "ja", nil, ErrTranslationWrongLanguage},
+		{"other latin language is tolerated", "一只猫", "un chat", "zh", "de", nil, nil},
+		{"low confidence source language", "Tokyo", "Tokyo Tower", "en", "ja", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTranslation(tt.source, tt.translated, tt.sourceLang, tt.target, tt.protected, 0.3)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("checkTranslation(%q, %q) = %v, want %v", tt.source, tt.translated, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptTranslation(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		finishReason string
+		want         string
+		wantErr      error
+	}{
+		{"clean output", "<think>ok</think>\nHere is the translation:\n\"a cute cat\"", "stop", "a cute cat", nil},
+		{"truncated in think", "<think>\n用户要求翻译，我需要考虑", "length", "", ErrTranslationTruncated},
+		{"truncated translation", "a cute cat sitting on", "length", "a cute cat sitting on", ErrTranslationTruncated},
+		{"think never closed", "<think>\n用户要求翻译", "stop", "", ErrTranslationEmpty},
+		{"only reasoning", "<think>done</think>", "stop", "", ErrTranslationEmpty},
+		{"answer in chinese", "</think>\n一只可爱的小猫咪", "stop", "一只可爱的小猫咪", ErrTranslationNotEnglish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := acceptTranslation("一只可爱的猫", tt.content, tt.finishReason, "zh", "en", nil, 0.3)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("acceptTranslation(%q, %q) = %q, %v, want %q, %v", tt.content, tt.finishReason, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRejectionReason(t *testing.T) {
+	tests := map[error]string{
+		ErrTranslationEmpty:         "empty",
+		ErrTranslationTruncated:     "truncated",
+		ErrTranslationNotEnglish:    "not_english",
+		ErrTranslationWrongLanguage: "wrong_language",
+		ErrTranslationUntranslated:  "untranslated",
+		errors.New("boom"):          "other",
+	}
+	for err, want := range tests {
+		if got := rejectionReason(err); got != want {
+			t.Errorf("rejectionReason(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
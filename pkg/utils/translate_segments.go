@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"svg-generator/internal/langdetect"
+	"svg-generator/internal/logging"
+)
+
+// ErrSegmentsMismatch 批量译文中的编号与原文片段对不上
+var ErrSegmentsMismatch = errors.New("translated segments do not match the source segments")
+
+type segmentedKey struct{}
+
+// withSegmented 标记待翻译文本为 [[n]] 编号的片段列表，翻译器据此要求模型保留编号
+func withSegmented(ctx context.Context) context.Context {
+	return context.WithValue(ctx, segmentedKey{}, true)
+}
+
+func isSegmented(ctx context.Context) bool {
+	segmented, _ := ctx.Value(segmentedKey{}).(bool)
+	return segmented
+}
+
+// segmentMarker 片段编号，译文中允许编号前后有空白
+var segmentMarker = regexp.MustCompile(`\[\[(\d+)\]\]`)
+
+// TranslateSegments 把需要翻译的短文本拼成一次批量翻译，每个片段一行并以 [[n]] 编号；
+// 译文的编号对不上时退回逐个翻译。语言按片段检测，空白片段和已经是目标语言的片段原样返回，
+// 都不需要翻译时不调用翻译服务。片段内的换行和连续空白合并为一个空格
+func TranslateSegments(ctx context.Context, svc TranslateService, segments []string) ([]string, error) {
+	target := TargetLanguage(ctx)
+	out := make([]string, len(segments))
+	var batch strings.Builder
+	var indexes []int
+	for i, s := range segments {
+		s = strings.Join(strings.Fields(s), " ")
+		out[i] = s
+		if s == "" || isTargetLanguage(s, langdetect.Detect(s).Lang, target) {
+			continue
+		}
+		indexes = append(indexes, i)
+		fmt.Fprintf(&batch, "[[%d]] %s\n", len(indexes), s)
+	}
+	if len(indexes) == 0 {
+		return out, nil
+	}
+
+	translated, err := svc.Translate(withSegmented(ctx), strings.TrimRight(batch.String(), "\n"))
+	if err != nil {
+		return nil, err
+	}
+	parts, err := splitSegments(translated, len(indexes))
+	if err == nil {
+		for n, i := range indexes {
+			out[i] = parts[n]
+		}
+		return out, nil
+	}
+
+	logging.Component("translate").WarnContext(ctx, "batch translation lost segment markers, translating one by one",
+		"segments", len(indexes), "error", err)
+	for _, i := range indexes {
+		if out[i], err = svc.Translate(ctx, out[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// splitSegments 按 [[n]] 编号拆分批量译文；原文已是目标语言时翻译服务原样返回，同样可以拆分
+func splitSegments(text string, n int) ([]string, error) {
+	matches := segmentMarker.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) != n {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrSegmentsMismatch, n, len(matches))
+	}
+	parts := make([]string, n)
+	for k, m := range matches {
+		num, _ := strconv.Atoi(text[m[2]:m[3]])
+		if num != k+1 {
+			return nil, fmt.Errorf("%w: segment %d is numbered %d", ErrSegmentsMismatch, k+1, num)
+		}
+		end := len(text)
+		if k+1 < len(matches) {
+			end = matches[k+1][0]
+		}
+		if parts[k] = strings.Join(strings.Fields(text[m[1]:end]), " "); parts[k] == "" {
+			return nil, fmt.Errorf("%w: segment %d is empty", ErrSegmentsMismatch, k+1)
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTranslator 按词表逐行替换，记录收到的每次请求
+type fakeTranslator struct {
+	words map[string]string
+	calls []string
+}
+
+func (f *fakeTranslator) Translate(ctx context.Context, text string) (string, error) {
+	f.calls = append(f.calls, text)
+	for from, to := range f.words {
+		text = strings.ReplaceAll(text, from, to)
+	}
+	return text, nil
+}
+
+func TestTranslateSegments(t *testing.T) {
+	words := map[string]string{"标题": "Title", "合计": "Total", "一只猫": "a cat"}
+	tests := []struct {
+		name     string
+		segments []string
+		want     []string
+		calls    []string
+	}{
+		{
+			name:     "only foreign segments are sent",
+			segments: []string{"Quarterly revenue report for the sales team", "Total of all regions", "标题"},
+			want:     []string{"Quarterly revenue report for the sales team", "Total of all regions", "Title"},
+			calls:    []string{"[[1]] 标题"},
+		},
+		{
+			name:     "already in target language",
+			segments: []string{"Quarterly revenue report", "Total of all regions", "2024", " "},
+			want:     []string{"Quarterly revenue report", "Total of all regions", "2024", ""},
+		},
+		{
+			name:     "mixed segment is translated",
+			segments: []string{"Q3 合计", "a cute cat", "一只猫\n在睡觉"},
+			want:     []string{"Q3 Total", "a cute cat", "a cat 在睡觉"},
+			calls:    []string{"[[1]] Q3 合计\n[[2]] 一只猫 在睡觉"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeTranslator{words: words}
+			got, err := TranslateSegments(context.Background(), svc, tt.segments)
+			if err != nil {
+				t.Fatalf("TranslateSegments: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TranslateSegments = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(svc.calls, tt.calls) {
+				t.Errorf("translator calls = %q, want %q", svc.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestTranslateSegmentsTargetLanguage(t *testing.T) {
+	svc := &fakeTranslator{words: map[string]string{"a cat": "一只猫"}}
+	ctx := WithTargetLanguage(context.Background(), "zh")
+	got, err := TranslateSegments(ctx, svc, []string{"标题", "a cat"})
+	if err != nil {
+		t.Fatalf("TranslateSegments: %v", err)
+	}
+	if want := []string{"标题", "一只猫"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TranslateSegments = %q, want %q", got, want)
+	}
+	if want := []string{"[[1]] a cat"}; !reflect.DeepEqual(svc.calls, want) {
+		t.Errorf("translator calls = %q, want %q", svc.calls, want)
+	}
+}
+
+func TestSplitSegments(t *testing.T) {
+	parts, err := splitSegments("[[1]] Title\n [[2]]  Total  of all\n", 2)
+	if err != nil || !reflect.DeepEqual(parts, []string{"Title", "Total of all"}) {
+		t.Errorf("splitSegments = %q, %v", parts, err)
+	}
+	for _, text := range []string{"[[1]] Title", "[[2]] Title\n[[1]] Total", "[[1]] Title\n[[2]]"} {
+		if _, err := splitSegments(text, 2); err == nil {
+			t.Errorf("splitSegments(%q) succeeded, want ErrSegmentsMismatch", text)
+		}
+	}
+}